@@ -0,0 +1,111 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"log"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/Linxhhh/webook/config"
+	"github.com/Linxhhh/webook/internal/events"
+	"github.com/Linxhhh/webook/internal/job"
+	"github.com/Linxhhh/webook/internal/service"
+	"github.com/gin-gonic/gin"
+)
+
+// App 应用的生命周期：启动消费者、后台任务和 Web 服务，收到退出信号后依次关闭
+type App struct {
+	Server    *gin.Engine
+	Consumers []events.Consumer
+	Jobs      []job.Job
+	AdminSvc  *service.AdminService
+	AdminCfg  config.AdminConfig
+}
+
+/*
+Run 运行应用：
+先初始化配置中的管理员，再启动所有消费者和后台任务，最后启动 Web 服务；收到 SIGINT/SIGTERM 后，
+先停止接收新请求，再关闭后台任务和消费组，并等待正在处理的消息完成
+*/
+func (a *App) Run(addr string) error {
+
+	// 初始化管理员
+	if err := a.bootstrapAdmins(); err != nil {
+		return err
+	}
+
+	// 启动消费者，失败时关闭已经启动的消费者
+	for i, consumer := range a.Consumers {
+		if err := consumer.Start(); err != nil {
+			closeAll(nil, a.Consumers[:i])
+			return err
+		}
+	}
+
+	// 启动后台任务，失败时关闭已经启动的后台任务和所有消费者
+	for i, j := range a.Jobs {
+		if err := j.Start(); err != nil {
+			closeAll(a.Jobs[:i], a.Consumers)
+			return err
+		}
+	}
+
+	// 启动 Web 服务
+	srv := &http.Server{Addr: addr, Handler: a.Server}
+	errCh := make(chan error, 1)
+	go func() {
+		if err := srv.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			errCh <- err
+		}
+	}()
+
+	// 等待退出信号
+	quit := make(chan os.Signal, 1)
+	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
+	var runErr error
+	select {
+	case sig := <-quit:
+		log.Println("收到退出信号", sig)
+	case runErr = <-errCh:
+		log.Println("Web 服务异常退出", runErr)
+	}
+
+	// 关闭 Web 服务
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	if err := srv.Shutdown(ctx); err != nil {
+		log.Println("关闭 Web 服务失败", err)
+	}
+
+	// 关闭后台任务和消费者
+	closeAll(a.Jobs, a.Consumers)
+	return runErr
+}
+
+// bootstrapAdmins 把配置中的用户设置为管理员
+func (a *App) bootstrapAdmins() error {
+	if len(a.AdminCfg.BootstrapUids) == 0 {
+		return nil
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	return a.AdminSvc.Bootstrap(ctx, a.AdminCfg.BootstrapUids)
+}
+
+// closeAll 先关闭后台任务，再关闭消费者，关闭失败时只记录日志
+func closeAll(jobs []job.Job, consumers []events.Consumer) {
+	for _, j := range jobs {
+		if err := j.Close(); err != nil {
+			log.Println("关闭后台任务失败", err)
+		}
+	}
+	for _, consumer := range consumers {
+		if err := consumer.Close(); err != nil {
+			log.Println("关闭消费者失败", err)
+		}
+	}
+}
@@ -0,0 +1,46 @@
+package main
+
+import (
+	"flag"
+	"log"
+	"strings"
+
+	"github.com/IBM/sarama"
+	samarax "github.com/Linxhhh/webook/pkg/saramax"
+)
+
+/*
+dlqreplay 重放死信队列：
+go run ./cmd/dlqreplay -topic article_feed.dlq
+*/
+func main() {
+	brokers := flag.String("brokers", "localhost:9094", "Kafka 地址，多个地址使用逗号分隔")
+	topic := flag.String("topic", "", "死信队列的 topic，例如 article_feed.dlq")
+	group := flag.String("group", "dlq_replay", "记录重放进度的消费组")
+	flag.Parse()
+
+	if !strings.HasSuffix(*topic, samarax.DLQSuffix) {
+		log.Fatalf("topic 必须以 %s 结尾", samarax.DLQSuffix)
+	}
+
+	cfg := sarama.NewConfig()
+	cfg.Producer.Return.Successes = true
+	cfg.Consumer.Offsets.Initial = sarama.OffsetOldest
+	client, err := sarama.NewClient(strings.Split(*brokers, ","), cfg)
+	if err != nil {
+		log.Fatalln(err)
+	}
+	defer client.Close()
+
+	producer, err := sarama.NewSyncProducerFromClient(client)
+	if err != nil {
+		log.Fatalln(err)
+	}
+	defer producer.Close()
+
+	n, err := samarax.ReplayDLQ(client, producer, *topic, *group)
+	if err != nil {
+		log.Fatalf("重放了 %d 条消息后失败：%s", n, err)
+	}
+	log.Printf("重放了 %d 条消息", n)
+}
@@ -0,0 +1,459 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Config 应用配置，从 YAML 文件加载，环境变量优先级更高
+type Config struct {
+	Server     ServerConfig     `yaml:"server"`
+	DB         DBConfig         `yaml:"db"`
+	Redis      RedisConfig      `yaml:"redis"`
+	Kafka      KafkaConfig      `yaml:"kafka"`
+	JWT        JWTConfig        `yaml:"jwt"`
+	OAuth2     OAuth2Config     `yaml:"oauth2"`
+	Email      EmailConfig      `yaml:"email"`
+	SMS        SMSConfig        `yaml:"sms"`
+	RateLimit  RateLimitConfig  `yaml:"rate_limit"`
+	Moderation ModerationConfig `yaml:"moderation"`
+	Admin      AdminConfig      `yaml:"admin"`
+	Report     ReportConfig     `yaml:"report"`
+}
+
+type ServerConfig struct {
+	Addr string `yaml:"addr"`
+}
+
+// DBConfig MySQL 主从配置，Slaves 为空时读请求也走主库
+type DBConfig struct {
+	Master string   `yaml:"master"`
+	Slaves []string `yaml:"slaves"`
+}
+
+type RedisConfig struct {
+	Addr     string `yaml:"addr"`
+	Password string `yaml:"password"`
+	DB       int    `yaml:"db"`
+}
+
+type KafkaConfig struct {
+	Brokers   []string        `yaml:"brokers"`
+	ReadBatch ReadBatchConfig `yaml:"read_batch"`
+}
+
+// ReadBatchConfig 阅读事件批量消费，攒够 Size 条消息，或者等待 Interval 之后，写入一次
+type ReadBatchConfig struct {
+	Size     int           `yaml:"size"`
+	Interval time.Duration `yaml:"interval"`
+}
+
+// JWTConfig Expire 为短令牌有效期，RefreshExpire 为长令牌有效期
+type JWTConfig struct {
+	Key           string        `yaml:"key"`
+	Expire        time.Duration `yaml:"expire"`
+	RefreshExpire time.Duration `yaml:"refresh_expire"`
+}
+
+// EmailConfig SMTP 配置，Host 为空时只在本地打印邮件
+type EmailConfig struct {
+	Host     string `yaml:"host"`
+	Port     int    `yaml:"port"`
+	Username string `yaml:"username"`
+	Password string `yaml:"password"`
+	From     string `yaml:"from"`
+}
+
+/*
+SMSConfig 短信配置：
+服务商按照配置顺序组成故障转移链，为空时只在本地打印短信；
+每个服务商使用 Rate/Burst 的令牌桶限流，全部失败的短信存入数据库，每隔 RetryInterval 重试一次
+*/
+type SMSConfig struct {
+	Providers     []SMSProviderConfig `yaml:"providers"`
+	Strategy      string              `yaml:"strategy"` // failover：轮询故障转移；response_time：按照响应时间切换
+	Rate          float64             `yaml:"rate"`
+	Burst         int                 `yaml:"burst"`
+	FailThreshold int32               `yaml:"fail_threshold"`
+	Cooldown      time.Duration       `yaml:"cooldown"`
+	MaxLatency    time.Duration       `yaml:"max_latency"`
+	RetryInterval time.Duration       `yaml:"retry_interval"`
+	RetryMax      int                 `yaml:"retry_max"`
+}
+
+/*
+SMSProviderConfig 短信服务商配置，Name 用于区分限流的令牌桶，为空时使用 Type：
+腾讯云和阿里云使用 SecretId/SecretKey 作为访问密钥，阿里云的 Endpoint 为空时使用默认地址；
+http 类型把短信以 JSON 格式发送到 URL；Templates 把内部模板 ID 映射为服务商的模板和参数名称
+*/
+type SMSProviderConfig struct {
+	Type      string                       `yaml:"type"` // tencent、aliyun、http、local
+	Name      string                       `yaml:"name"`
+	SecretId  string                       `yaml:"secret_id"`
+	SecretKey string                       `yaml:"secret_key"`
+	Region    string                       `yaml:"region"`
+	AppId     string                       `yaml:"app_id"`
+	SignName  string                       `yaml:"sign_name"`
+	Endpoint  string                       `yaml:"endpoint"`
+	URL       string                       `yaml:"url"`
+	Method    string                       `yaml:"method"`
+	Headers   map[string]string            `yaml:"headers"`
+	Templates map[string]SMSTemplateConfig `yaml:"templates"`
+}
+
+type SMSTemplateConfig struct {
+	Id     string   `yaml:"id"`
+	Params []string `yaml:"params"`
+}
+
+// 短信服务商切换策略
+const (
+	SMSStrategyFailover     = "failover"
+	SMSStrategyResponseTime = "response_time"
+)
+
+/*
+RateLimitConfig 限流配置：
+Global 对所有请求按照 IP 限流（Limit 为 0 时不启用），Routes 为指定路由的限流规则
+*/
+type RateLimitConfig struct {
+	Global RateLimitRuleConfig   `yaml:"global"`
+	Routes []RateLimitRuleConfig `yaml:"routes"`
+}
+
+// RateLimitRuleConfig Window 时间内最多允许 Limit 个请求，Key 为 ip 或 user，Method 为空时匹配所有方法
+type RateLimitRuleConfig struct {
+	Method string        `yaml:"method"`
+	Path   string        `yaml:"path"`
+	Key    string        `yaml:"key"`
+	Window time.Duration `yaml:"window"`
+	Limit  int           `yaml:"limit"`
+}
+
+// ModerationConfig 帖子审核配置，Keywords 忽略大小写按子串匹配，Patterns 为正则表达式
+type ModerationConfig struct {
+	Keywords []string `yaml:"keywords"`
+	Patterns []string `yaml:"patterns"`
+}
+
+/*
+AdminConfig 管理员配置：
+BootstrapUids 中的用户在启动时被设置为管理员，用于创建第一个管理员，之后可以通过管理后台调整角色
+*/
+type AdminConfig struct {
+	BootstrapUids []int64 `yaml:"bootstrap_uids"`
+}
+
+// ReportConfig 举报配置，帖子待处理的举报数量达到 HideThreshold 之后自动隐藏
+type ReportConfig struct {
+	HideThreshold int64 `yaml:"hide_threshold"`
+}
+
+// OAuth2Config 第三方登录配置，ClientId 为空的平台不启用
+type OAuth2Config struct {
+	Github OAuth2ProviderConfig `yaml:"github"`
+	Wechat OAuth2ProviderConfig `yaml:"wechat"`
+}
+
+// OAuth2ProviderConfig 接口地址为空时使用平台的默认地址
+type OAuth2ProviderConfig struct {
+	ClientId     string `yaml:"client_id"`
+	ClientSecret string `yaml:"client_secret"`
+	RedirectURL  string `yaml:"redirect_url"`
+	AuthURL      string `yaml:"auth_url"`
+	TokenURL     string `yaml:"token_url"`
+	UserURL      string `yaml:"user_url"`
+}
+
+func (c OAuth2ProviderConfig) Enabled() bool {
+	return c.ClientId != ""
+}
+
+/*
+Load 加载配置：
+先读取 YAML 文件（path 为空时跳过），再使用环境变量覆盖，最后校验配置
+*/
+func Load(path string) (*Config, error) {
+	cfg := &Config{}
+	if path != "" {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("读取配置文件失败：%w", err)
+		}
+		if err = yaml.Unmarshal(data, cfg); err != nil {
+			return nil, fmt.Errorf("解析配置文件失败：%w", err)
+		}
+	}
+	if err := cfg.loadEnv(); err != nil {
+		return nil, err
+	}
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+	return cfg, nil
+}
+
+// loadEnv 使用 WEBOOK_ 前缀的环境变量覆盖配置，列表使用逗号分隔
+func (c *Config) loadEnv() error {
+	setString("WEBOOK_SERVER_ADDR", &c.Server.Addr)
+	setString("WEBOOK_DB_MASTER", &c.DB.Master)
+	setList("WEBOOK_DB_SLAVES", &c.DB.Slaves)
+	setString("WEBOOK_REDIS_ADDR", &c.Redis.Addr)
+	setString("WEBOOK_REDIS_PASSWORD", &c.Redis.Password)
+	setList("WEBOOK_KAFKA_BROKERS", &c.Kafka.Brokers)
+	setString("WEBOOK_JWT_KEY", &c.JWT.Key)
+	setString("WEBOOK_EMAIL_HOST", &c.Email.Host)
+	setString("WEBOOK_EMAIL_USERNAME", &c.Email.Username)
+	setString("WEBOOK_EMAIL_PASSWORD", &c.Email.Password)
+	setString("WEBOOK_EMAIL_FROM", &c.Email.From)
+	setString("WEBOOK_SMS_STRATEGY", &c.SMS.Strategy)
+	setString("WEBOOK_OAUTH2_GITHUB_CLIENT_ID", &c.OAuth2.Github.ClientId)
+	setString("WEBOOK_OAUTH2_GITHUB_CLIENT_SECRET", &c.OAuth2.Github.ClientSecret)
+	setString("WEBOOK_OAUTH2_WECHAT_CLIENT_ID", &c.OAuth2.Wechat.ClientId)
+	setString("WEBOOK_OAUTH2_WECHAT_CLIENT_SECRET", &c.OAuth2.Wechat.ClientSecret)
+
+	if v, ok := os.LookupEnv("WEBOOK_REDIS_DB"); ok {
+		db, err := strconv.Atoi(v)
+		if err != nil {
+			return fmt.Errorf("环境变量 WEBOOK_REDIS_DB 格式错误：%w", err)
+		}
+		c.Redis.DB = db
+	}
+	if v, ok := os.LookupEnv("WEBOOK_EMAIL_PORT"); ok {
+		port, err := strconv.Atoi(v)
+		if err != nil {
+			return fmt.Errorf("环境变量 WEBOOK_EMAIL_PORT 格式错误：%w", err)
+		}
+		c.Email.Port = port
+	}
+	if v, ok := os.LookupEnv("WEBOOK_JWT_EXPIRE"); ok {
+		expire, err := time.ParseDuration(v)
+		if err != nil {
+			return fmt.Errorf("环境变量 WEBOOK_JWT_EXPIRE 格式错误：%w", err)
+		}
+		c.JWT.Expire = expire
+	}
+	if _, ok := os.LookupEnv("WEBOOK_ADMIN_BOOTSTRAP_UIDS"); ok {
+		var list []string
+		setList("WEBOOK_ADMIN_BOOTSTRAP_UIDS", &list)
+		uids := make([]int64, 0, len(list))
+		for _, s := range list {
+			uid, err := strconv.ParseInt(s, 10, 64)
+			if err != nil {
+				return fmt.Errorf("环境变量 WEBOOK_ADMIN_BOOTSTRAP_UIDS 格式错误：%w", err)
+			}
+			uids = append(uids, uid)
+		}
+		c.Admin.BootstrapUids = uids
+	}
+	if v, ok := os.LookupEnv("WEBOOK_JWT_REFRESH_EXPIRE"); ok {
+		expire, err := time.ParseDuration(v)
+		if err != nil {
+			return fmt.Errorf("环境变量 WEBOOK_JWT_REFRESH_EXPIRE 格式错误：%w", err)
+		}
+		c.JWT.RefreshExpire = expire
+	}
+	return nil
+}
+
+func setString(key string, dst *string) {
+	if v, ok := os.LookupEnv(key); ok {
+		*dst = v
+	}
+}
+
+func setList(key string, dst *[]string) {
+	v, ok := os.LookupEnv(key)
+	if !ok {
+		return
+	}
+	list := make([]string, 0)
+	for _, s := range strings.Split(v, ",") {
+		if s = strings.TrimSpace(s); s != "" {
+			list = append(list, s)
+		}
+	}
+	*dst = list
+}
+
+// Validate 校验配置，返回所有不合法的配置项
+func (c *Config) Validate() error {
+	var errs []error
+	if c.Server.Addr == "" {
+		errs = append(errs, errors.New("server.addr 不能为空"))
+	}
+	if c.DB.Master == "" {
+		errs = append(errs, errors.New("db.master 不能为空"))
+	}
+	for i, s := range c.DB.Slaves {
+		if s == "" {
+			errs = append(errs, fmt.Errorf("db.slaves[%d] 不能为空", i))
+		}
+	}
+	if c.Redis.Addr == "" {
+		errs = append(errs, errors.New("redis.addr 不能为空"))
+	}
+	if c.Redis.DB < 0 {
+		errs = append(errs, errors.New("redis.db 不能小于 0"))
+	}
+	if len(c.Kafka.Brokers) == 0 {
+		errs = append(errs, errors.New("kafka.brokers 不能为空"))
+	}
+	if c.Kafka.ReadBatch.Size <= 0 || c.Kafka.ReadBatch.Interval <= 0 {
+		errs = append(errs, errors.New("kafka.read_batch.size 和 kafka.read_batch.interval 必须大于 0"))
+	}
+	if len(c.JWT.Key) < 16 {
+		errs = append(errs, errors.New("jwt.key 长度不能小于 16"))
+	}
+	if c.JWT.Expire <= 0 {
+		errs = append(errs, errors.New("jwt.expire 必须大于 0"))
+	}
+	if c.JWT.RefreshExpire <= c.JWT.Expire {
+		errs = append(errs, errors.New("jwt.refresh_expire 必须大于 jwt.expire"))
+	}
+	if c.Email.Host != "" {
+		if c.Email.Port <= 0 || c.Email.Port > 65535 {
+			errs = append(errs, errors.New("email.port 不合法"))
+		}
+		if c.Email.From == "" {
+			errs = append(errs, errors.New("email.from 不能为空"))
+		}
+	}
+	errs = append(errs, c.SMS.validate()...)
+	errs = append(errs, c.RateLimit.validate()...)
+	errs = append(errs, c.Moderation.validate()...)
+	if c.Report.HideThreshold <= 0 {
+		errs = append(errs, errors.New("report.hide_threshold 必须大于 0"))
+	}
+	for i, uid := range c.Admin.BootstrapUids {
+		if uid <= 0 {
+			errs = append(errs, fmt.Errorf("admin.bootstrap_uids[%d] 必须大于 0", i))
+		}
+	}
+	errs = append(errs, c.OAuth2.Github.validate("oauth2.github")...)
+	errs = append(errs, c.OAuth2.Wechat.validate("oauth2.wechat")...)
+	if len(errs) > 0 {
+		return fmt.Errorf("配置校验失败：%w", errors.Join(errs...))
+	}
+	return nil
+}
+
+func (c SMSConfig) validate() []error {
+	var errs []error
+	if c.Strategy != SMSStrategyFailover && c.Strategy != SMSStrategyResponseTime {
+		errs = append(errs, fmt.Errorf("sms.strategy 不支持 %q", c.Strategy))
+	}
+	if c.Rate <= 0 || c.Burst <= 0 {
+		errs = append(errs, errors.New("sms.rate 和 sms.burst 必须大于 0"))
+	}
+	if c.FailThreshold <= 0 || c.Cooldown <= 0 {
+		errs = append(errs, errors.New("sms.fail_threshold 和 sms.cooldown 必须大于 0"))
+	}
+	if c.MaxLatency <= 0 {
+		errs = append(errs, errors.New("sms.max_latency 必须大于 0"))
+	}
+	if c.RetryInterval <= 0 || c.RetryMax <= 0 {
+		errs = append(errs, errors.New("sms.retry_interval 和 sms.retry_max 必须大于 0"))
+	}
+	names := make(map[string]bool, len(c.Providers))
+	for i, p := range c.Providers {
+		name := fmt.Sprintf("sms.providers[%d]", i)
+		switch p.Type {
+		case "tencent":
+			if p.SecretId == "" || p.SecretKey == "" || p.Region == "" || p.AppId == "" || p.SignName == "" {
+				errs = append(errs, fmt.Errorf("%s 缺少 secret_id、secret_key、region、app_id 或 sign_name", name))
+			}
+		case "aliyun":
+			if p.SecretId == "" || p.SecretKey == "" || p.Region == "" || p.SignName == "" {
+				errs = append(errs, fmt.Errorf("%s 缺少 secret_id、secret_key、region 或 sign_name", name))
+			}
+			if len(p.Templates) == 0 {
+				errs = append(errs, fmt.Errorf("%s.templates 不能为空", name))
+			}
+		case "http":
+			if p.URL == "" {
+				errs = append(errs, fmt.Errorf("%s.url 不能为空", name))
+			}
+			if len(p.Templates) == 0 {
+				errs = append(errs, fmt.Errorf("%s.templates 不能为空", name))
+			}
+		case "local":
+		default:
+			errs = append(errs, fmt.Errorf("%s.type 不支持 %q", name, p.Type))
+		}
+		if names[p.ProviderName()] {
+			errs = append(errs, fmt.Errorf("%s.name 重复", name))
+		}
+		names[p.ProviderName()] = true
+	}
+	return errs
+}
+
+func (c RateLimitConfig) validate() []error {
+	var errs []error
+	if g := c.Global; g.Limit > 0 {
+		if g.Window <= 0 {
+			errs = append(errs, errors.New("rate_limit.global.window 必须大于 0"))
+		}
+		if g.Key != "ip" {
+			errs = append(errs, errors.New("rate_limit.global.key 只能为 ip"))
+		}
+	}
+	rules := make(map[string]bool, len(c.Routes))
+	for i, r := range c.Routes {
+		name := fmt.Sprintf("rate_limit.routes[%d]", i)
+		if r.Path == "" {
+			errs = append(errs, fmt.Errorf("%s.path 不能为空", name))
+		}
+		if r.Key != "ip" && r.Key != "user" {
+			errs = append(errs, fmt.Errorf("%s.key 只能为 ip 或 user", name))
+		}
+		if r.Window <= 0 || r.Limit <= 0 {
+			errs = append(errs, fmt.Errorf("%s.window 和 %s.limit 必须大于 0", name, name))
+		}
+		rule := r.Method + " " + r.Path + " " + r.Key
+		if rules[rule] {
+			errs = append(errs, fmt.Errorf("%s 重复", name))
+		}
+		rules[rule] = true
+	}
+	return errs
+}
+
+func (c ModerationConfig) validate() []error {
+	var errs []error
+	for i, p := range c.Patterns {
+		if _, err := regexp.Compile(p); err != nil {
+			errs = append(errs, fmt.Errorf("moderation.patterns[%d] 不合法：%w", i, err))
+		}
+	}
+	return errs
+}
+
+func (c SMSProviderConfig) ProviderName() string {
+	if c.Name != "" {
+		return c.Name
+	}
+	return c.Type
+}
+
+func (c OAuth2ProviderConfig) validate(name string) []error {
+	if !c.Enabled() {
+		return nil
+	}
+	var errs []error
+	if c.ClientSecret == "" {
+		errs = append(errs, fmt.Errorf("%s.client_secret 不能为空", name))
+	}
+	if c.RedirectURL == "" {
+		errs = append(errs, fmt.Errorf("%s.redirect_url 不能为空", name))
+	}
+	return errs
+}
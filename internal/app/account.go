@@ -0,0 +1,334 @@
+package app
+
+import (
+	"github.com/Linxhhh/webook/internal/service"
+	"github.com/Linxhhh/webook/pkg/jwts"
+	"github.com/Linxhhh/webook/pkg/res"
+	"github.com/gin-gonic/gin"
+)
+
+// 验证码的业务类型
+const (
+	bizBindPhone = "bind_phone"
+	bizBindEmail = "bind_email"
+	bizMerge     = "merge_account"
+)
+
+// AccountHandler 账号绑定与合并
+type AccountHandler struct {
+	userSvc    *service.UserService
+	codeSvc    *service.CodeService
+	accountSvc *service.AccountService
+}
+
+func NewAccountHandler(userSvc *service.UserService, codeSvc *service.CodeService, accountSvc *service.AccountService) *AccountHandler {
+	return &AccountHandler{
+		userSvc:    userSvc,
+		codeSvc:    codeSvc,
+		accountSvc: accountSvc,
+	}
+}
+
+func (hdl *AccountHandler) RegistryRouter(router *gin.Engine) {
+	ug := router.Group("user")
+	ug.GET("bindings", hdl.Bindings) // 绑定信息
+
+	ug.POST("bind/phone/send", hdl.SendBindPhoneCode) // 绑定手机号码：发送验证码
+	ug.POST("bind/phone", hdl.BindPhone)              // 绑定手机号码：校验验证码
+	ug.DELETE("bind/phone", hdl.UnbindPhone)          // 解绑手机号码
+	ug.POST("bind/email/send", hdl.SendBindEmailCode) // 绑定邮箱：发送验证码
+	ug.POST("bind/email", hdl.BindEmail)              // 绑定邮箱：校验验证码
+
+	ug.POST("merge/send", hdl.SendMergeCode) // 合并账号：向另一个账号的手机号码或邮箱发送验证码
+	ug.POST("merge", hdl.Merge)              // 合并账号：校验验证码，把另一个账号合并到当前账号
+}
+
+// Bindings 获取当前账号绑定的手机号码、邮箱和第三方账号
+func (hdl *AccountHandler) Bindings(ctx *gin.Context) {
+
+	// 获取用户 Token
+	_claims, _ := ctx.Get("claims")
+	claims := _claims.(*jwts.CustomClaims)
+
+	// 调用下层服务
+	user, err := hdl.userSvc.Profile(ctx, claims.UserId)
+	if err != nil {
+		res.FailWithMsg("系统错误", ctx)
+		return
+	}
+	bindings, err := hdl.userSvc.OAuth2Bindings(ctx, claims.UserId)
+	if err != nil {
+		res.FailWithMsg("系统错误", ctx)
+		return
+	}
+
+	// 返回响应
+	providers := make([]string, 0, len(bindings))
+	for _, b := range bindings {
+		providers = append(providers, b.Provider)
+	}
+	res.OKWithData(gin.H{
+		"phone":  user.Phone,
+		"email":  user.Email,
+		"oauth2": providers,
+	}, ctx)
+}
+
+// SendBindPhoneCode 向需要绑定的手机号码发送验证码
+func (hdl *AccountHandler) SendBindPhoneCode(ctx *gin.Context) {
+
+	// 绑定参数
+	type Req struct {
+		Phone string `json:"phone" binding:"required"`
+	}
+	var req Req
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		res.FailWithMsg("参数错误", ctx)
+		return
+	}
+
+	// 校验手机号码
+	if ok, err := isValidPhone(req.Phone); err != nil {
+		res.FailWithMsg("系统错误", ctx)
+		return
+	} else if !ok {
+		res.FailWithMsg("非法手机号码", ctx)
+		return
+	}
+
+	// 调用下层服务
+	hdl.sendCode(ctx, hdl.codeSvc.Send(ctx, bizBindPhone, service.CodeChannelSMS, req.Phone))
+}
+
+// BindPhone 校验验证码，为当前账号绑定手机号码
+func (hdl *AccountHandler) BindPhone(ctx *gin.Context) {
+
+	// 绑定参数
+	type Req struct {
+		Phone string `json:"phone" binding:"required"`
+		Code  string `json:"code" binding:"required"`
+	}
+	var req Req
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		res.FailWithMsg("参数错误", ctx)
+		return
+	}
+
+	// 验证码校验
+	if !hdl.verifyCode(ctx, bizBindPhone, req.Phone, req.Code) {
+		return
+	}
+
+	// 获取用户 Token
+	_claims, _ := ctx.Get("claims")
+	claims := _claims.(*jwts.CustomClaims)
+
+	// 调用下层服务
+	err := hdl.userSvc.BindPhone(ctx, claims.UserId, req.Phone)
+	switch err {
+	case nil:
+		res.OKWithMsg("绑定成功", ctx)
+	case service.ErrAccountBound:
+		res.FailWithMsg("手机号码已被其他账号绑定，可以合并账号", ctx)
+	default:
+		res.FailWithMsg("系统错误", ctx)
+	}
+}
+
+// UnbindPhone 解绑手机号码
+func (hdl *AccountHandler) UnbindPhone(ctx *gin.Context) {
+
+	// 获取用户 Token
+	_claims, _ := ctx.Get("claims")
+	claims := _claims.(*jwts.CustomClaims)
+
+	// 调用下层服务
+	err := hdl.userSvc.UnbindPhone(ctx, claims.UserId)
+	switch err {
+	case nil:
+		res.OKWithMsg("解绑成功", ctx)
+	case service.ErrBindingNotFound:
+		res.FailWithMsg("未绑定手机号码", ctx)
+	case service.ErrLastLoginMethod:
+		res.FailWithMsg("至少需要保留一种登录方式", ctx)
+	default:
+		res.FailWithMsg("系统错误", ctx)
+	}
+}
+
+// SendBindEmailCode 向需要绑定的邮箱发送验证码
+func (hdl *AccountHandler) SendBindEmailCode(ctx *gin.Context) {
+
+	// 绑定参数
+	type Req struct {
+		Email string `json:"email" binding:"required"`
+	}
+	var req Req
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		res.FailWithMsg("参数错误", ctx)
+		return
+	}
+
+	// 校验邮箱
+	if ok, err := isValidEmail(req.Email); err != nil {
+		res.FailWithMsg("系统错误", ctx)
+		return
+	} else if !ok {
+		res.FailWithMsg("非法邮箱格式", ctx)
+		return
+	}
+
+	// 调用下层服务
+	hdl.sendCode(ctx, hdl.codeSvc.Send(ctx, bizBindEmail, service.CodeChannelEmail, req.Email))
+}
+
+// BindEmail 校验验证码，为当前账号绑定邮箱
+func (hdl *AccountHandler) BindEmail(ctx *gin.Context) {
+
+	// 绑定参数
+	type Req struct {
+		Email string `json:"email" binding:"required"`
+		Code  string `json:"code" binding:"required"`
+	}
+	var req Req
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		res.FailWithMsg("参数错误", ctx)
+		return
+	}
+
+	// 验证码校验
+	if !hdl.verifyCode(ctx, bizBindEmail, req.Email, req.Code) {
+		return
+	}
+
+	// 获取用户 Token
+	_claims, _ := ctx.Get("claims")
+	claims := _claims.(*jwts.CustomClaims)
+
+	// 调用下层服务
+	err := hdl.userSvc.BindEmail(ctx, claims.UserId, req.Email)
+	switch err {
+	case nil:
+		res.OKWithMsg("绑定成功", ctx)
+	case service.ErrAccountBound:
+		res.FailWithMsg("邮箱已被其他账号绑定，可以合并账号", ctx)
+	default:
+		res.FailWithMsg("系统错误", ctx)
+	}
+}
+
+/*
+SendMergeCode 合并账号：
+向另一个账号的手机号码或邮箱发送验证码，证明当前用户同时拥有该账号
+*/
+func (hdl *AccountHandler) SendMergeCode(ctx *gin.Context) {
+
+	// 绑定参数
+	type Req struct {
+		Phone string `json:"phone"`
+		Email string `json:"email"`
+	}
+	var req Req
+	if err := ctx.ShouldBindJSON(&req); err != nil || (req.Phone == "") == (req.Email == "") {
+		res.FailWithMsg("参数错误", ctx)
+		return
+	}
+
+	// 调用下层服务
+	if req.Phone != "" {
+		hdl.sendCode(ctx, hdl.codeSvc.Send(ctx, bizMerge, service.CodeChannelSMS, req.Phone))
+		return
+	}
+	hdl.sendCode(ctx, hdl.codeSvc.Send(ctx, bizMerge, service.CodeChannelEmail, req.Email))
+}
+
+/*
+Merge 合并账号API：
+校验验证码之后，把手机号码或邮箱所属的账号合并到当前账号，被合并的账号会被删除
+*/
+func (hdl *AccountHandler) Merge(ctx *gin.Context) {
+
+	// 绑定参数
+	type Req struct {
+		Phone string `json:"phone"`
+		Email string `json:"email"`
+		Code  string `json:"code" binding:"required"`
+	}
+	var req Req
+	if err := ctx.ShouldBindJSON(&req); err != nil || (req.Phone == "") == (req.Email == "") {
+		res.FailWithMsg("参数错误", ctx)
+		return
+	}
+	target := req.Phone
+	if target == "" {
+		target = req.Email
+	}
+
+	// 验证码校验
+	if !hdl.verifyCode(ctx, bizMerge, target, req.Code) {
+		return
+	}
+
+	// 获取用户 Token
+	_claims, _ := ctx.Get("claims")
+	claims := _claims.(*jwts.CustomClaims)
+
+	// 查找被合并的账号
+	var (
+		src int64
+		err error
+	)
+	if req.Phone != "" {
+		src, err = hdl.accountSvc.FindByPhone(ctx, req.Phone)
+	} else {
+		src, err = hdl.accountSvc.FindByEmail(ctx, req.Email)
+	}
+	switch err {
+	case nil:
+	case service.ErrUserNotFound:
+		res.FailWithMsg("账号不存在", ctx)
+		return
+	default:
+		res.FailWithMsg("系统错误", ctx)
+		return
+	}
+
+	// 调用下层服务
+	err = hdl.accountSvc.Merge(ctx, claims.UserId, src)
+	switch err {
+	case nil:
+		res.OKWithMsg("合并成功", ctx)
+	case service.ErrMergeSelf:
+		res.FailWithMsg("不能合并当前账号", ctx)
+	default:
+		res.FailWithMsg("系统错误", ctx)
+	}
+}
+
+// sendCode 返回发送验证码的结果
+func (hdl *AccountHandler) sendCode(ctx *gin.Context, err error) {
+	switch err {
+	case nil:
+		res.OKWithMsg("验证码发送成功", ctx)
+	case service.ErrSendCodeTooMany:
+		res.FailWithMsg("验证码发送频繁", ctx)
+	default:
+		res.FailWithMsg("系统错误", ctx)
+	}
+}
+
+// verifyCode 校验验证码，校验失败时直接返回响应
+func (hdl *AccountHandler) verifyCode(ctx *gin.Context, biz, target, code string) bool {
+	err := hdl.codeSvc.Verify(ctx, biz, target, code)
+	switch err {
+	case nil:
+		return true
+	case service.ErrVerifyCodeFailed:
+		res.FailWithMsg("校验失败", ctx)
+	case service.ErrVerifyCodeTooMany:
+		res.FailWithMsg("校验频繁", ctx)
+	default:
+		res.FailWithMsg("系统错误", ctx)
+	}
+	return false
+}
@@ -0,0 +1,221 @@
+package app
+
+import (
+	"strconv"
+
+	"github.com/Linxhhh/webook/internal/app/middleware"
+	"github.com/Linxhhh/webook/internal/domain"
+	"github.com/Linxhhh/webook/internal/service"
+	"github.com/Linxhhh/webook/pkg/jwts"
+	"github.com/Linxhhh/webook/pkg/res"
+	"github.com/gin-gonic/gin"
+)
+
+// AdminHandler 管理后台，版主可以封禁用户、下架帖子和处理举报，管理员可以调整角色
+type AdminHandler struct {
+	svc *service.AdminService
+}
+
+func NewAdminHandler(svc *service.AdminService) *AdminHandler {
+	return &AdminHandler{
+		svc: svc,
+	}
+}
+
+func (hdl *AdminHandler) RegistryRouter(router *gin.Engine) {
+	ag := router.Group("admin", middleware.RequireRole(domain.RoleModerator))
+	ag.POST("user/ban", hdl.Ban)                 // 封禁用户
+	ag.POST("user/unban", hdl.Unban)             // 解除封禁
+	ag.POST("article/unpublish", hdl.Unpublish)  // 强制下架帖子
+	ag.GET("report/list", hdl.ReportList)        // 待处理的举报列表
+	ag.GET("report/detail", hdl.ReportDetail)    // 举报明细
+	ag.POST("report/resolve", hdl.ResolveReport) // 处理举报
+
+	// 只有管理员可以调整角色
+	sg := ag.Group("", middleware.RequireRole(domain.RoleAdmin))
+	sg.POST("user/role", hdl.SetRole)
+}
+
+type adminUserReq struct {
+	Uid int64 `json:"uid" binding:"required"`
+}
+
+// Ban 封禁用户API
+func (hdl *AdminHandler) Ban(ctx *gin.Context) {
+
+	// 绑定参数
+	var req adminUserReq
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		res.FailWithMsg("参数错误", ctx)
+		return
+	}
+
+	// 获取用户 Token
+	_claims, _ := ctx.Get("claims")
+	claims := _claims.(*jwts.CustomClaims)
+
+	// 调用下层服务
+	err := hdl.svc.Ban(ctx, domain.Role(claims.Role), claims.UserId, req.Uid)
+	adminResult(ctx, err, "封禁成功")
+}
+
+// Unban 解除封禁API
+func (hdl *AdminHandler) Unban(ctx *gin.Context) {
+
+	// 绑定参数
+	var req adminUserReq
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		res.FailWithMsg("参数错误", ctx)
+		return
+	}
+
+	// 获取用户 Token
+	_claims, _ := ctx.Get("claims")
+	claims := _claims.(*jwts.CustomClaims)
+
+	// 调用下层服务
+	err := hdl.svc.Unban(ctx, domain.Role(claims.Role), claims.UserId, req.Uid)
+	adminResult(ctx, err, "解封成功")
+}
+
+// SetRole 调整角色API，role 为 user、moderator 或 admin
+func (hdl *AdminHandler) SetRole(ctx *gin.Context) {
+
+	// 绑定参数
+	type Req struct {
+		Uid  int64  `json:"uid" binding:"required"`
+		Role string `json:"role" binding:"required"`
+	}
+	var req Req
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		res.FailWithMsg("参数错误", ctx)
+		return
+	}
+	role, ok := domain.ParseRole(req.Role)
+	if !ok {
+		res.FailWithMsg("参数错误", ctx)
+		return
+	}
+
+	// 获取用户 Token
+	_claims, _ := ctx.Get("claims")
+	claims := _claims.(*jwts.CustomClaims)
+
+	// 调用下层服务
+	err := hdl.svc.SetRole(ctx, domain.Role(claims.Role), claims.UserId, req.Uid, role)
+	adminResult(ctx, err, "设置成功")
+}
+
+// Unpublish 强制下架帖子API
+func (hdl *AdminHandler) Unpublish(ctx *gin.Context) {
+
+	// 绑定参数
+	type Req struct {
+		Id int64 `json:"id" binding:"required"`
+	}
+	var req Req
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		res.FailWithMsg("参数错误", ctx)
+		return
+	}
+
+	// 调用下层服务
+	err := hdl.svc.Unpublish(ctx, req.Id)
+	adminResult(ctx, err, "下架成功")
+}
+
+// ReportList 待处理的举报列表API，按照被举报的资源聚合
+func (hdl *AdminHandler) ReportList(ctx *gin.Context) {
+
+	// 绑定参数
+	var (
+		page     = 1
+		pageSize = 20
+		err      error
+	)
+	if p := ctx.Query("page"); p != "" {
+		if page, err = strconv.Atoi(p); err != nil || page <= 0 {
+			res.FailWithMsg("参数错误", ctx)
+			return
+		}
+	}
+	if ps := ctx.Query("pageSize"); ps != "" {
+		if pageSize, err = strconv.Atoi(ps); err != nil || pageSize <= 0 || pageSize > 50 {
+			res.FailWithMsg("参数错误", ctx)
+			return
+		}
+	}
+
+	// 调用下层服务
+	list, err := hdl.svc.ReportList(ctx, page, pageSize)
+	if err != nil {
+		res.FailWithMsg("系统错误", ctx)
+		return
+	}
+	res.OKWithData(list, ctx)
+}
+
+// ReportDetail 举报明细API
+func (hdl *AdminHandler) ReportDetail(ctx *gin.Context) {
+
+	// 绑定参数
+	biz := ctx.Query("biz")
+	bizId, err := strconv.ParseInt(ctx.Query("bizId"), 10, 64)
+	if biz == "" || bizId == 0 || err != nil {
+		res.FailWithMsg("参数错误", ctx)
+		return
+	}
+
+	// 调用下层服务
+	list, err := hdl.svc.ReportDetail(ctx, biz, bizId)
+	if err != nil {
+		res.FailWithMsg("系统错误", ctx)
+		return
+	}
+	res.OKWithData(list, ctx)
+}
+
+/*
+ResolveReport 处理举报API：
+action 为 accept 时举报成立，下架帖子、删除评论或者封禁用户；为 dismiss 时驳回举报
+*/
+func (hdl *AdminHandler) ResolveReport(ctx *gin.Context) {
+
+	// 绑定参数
+	type Req struct {
+		Biz    string `json:"biz" binding:"required"`
+		BizId  int64  `json:"bizId" binding:"required"`
+		Action string `json:"action" binding:"required,oneof=accept dismiss"`
+	}
+	var req Req
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		res.FailWithMsg("参数错误", ctx)
+		return
+	}
+
+	// 获取用户 Token
+	_claims, _ := ctx.Get("claims")
+	claims := _claims.(*jwts.CustomClaims)
+
+	// 调用下层服务
+	err := hdl.svc.ResolveReport(ctx, domain.Role(claims.Role), claims.UserId, req.Biz, req.BizId, req.Action == "accept")
+	adminResult(ctx, err, "处理成功")
+}
+
+// adminResult 把管理操作的错误转换为响应
+func adminResult(ctx *gin.Context, err error, msg string) {
+	switch err {
+	case nil:
+		res.OKWithMsg(msg, ctx)
+	case service.ErrPermissionDenied:
+		res.FailWithMsg("权限不足", ctx)
+	case service.ErrUserNotFound:
+		res.FailWithMsg("用户不存在", ctx)
+	case service.ErrArticleNotFound:
+		res.FailWithMsg("帖子不存在", ctx)
+	case service.ErrReportNotFound:
+		res.FailWithMsg("没有待处理的举报", ctx)
+	default:
+		res.FailWithMsg("系统错误", ctx)
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"errors"
 	"log"
 	"strconv"
+	"time"
 
 	"github.com/Linxhhh/webook/internal/domain"
 	"github.com/Linxhhh/webook/internal/events"
@@ -16,18 +17,23 @@ import (
 var ErrIncorrectArticleorAuthor = service.ErrIncorrectArticleorAuthor
 
 type ArticleHandler struct {
-	svc      *service.ArticleService
-	interSvc *service.InteractionService
-	producer *events.ArticleEventProducer
-	biz      string
+	svc           *service.ArticleService
+	interSvc      *service.InteractionService
+	producer      *events.ArticleEventProducer
+	readProducer  *events.SaramaReadProducer
+	interProducer *events.InteractionEventProducer
+	biz           string
 }
 
-func NewArticleHandler(svc *service.ArticleService, interSvc *service.InteractionService, producer *events.ArticleEventProducer) *ArticleHandler {
+func NewArticleHandler(svc *service.ArticleService, interSvc *service.InteractionService, producer *events.ArticleEventProducer,
+	readProducer *events.SaramaReadProducer, interProducer *events.InteractionEventProducer) *ArticleHandler {
 	return &ArticleHandler{
-		svc:      svc,
-		interSvc: interSvc,
-		producer: producer,
-		biz:      "article",
+		svc:           svc,
+		interSvc:      interSvc,
+		producer:      producer,
+		readProducer:  readProducer,
+		interProducer: interProducer,
+		biz:           "article",
 	}
 }
 
@@ -36,10 +42,17 @@ func (hdl *ArticleHandler) RegistryRouter(router *gin.Engine) {
 	ag := router.Group("article")
 	ag.POST("edit", hdl.Edit)
 	ag.POST("publish", hdl.Publish)
+	ag.POST("schedule", hdl.Schedule)              // 定时发表
+	ag.POST("schedule/cancel", hdl.CancelSchedule) // 取消定时发表
+	ag.POST("schedule/reschedule", hdl.Reschedule) // 修改定时发表的时间
 	ag.DELETE("withdraw", hdl.Withdraw)
 	ag.GET("count", hdl.Count)
 	ag.GET("list", hdl.List)
 	ag.GET("detail", hdl.Detail)
+	ag.GET("revisions", hdl.Revisions)               // 版本列表
+	ag.GET("revision", hdl.Revision)                 // 版本详情
+	ag.GET("revision/diff", hdl.DiffRevisions)       // 对比两个版本
+	ag.POST("revision/restore", hdl.RestoreRevision) // 恢复历史版本
 
 	// 读者接口
 	pg := router.Group("pub")
@@ -105,17 +118,126 @@ func (hdl *ArticleHandler) Publish(ctx *gin.Context) {
 		return
 	}
 
-	// 异步事件 —— feed 流推送
-	if err = hdl.producer.ProduceEvent(events.ArticleEvent{
-		Uid:   claims.UserId,
-		Aid:   aid,
-		Title: req.Title,
+	// 异步事件 —— 帖子审核，审核通过之后再推送 feed 流
+	if err = hdl.producer.ProduceReviewEvent(events.ArticleReviewEvent{
+		Uid: claims.UserId,
+		Aid: aid,
 	}); err != nil {
 		res.FailWithMsg("异步事件生成错误", ctx)
+		return
 	}
 	res.OKWithData(gin.H{"article_id": aid}, ctx)
 }
 
+/*
+Schedule 定时发表：
+publishAt 为 RFC3339 格式的发表时间，帖子先进入审核，审核通过之后到达发表时间才会发表
+*/
+func (hdl *ArticleHandler) Schedule(ctx *gin.Context) {
+
+	// 绑定参数
+	type Req struct {
+		ArticleRequest
+		PublishAt time.Time `json:"publishAt" binding:"required"`
+	}
+	var req Req
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		res.FailWithMsg("参数错误", ctx)
+		return
+	}
+
+	// 获取用户 Token
+	_claims, _ := ctx.Get("claims")
+	claims := _claims.(*jwts.CustomClaims)
+
+	// 调用下层服务
+	aid, err := hdl.svc.Schedule(ctx, domain.Article{
+		Id:       req.Id,
+		Title:    req.Title,
+		Content:  req.Content,
+		AuthorId: claims.UserId,
+	}, req.PublishAt)
+	if err != nil {
+		if errors.Is(err, service.ErrInvalidPublishTime) {
+			res.FailWithMsg("发表时间不合法", ctx)
+			return
+		}
+		res.FailWithMsg("系统错误", ctx)
+		return
+	}
+
+	// 异步事件 —— 帖子审核
+	if err = hdl.producer.ProduceReviewEvent(events.ArticleReviewEvent{
+		Uid: claims.UserId,
+		Aid: aid,
+	}); err != nil {
+		res.FailWithMsg("异步事件生成错误", ctx)
+		return
+	}
+	res.OKWithData(gin.H{"article_id": aid}, ctx)
+}
+
+// CancelSchedule 取消定时发表，帖子变为未发表
+func (hdl *ArticleHandler) CancelSchedule(ctx *gin.Context) {
+
+	// 绑定参数
+	type Req struct {
+		Id int64 `json:"id" binding:"required"`
+	}
+	var req Req
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		res.FailWithMsg("参数错误", ctx)
+		return
+	}
+
+	// 获取用户 Token
+	_claims, _ := ctx.Get("claims")
+	claims := _claims.(*jwts.CustomClaims)
+
+	// 调用下层服务
+	err := hdl.svc.CancelSchedule(ctx, claims.UserId, req.Id)
+	switch {
+	case err == nil:
+		res.OKWithMsg("取消成功", ctx)
+	case errors.Is(err, service.ErrArticleNotScheduled):
+		res.FailWithMsg("帖子没有定时发表", ctx)
+	default:
+		res.FailWithMsg("系统错误", ctx)
+	}
+}
+
+// Reschedule 修改定时发表的时间，内容没有变化，不需要重新审核
+func (hdl *ArticleHandler) Reschedule(ctx *gin.Context) {
+
+	// 绑定参数
+	type Req struct {
+		Id        int64     `json:"id" binding:"required"`
+		PublishAt time.Time `json:"publishAt" binding:"required"`
+	}
+	var req Req
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		res.FailWithMsg("参数错误", ctx)
+		return
+	}
+
+	// 获取用户 Token
+	_claims, _ := ctx.Get("claims")
+	claims := _claims.(*jwts.CustomClaims)
+
+	// 调用下层服务
+	err := hdl.svc.Reschedule(ctx, claims.UserId, req.Id, req.PublishAt)
+	switch {
+	case err == nil:
+		res.OKWithMsg("修改成功", ctx)
+	case errors.Is(err, service.ErrInvalidPublishTime):
+		res.FailWithMsg("发表时间不合法", ctx)
+	case errors.Is(err, service.ErrArticleNotScheduled):
+		res.FailWithMsg("帖子没有定时发表", ctx)
+	default:
+		res.FailWithMsg("系统错误", ctx)
+	}
+}
+
 // Withdraw 撤销发表
 func (hdl *ArticleHandler) Withdraw(ctx *gin.Context) {
 
@@ -226,6 +348,134 @@ func (hdl *ArticleHandler) Detail(ctx *gin.Context) {
 	res.OKWithData(art, ctx)
 }
 
+// Revisions 获取帖子的版本列表，新版本在前
+func (hdl *ArticleHandler) Revisions(ctx *gin.Context) {
+
+	// 绑定参数
+	var (
+		page     = 1
+		pageSize = 20
+	)
+	aid, err := strconv.ParseInt(ctx.Query("id"), 10, 64)
+	if aid == 0 || err != nil {
+		res.FailWithMsg("参数错误", ctx)
+		return
+	}
+	if p := ctx.Query("page"); p != "" {
+		if page, err = strconv.Atoi(p); err != nil || page <= 0 {
+			res.FailWithMsg("参数错误", ctx)
+			return
+		}
+	}
+	if ps := ctx.Query("pageSize"); ps != "" {
+		if pageSize, err = strconv.Atoi(ps); err != nil || pageSize <= 0 || pageSize > 50 {
+			res.FailWithMsg("参数错误", ctx)
+			return
+		}
+	}
+
+	// 获取用户 Token
+	_claims, _ := ctx.Get("claims")
+	claims := _claims.(*jwts.CustomClaims)
+
+	// 调用下层服务
+	list, err := hdl.svc.Revisions(ctx, claims.UserId, aid, page, pageSize)
+	if err != nil {
+		revisionFail(ctx, err)
+		return
+	}
+	res.OKWithData(list, ctx)
+}
+
+// Revision 获取指定版本的详情
+func (hdl *ArticleHandler) Revision(ctx *gin.Context) {
+
+	// 绑定参数
+	rid, err := strconv.ParseInt(ctx.Query("id"), 10, 64)
+	if rid == 0 || err != nil {
+		res.FailWithMsg("参数错误", ctx)
+		return
+	}
+
+	// 获取用户 Token
+	_claims, _ := ctx.Get("claims")
+	claims := _claims.(*jwts.CustomClaims)
+
+	// 调用下层服务
+	rev, err := hdl.svc.Revision(ctx, claims.UserId, rid)
+	if err != nil {
+		revisionFail(ctx, err)
+		return
+	}
+	res.OKWithData(rev, ctx)
+}
+
+// DiffRevisions 按行对比两个版本，from 和 to 为版本 ID
+func (hdl *ArticleHandler) DiffRevisions(ctx *gin.Context) {
+
+	// 绑定参数
+	from, err1 := strconv.ParseInt(ctx.Query("from"), 10, 64)
+	to, err2 := strconv.ParseInt(ctx.Query("to"), 10, 64)
+	if from == 0 || to == 0 || err1 != nil || err2 != nil {
+		res.FailWithMsg("参数错误", ctx)
+		return
+	}
+
+	// 获取用户 Token
+	_claims, _ := ctx.Get("claims")
+	claims := _claims.(*jwts.CustomClaims)
+
+	// 调用下层服务
+	d, err := hdl.svc.DiffRevisions(ctx, claims.UserId, from, to)
+	if err != nil {
+		revisionFail(ctx, err)
+		return
+	}
+	res.OKWithData(d, ctx)
+}
+
+// RestoreRevision 使用历史版本保存新的草稿
+func (hdl *ArticleHandler) RestoreRevision(ctx *gin.Context) {
+
+	// 绑定参数
+	type Req struct {
+		Id int64 `json:"id" binding:"required"`
+	}
+	var req Req
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		res.FailWithMsg("参数错误", ctx)
+		return
+	}
+
+	// 获取用户 Token
+	_claims, _ := ctx.Get("claims")
+	claims := _claims.(*jwts.CustomClaims)
+
+	// 调用下层服务
+	aid, err := hdl.svc.Restore(ctx, claims.UserId, req.Id)
+	if err != nil {
+		revisionFail(ctx, err)
+		return
+	}
+	res.OKWithData(gin.H{"article_id": aid}, ctx)
+}
+
+// revisionFail 把版本相关的错误转换为响应
+func revisionFail(ctx *gin.Context, err error) {
+	switch {
+	case errors.Is(err, ErrIncorrectArticleorAuthor):
+		res.FailWithMsg("非法查询", ctx)
+	case errors.Is(err, service.ErrRevisionNotFound):
+		res.FailWithMsg("版本不存在", ctx)
+	case errors.Is(err, service.ErrRevisionMismatch):
+		res.FailWithMsg("只能对比同一个帖子的版本", ctx)
+	case errors.Is(err, service.ErrRestoreNotAllowed):
+		res.FailWithMsg("审核中或等待定时发表的帖子不能恢复版本", ctx)
+	default:
+		res.FailWithMsg("系统错误", ctx)
+	}
+}
+
 // PubDetail 获取线上库的帖子详情
 func (hdl *ArticleHandler) PubDetail(ctx *gin.Context) {
 
@@ -238,6 +488,10 @@ func (hdl *ArticleHandler) PubDetail(ctx *gin.Context) {
 
 	// 调用下层服务
 	art, err := hdl.svc.PubDetail(ctx, aid)
+	if errors.Is(err, service.ErrArticleNotFound) {
+		res.FailWithMsg("帖子不存在", ctx)
+		return
+	}
 	if err != nil {
 		res.FailWithMsg("系统错误", ctx)
 		return
@@ -260,10 +514,17 @@ func (hdl *ArticleHandler) Read(ctx *gin.Context) {
 		return
 	}
 
-	// 调用下层服务
-	err := hdl.interSvc.IncrReadCnt(ctx, hdl.biz, aid.(int64))
+	// 获取用户 Token
+	_claims, _ := ctx.Get("claims")
+	claims := _claims.(*jwts.CustomClaims)
+
+	// 异步事件 —— 阅读计数，由消费者批量写入
+	err := hdl.readProducer.ProduceEvent(events.ReadEvent{
+		Aid: aid.(int64),
+		Uid: claims.UserId,
+	})
 	if err != nil {
-		log.Panicln("IncrReadCnt 报错：err : ", err.Error())
+		log.Println("ReadEvent 生成错误：err : ", err.Error())
 	}
 }
 
@@ -294,6 +555,18 @@ func (hdl *ArticleHandler) Like(ctx *gin.Context) {
 		res.FailWithMsg("系统错误", ctx)
 		return
 	}
+
+	// 异步事件 —— 通知帖子作者
+	if req.Like {
+		err = hdl.interProducer.ProduceLikeEvent(events.InteractionEvent{
+			Uid:   claims.UserId,
+			Biz:   hdl.biz,
+			BizId: req.Id,
+		})
+		if err != nil {
+			log.Println("LikeEvent 生成错误：err : ", err.Error())
+		}
+	}
 	res.OKWithMsg("操作成功", ctx)
 }
 
@@ -323,6 +596,18 @@ func (hdl *ArticleHandler) Collect(ctx *gin.Context) {
 		res.FailWithMsg("系统错误", ctx)
 		return
 	}
+
+	// 异步事件 —— 通知帖子作者
+	if req.Collect {
+		err = hdl.interProducer.ProduceCollectEvent(events.InteractionEvent{
+			Uid:   claims.UserId,
+			Biz:   hdl.biz,
+			BizId: req.Id,
+		})
+		if err != nil {
+			log.Println("CollectEvent 生成错误：err : ", err.Error())
+		}
+	}
 	res.OKWithMsg("操作成功", ctx)
 }
 
@@ -366,6 +651,7 @@ func (hdl *ArticleHandler) Interaction(ctx *gin.Context) {
 		ReadCnt     int64 `json:"readCnt"`
 		LikeCnt     int64 `json:"likeCnt"`
 		CollectCnt  int64 `json:"collectCnt"`
+		CommentCnt  int64 `json:"commentCnt"`
 		IsLiked     bool  `json:"isLiked"`
 		IsCollected bool  `json:"isCollected"`
 	}
@@ -373,6 +659,7 @@ func (hdl *ArticleHandler) Interaction(ctx *gin.Context) {
 		ReadCnt:     i.ReadCnt,
 		LikeCnt:     i.LikeCnt,
 		CollectCnt:  i.CollectCnt,
+		CommentCnt:  i.CommentCnt,
 		IsLiked:     i.IsLiked,
 		IsCollected: i.IsCollected,
 	}, ctx)
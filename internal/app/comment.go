@@ -0,0 +1,202 @@
+package app
+
+import (
+	"errors"
+	"log"
+	"strconv"
+	"unicode/utf8"
+
+	"github.com/Linxhhh/webook/internal/domain"
+	"github.com/Linxhhh/webook/internal/events"
+	"github.com/Linxhhh/webook/internal/service"
+	"github.com/Linxhhh/webook/pkg/jwts"
+	"github.com/Linxhhh/webook/pkg/res"
+	"github.com/gin-gonic/gin"
+)
+
+type CommentHandler struct {
+	svc      *service.CommentService
+	producer *events.CommentEventProducer
+}
+
+func NewCommentHandler(svc *service.CommentService, producer *events.CommentEventProducer) *CommentHandler {
+	return &CommentHandler{
+		svc:      svc,
+		producer: producer,
+	}
+}
+
+func (hdl *CommentHandler) RegistryRouter(router *gin.Engine) {
+	cg := router.Group("comment")
+	cg.POST("create", hdl.Create)   // 发表评论或回复
+	cg.DELETE("delete", hdl.Delete) // 删除自己的评论
+	cg.GET("list", hdl.List)        // 顶级评论列表
+	cg.GET("replies", hdl.Replies)  // 回复列表
+}
+
+// Create 发表评论，parentId 不为 0 时表示回复
+func (hdl *CommentHandler) Create(ctx *gin.Context) {
+
+	// 绑定参数
+	type Req struct {
+		Biz      string `json:"biz"`
+		BizId    int64  `json:"bizId" binding:"required"`
+		ParentId int64  `json:"parentId"`
+		Content  string `json:"content" binding:"required"`
+	}
+	var req Req
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		res.FailWithMsg("参数错误", ctx)
+		return
+	}
+	if req.Biz == "" {
+		req.Biz = "article"
+	}
+
+	// 校验评论长度
+	if !isValidComment(req.Content) {
+		res.FailWithMsg("评论内容超长", ctx)
+		return
+	}
+
+	// 获取用户 Token
+	_claims, _ := ctx.Get("claims")
+	claims := _claims.(*jwts.CustomClaims)
+
+	// 调用下层服务
+	c, err := hdl.svc.Create(ctx, domain.Comment{
+		Uid:      claims.UserId,
+		Biz:      req.Biz,
+		BizId:    req.BizId,
+		ParentId: req.ParentId,
+		Content:  req.Content,
+	})
+	if err != nil {
+		if errors.Is(err, service.ErrCommentTargetNotFound) {
+			res.FailWithMsg("评论对象不存在", ctx)
+			return
+		}
+		res.FailWithMsg("系统错误", ctx)
+		return
+	}
+
+	// 异步事件 —— 通知帖子作者或被回复的用户
+	err = hdl.producer.ProduceEvent(events.CommentEvent{
+		Uid:       c.Uid,
+		Biz:       c.Biz,
+		BizId:     c.BizId,
+		CommentId: c.Id,
+		ParentUid: c.ParentUid,
+		Content:   c.Content,
+	})
+	if err != nil {
+		log.Println("CommentEvent 生成错误：err : ", err.Error())
+	}
+	res.OKWithData(gin.H{"comment_id": c.Id}, ctx)
+}
+
+// isValidComment 校验评论长度
+func isValidComment(s string) bool {
+	const MaxLength = 1000
+	return utf8.RuneCountInString(s) <= MaxLength
+}
+
+// Delete 删除自己的评论
+func (hdl *CommentHandler) Delete(ctx *gin.Context) {
+
+	// 绑定参数
+	type Req struct{ Id int64 }
+	var req Req
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		res.FailWithMsg("参数错误", ctx)
+		return
+	}
+
+	// 获取用户 Token
+	_claims, _ := ctx.Get("claims")
+	claims := _claims.(*jwts.CustomClaims)
+
+	// 调用下层服务
+	err := hdl.svc.Delete(ctx, claims.UserId, req.Id)
+	if err != nil {
+		if errors.Is(err, service.ErrIncorrectCommentorUser) {
+			res.FailWithMsg("非法删除", ctx)
+			return
+		}
+		res.FailWithMsg("系统错误", ctx)
+		return
+	}
+	res.OKWithMsg("删除成功", ctx)
+}
+
+/*
+List 获取顶级评论列表API：
+使用游标分页，id 为上一页最后一条评论的 ID，首页不需要传递
+*/
+func (hdl *CommentHandler) List(ctx *gin.Context) {
+
+	// 绑定参数
+	biz := ctx.DefaultQuery("biz", "article")
+	bizId, err := strconv.ParseInt(ctx.Query("bizId"), 10, 64)
+	if bizId == 0 || err != nil {
+		res.FailWithMsg("参数错误", ctx)
+		return
+	}
+	lastId, limit, ok := parseCursor(ctx)
+	if !ok {
+		res.FailWithMsg("参数错误", ctx)
+		return
+	}
+
+	// 调用下层服务
+	list, err := hdl.svc.List(ctx, biz, bizId, lastId, limit)
+	if err != nil {
+		res.FailWithMsg("系统错误", ctx)
+		return
+	}
+	res.OKWithData(list, ctx)
+}
+
+/*
+Replies 获取回复列表API：
+使用游标分页，id 为上一页最后一条回复的 ID，首页不需要传递
+*/
+func (hdl *CommentHandler) Replies(ctx *gin.Context) {
+
+	// 绑定参数
+	rootId, err := strconv.ParseInt(ctx.Query("rootId"), 10, 64)
+	if rootId == 0 || err != nil {
+		res.FailWithMsg("参数错误", ctx)
+		return
+	}
+	lastId, limit, ok := parseCursor(ctx)
+	if !ok {
+		res.FailWithMsg("参数错误", ctx)
+		return
+	}
+
+	// 调用下层服务
+	list, err := hdl.svc.Replies(ctx, rootId, lastId, limit)
+	if err != nil {
+		res.FailWithMsg("系统错误", ctx)
+		return
+	}
+	res.OKWithData(list, ctx)
+}
+
+// parseCursor 解析游标分页参数 id 和 limit
+func parseCursor(ctx *gin.Context) (lastId int64, limit int, ok bool) {
+	var err error
+	limit = 20
+	if id := ctx.Query("id"); id != "" {
+		if lastId, err = strconv.ParseInt(id, 10, 64); err != nil {
+			return 0, 0, false
+		}
+	}
+	if l := ctx.Query("limit"); l != "" {
+		if limit, err = strconv.Atoi(l); err != nil || limit <= 0 || limit > 50 {
+			return 0, 0, false
+		}
+	}
+	return lastId, limit, true
+}
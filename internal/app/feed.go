@@ -0,0 +1,131 @@
+package app
+
+import (
+	"strconv"
+	"time"
+
+	"github.com/Linxhhh/webook/internal/domain"
+	"github.com/Linxhhh/webook/internal/service"
+	"github.com/Linxhhh/webook/pkg/jwts"
+	"github.com/Linxhhh/webook/pkg/res"
+	"github.com/gin-gonic/gin"
+)
+
+type FeedHandler struct {
+	svc *service.FeedEventService
+}
+
+func NewFeedHandler(svc *service.FeedEventService) *FeedHandler {
+	return &FeedHandler{
+		svc: svc,
+	}
+}
+
+func (hdl *FeedHandler) RegistryRouter(router *gin.Engine) {
+	router.GET("feed", hdl.Feed)
+}
+
+/*
+Feed 获取 Feed 流API：
+使用游标分页，timestamp、source 和 id 为上一页返回的游标，首页不需要传递
+*/
+func (hdl *FeedHandler) Feed(ctx *gin.Context) {
+
+	// 绑定参数
+	var (
+		cursor domain.FeedCursor
+		limit  int64 = 10
+		err    error
+	)
+	if ts := ctx.Query("timestamp"); ts != "" {
+		if cursor.Timestamp, err = strconv.ParseInt(ts, 10, 64); err != nil {
+			res.FailWithMsg("参数错误", ctx)
+			return
+		}
+	}
+	if src := ctx.Query("source"); src != "" {
+		source, err := strconv.ParseUint(src, 10, 8)
+		if err != nil {
+			res.FailWithMsg("参数错误", ctx)
+			return
+		}
+		cursor.Source = domain.FeedSource(source)
+	}
+	if id := ctx.Query("id"); id != "" {
+		if cursor.Id, err = strconv.ParseInt(id, 10, 64); err != nil {
+			res.FailWithMsg("参数错误", ctx)
+			return
+		}
+	}
+	if l := ctx.Query("limit"); l != "" {
+		if limit, err = strconv.ParseInt(l, 10, 64); err != nil || limit <= 0 || limit > 50 {
+			res.FailWithMsg("参数错误", ctx)
+			return
+		}
+	}
+
+	// 首页从当前时间开始查询
+	if cursor.Timestamp == 0 {
+		cursor.Timestamp = time.Now().Unix() + 1
+	}
+
+	// 获取用户 Token
+	_claims, _ := ctx.Get("claims")
+	claims := _claims.(*jwts.CustomClaims)
+
+	// 调用下层服务
+	elems, next, err := hdl.svc.GetFeed(ctx, claims.UserId, cursor, limit)
+	if err != nil {
+		res.FailWithMsg("系统错误", ctx)
+		return
+	}
+
+	// 返回响应
+	type Elem struct {
+		Id         int64             `json:"id"`
+		Type       string            `json:"type"`
+		Ctime      int64             `json:"ctime"`
+		Ext        map[string]string `json:"ext"`
+		ActorId    int64             `json:"actorId"`
+		ActorName  string            `json:"actorName"`
+		Aid        int64             `json:"aid"`
+		Title      string            `json:"title"`
+		AuthorId   int64             `json:"authorId"`
+		AuthorName string            `json:"authorName"`
+		ReadCnt    int64             `json:"readCnt"`
+		LikeCnt    int64             `json:"likeCnt"`
+		CollectCnt int64             `json:"collectCnt"`
+		CommentCnt int64             `json:"commentCnt"`
+	}
+	type Resp struct {
+		List      []Elem `json:"list"`
+		Timestamp int64  `json:"timestamp"` // 下一页游标
+		Source    uint8  `json:"source"`    // 下一页游标
+		Id        int64  `json:"id"`        // 下一页游标
+	}
+	list := make([]Elem, 0, len(elems))
+	for _, e := range elems {
+		list = append(list, Elem{
+			Id:         e.Event.Id,
+			Type:       e.Event.Type,
+			Ctime:      e.Event.Ctime.Unix(),
+			Ext:        e.Event.Ext,
+			ActorId:    e.Actor.Id,
+			ActorName:  e.Actor.NickName,
+			Aid:        e.Article.Id,
+			Title:      e.Article.Title,
+			AuthorId:   e.Article.AuthorId,
+			AuthorName: e.Article.AuthorName,
+			ReadCnt:    e.Interaction.ReadCnt,
+			LikeCnt:    e.Interaction.LikeCnt,
+			CollectCnt: e.Interaction.CollectCnt,
+			CommentCnt: e.Interaction.CommentCnt,
+		})
+	}
+	res.OKWithData(Resp{
+		List:      list,
+		Timestamp: next.Timestamp,
+		Source:    uint8(next.Source),
+		Id:        next.Id,
+	}, ctx)
+}
@@ -1,8 +1,10 @@
 package app
 
 import (
+	"log"
 	"strconv"
 
+	"github.com/Linxhhh/webook/internal/events"
 	"github.com/Linxhhh/webook/internal/service"
 	"github.com/Linxhhh/webook/pkg/jwts"
 	"github.com/Linxhhh/webook/pkg/res"
@@ -11,12 +13,14 @@ import (
 )
 
 type FollowHandler struct {
-	svc *service.FollowService
+	svc      *service.FollowService
+	producer *events.FollowEventProducer
 }
 
-func NewFollowHandler(svc *service.FollowService) *FollowHandler {
+func NewFollowHandler(svc *service.FollowService, producer *events.FollowEventProducer) *FollowHandler {
 	return &FollowHandler{
-		svc: svc,
+		svc:      svc,
+		producer: producer,
 	}
 }
 
@@ -55,6 +59,16 @@ func (hdl *FollowHandler) Follow(ctx *gin.Context) {
 		res.FailWithMsg("系统错误", ctx)
 		return
 	}
+
+	// 异步事件 —— 通知被关注的用户
+	err = hdl.producer.ProduceEvent(events.FollowEvent{
+		Follower: claims.UserId,
+		Followee: req.Id,
+		Follow:   req.Follow,
+	})
+	if err != nil {
+		log.Println("FollowEvent 生成错误：err : ", err.Error())
+	}
 	res.OKWithMsg("操作成功", ctx)
 }
 
@@ -2,24 +2,22 @@ package middleware
 
 import (
 	"log"
-	"time"
 
+	"github.com/Linxhhh/webook/internal/service"
 	"github.com/Linxhhh/webook/pkg/jwts"
-	"github.com/dgrijalva/jwt-go/v4"
 	"github.com/gin-gonic/gin"
 )
 
 /*
 AuthByJWT 鉴权中间件：
+校验短令牌和用户代理，并检查会话是否已经退出登录，短令牌过期后由前端使用长令牌刷新
 */
-func AuthByJWT() gin.HandlerFunc {
+func AuthByJWT(j *jwts.JWT, sessSvc *service.SessionService) gin.HandlerFunc {
 	return func(ctx *gin.Context) {
 		path := ctx.Request.URL.Path
-		for _, ignorePath := range ignorePaths {
+		if isIgnored(path) {
 			// 不需要登录鉴权
-			if path == ignorePath {
-				return
-			}
+			return
 		}
 
 		// 获取 Token
@@ -30,7 +28,7 @@ func AuthByJWT() gin.HandlerFunc {
 			return
 		}
 
-		claims, err := jwts.ParseToken(token)
+		claims, err := j.ParseToken(token)
 		if err != nil {
 			ctx.String(200, "令牌错误!")
 			ctx.Abort()
@@ -44,18 +42,20 @@ func AuthByJWT() gin.HandlerFunc {
 			return
 		}
 
-		// 如果剩余有效期小于30分钟，则刷新有效期
-		if time.Until(claims.ExpiresAt.Time) < time.Minute*30 {
-
-			claims.ExpiresAt = &jwt.Time{Time: time.Now().Add(time.Hour * 8)} // 刷新有效期：未来两小时
-
-			newToken := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-			tokenStr, err := newToken.SignedString([]byte("secret"))
-			if err != nil {
-				log.Printf("jwt 续约失败，err : %s", err)
+		// 校验会话是否已经退出登录
+		if err = sessSvc.Check(ctx, claims.Ssid); err != nil {
+			if err == service.ErrSessionRevoked {
+				ctx.String(200, "会话已失效!")
 			} else {
-				ctx.Header("jwt-token", tokenStr)
+				ctx.String(200, "系统错误!")
 			}
+			ctx.Abort()
+			return
+		}
+
+		// 更新会话的最近活跃时间，失败不影响本次请求
+		if err = sessSvc.Touch(ctx, claims.Ssid); err != nil {
+			log.Printf("更新会话活跃时间失败，err : %s", err)
 		}
 
 		// 如果通过验证，则设置 claims 上下文
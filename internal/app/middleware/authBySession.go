@@ -15,11 +15,9 @@ AuthBySession 鉴权中间件：
 func AuthBySession() gin.HandlerFunc {
 	return func(ctx *gin.Context) {
 		path := ctx.Request.URL.Path
-		for _, ignorePath := range ignorePaths {
+		if isIgnored(path) {
 			// 不需要登录鉴权
-			if path == ignorePath {
-				return
-			}
+			return
 		}
 
 		// 获取 Session
@@ -1,9 +1,35 @@
 package middleware
 
+import "strings"
 
 var ignorePaths = []string{
 	"/user/signup", 
 	"/user/login",
+	"/user/refresh_token",
+	"/user/password/forgot",
+	"/user/password/reset",
+	"/user/unlock/send",
+	"/user/unlock",
 	"/user/sms/send",
 	"/user/sms/verify",
-}
\ No newline at end of file
+}
+
+// 按照前缀匹配，不需要登录鉴权的路由
+var ignorePrefixes = []string{
+	"/oauth2/",
+}
+
+// isIgnored 判断路由是否不需要登录鉴权
+func isIgnored(path string) bool {
+	for _, ignorePath := range ignorePaths {
+		if path == ignorePath {
+			return true
+		}
+	}
+	for _, prefix := range ignorePrefixes {
+		if strings.HasPrefix(path, prefix) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,84 @@
+package middleware
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"math"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/Linxhhh/webook/pkg/jwts"
+	"github.com/gin-gonic/gin"
+)
+
+// 限流的维度
+const (
+	RateLimitByIP   = "ip"
+	RateLimitByUser = "user"
+)
+
+// WindowLimiter 能够给出等待时间的限流器
+type WindowLimiter interface {
+	Acquire(ctx context.Context, key string) (bool, time.Duration, error)
+}
+
+/*
+RateLimitRule 限流规则：
+Method、Path 为空时匹配所有请求，Key 为 user 时按照用户限流，未登录的请求仍然按照 IP 限流
+*/
+type RateLimitRule struct {
+	Method  string
+	Path    string
+	Key     string
+	Limiter WindowLimiter
+}
+
+/*
+RateLimit 限流中间件：
+依次检查匹配的规则，触发限流时返回 429 状态码和 Retry-After 响应头；
+限流器异常时放行请求，避免 Redis 故障导致整个服务不可用。
+按照用户限流的规则需要注册在鉴权中间件之后
+*/
+func RateLimit(rules []RateLimitRule) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		for _, rule := range rules {
+			if !rule.match(ctx) {
+				continue
+			}
+
+			limited, wait, err := rule.Limiter.Acquire(ctx, rule.key(ctx))
+			if err != nil {
+				log.Printf("限流器异常，err : %s", err)
+				continue
+			}
+			if limited {
+				// 向上取整到秒，至少为 1 秒
+				retry := max(1, int(math.Ceil(wait.Seconds())))
+				ctx.Header("Retry-After", strconv.Itoa(retry))
+				ctx.String(http.StatusTooManyRequests, "请求过于频繁!")
+				ctx.Abort()
+				return
+			}
+		}
+	}
+}
+
+func (r RateLimitRule) match(ctx *gin.Context) bool {
+	if r.Method != "" && r.Method != ctx.Request.Method {
+		return false
+	}
+	return r.Path == "" || r.Path == ctx.Request.URL.Path
+}
+
+// key 限流的键，不同的规则使用不同的键
+func (r RateLimitRule) key(ctx *gin.Context) string {
+	if r.Key == RateLimitByUser {
+		if _claims, ok := ctx.Get("claims"); ok {
+			claims := _claims.(*jwts.CustomClaims)
+			return fmt.Sprintf("ratelimit:%s:%s:user:%d", r.Method, r.Path, claims.UserId)
+		}
+	}
+	return fmt.Sprintf("ratelimit:%s:%s:ip:%s", r.Method, r.Path, ctx.ClientIP())
+}
@@ -0,0 +1,149 @@
+package middleware
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/Linxhhh/webook/pkg/jwts"
+	"github.com/gin-gonic/gin"
+)
+
+// stubLimiter 记录收到的键，返回预设的限流结果
+type stubLimiter struct {
+	limited bool
+	wait    time.Duration
+	err     error
+	keys    []string
+}
+
+func (l *stubLimiter) Acquire(ctx context.Context, key string) (bool, time.Duration, error) {
+	l.keys = append(l.keys, key)
+	return l.limited, l.wait, l.err
+}
+
+// serve 使用限流中间件处理一个请求，uid 大于 0 时模拟已登录的用户
+func serve(rules []RateLimitRule, method, path string, uid int64) *httptest.ResponseRecorder {
+	gin.SetMode(gin.TestMode)
+	engine := gin.New()
+	engine.Use(func(ctx *gin.Context) {
+		if uid > 0 {
+			ctx.Set("claims", &jwts.CustomClaims{JwtPayload: jwts.JwtPayload{UserId: uid}})
+		}
+	}, RateLimit(rules))
+	engine.Handle(method, path, func(ctx *gin.Context) {
+		ctx.String(http.StatusOK, "ok")
+	})
+
+	req := httptest.NewRequest(method, path, nil)
+	req.RemoteAddr = "10.0.0.1:1234"
+	recorder := httptest.NewRecorder()
+	engine.ServeHTTP(recorder, req)
+	return recorder
+}
+
+func TestRateLimitMatch(t *testing.T) {
+	testCases := []struct {
+		name   string
+		rule   RateLimitRule
+		method string
+		path   string
+		want   bool // 规则是否生效
+	}{
+		{name: "匹配所有请求", rule: RateLimitRule{}, method: http.MethodGet, path: "/a", want: true},
+		{name: "只匹配方法", rule: RateLimitRule{Method: http.MethodPost}, method: http.MethodPost, path: "/a", want: true},
+		{name: "方法不匹配", rule: RateLimitRule{Method: http.MethodPost}, method: http.MethodGet, path: "/a"},
+		{name: "只匹配路径", rule: RateLimitRule{Path: "/a"}, method: http.MethodGet, path: "/a", want: true},
+		{name: "路径不匹配", rule: RateLimitRule{Path: "/a"}, method: http.MethodGet, path: "/b"},
+		{name: "方法和路径都匹配", rule: RateLimitRule{Method: http.MethodPost, Path: "/a"}, method: http.MethodPost, path: "/a", want: true},
+		{name: "方法匹配路径不匹配", rule: RateLimitRule{Method: http.MethodPost, Path: "/a"}, method: http.MethodPost, path: "/b"},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			l := &stubLimiter{limited: true, wait: time.Second}
+			tc.rule.Limiter = l
+			code := serve([]RateLimitRule{tc.rule}, tc.method, tc.path, 0).Code
+			if got := code == http.StatusTooManyRequests; got != tc.want {
+				t.Fatalf("规则是否生效：期望 %v，实际状态码 %d", tc.want, code)
+			}
+			if got := len(l.keys) > 0; got != tc.want {
+				t.Fatalf("不匹配的规则不应该调用限流器：%v", l.keys)
+			}
+		})
+	}
+}
+
+func TestRateLimitKey(t *testing.T) {
+	testCases := []struct {
+		name string
+		by   string
+		uid  int64
+		want string
+	}{
+		{name: "按照 IP 限流", by: RateLimitByIP, uid: 7, want: "ratelimit:POST:/login:ip:10.0.0.1"},
+		{name: "按照用户限流", by: RateLimitByUser, uid: 7, want: "ratelimit:POST:/login:user:7"},
+		{name: "未登录时按照 IP 限流", by: RateLimitByUser, want: "ratelimit:POST:/login:ip:10.0.0.1"},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			l := &stubLimiter{}
+			rules := []RateLimitRule{{Method: http.MethodPost, Path: "/login", Key: tc.by, Limiter: l}}
+			if code := serve(rules, http.MethodPost, "/login", tc.uid).Code; code != http.StatusOK {
+				t.Fatalf("没有触发限流时应该放行：%d", code)
+			}
+			if len(l.keys) != 1 || l.keys[0] != tc.want {
+				t.Fatalf("限流的键错误：%v", l.keys)
+			}
+		})
+	}
+}
+
+func TestRateLimitRetryAfter(t *testing.T) {
+	testCases := []struct {
+		name string
+		wait time.Duration
+		want string
+	}{
+		{name: "整秒", wait: 2 * time.Second, want: "2"},
+		{name: "向上取整", wait: 1500 * time.Millisecond, want: "2"},
+		{name: "不足 1 秒", wait: time.Millisecond, want: "1"},
+		{name: "没有等待时间", wait: 0, want: "1"},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			rules := []RateLimitRule{{Limiter: &stubLimiter{limited: true, wait: tc.wait}}}
+			recorder := serve(rules, http.MethodGet, "/a", 0)
+			if recorder.Code != http.StatusTooManyRequests {
+				t.Fatalf("期望 429，实际 %d", recorder.Code)
+			}
+			if got := recorder.Header().Get("Retry-After"); got != tc.want {
+				t.Fatalf("Retry-After 期望 %s，实际 %s", tc.want, got)
+			}
+		})
+	}
+}
+
+func TestRateLimitRules(t *testing.T) {
+	// 按顺序检查所有匹配的规则，触发限流之后不再检查后面的规则
+	first, second, third := &stubLimiter{}, &stubLimiter{limited: true, wait: time.Second}, &stubLimiter{}
+	rules := []RateLimitRule{{Limiter: first}, {Path: "/a", Limiter: second}, {Limiter: third}}
+	if code := serve(rules, http.MethodGet, "/a", 0).Code; code != http.StatusTooManyRequests {
+		t.Fatalf("期望 429，实际 %d", code)
+	}
+	if len(first.keys) != 1 || len(second.keys) != 1 || len(third.keys) != 0 {
+		t.Fatalf("规则检查顺序错误：%v %v %v", first.keys, second.keys, third.keys)
+	}
+
+	// 限流器异常时放行请求，并继续检查后面的规则
+	broken, next := &stubLimiter{err: errors.New("redis 不可用")}, &stubLimiter{}
+	rules = []RateLimitRule{{Limiter: broken}, {Limiter: next}}
+	if code := serve(rules, http.MethodGet, "/a", 0).Code; code != http.StatusOK {
+		t.Fatalf("限流器异常时应该放行：%d", code)
+	}
+	if len(next.keys) != 1 {
+		t.Fatal("限流器异常之后应该继续检查后面的规则")
+	}
+}
@@ -0,0 +1,27 @@
+package middleware
+
+import (
+	"net/http"
+
+	"github.com/Linxhhh/webook/internal/domain"
+	"github.com/Linxhhh/webook/pkg/jwts"
+	"github.com/gin-gonic/gin"
+)
+
+// RequireRole 角色校验中间件：注册在需要登录的路由上，角色低于 role 时返回 403 状态码
+func RequireRole(role domain.Role) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		_claims, ok := ctx.Get("claims")
+		if !ok {
+			ctx.String(http.StatusForbidden, "权限不足!")
+			ctx.Abort()
+			return
+		}
+		claims := _claims.(*jwts.CustomClaims)
+		if domain.Role(claims.Role) < role {
+			ctx.String(http.StatusForbidden, "权限不足!")
+			ctx.Abort()
+			return
+		}
+	}
+}
@@ -0,0 +1,113 @@
+package app
+
+import (
+	"strconv"
+
+	"github.com/Linxhhh/webook/internal/service"
+	"github.com/Linxhhh/webook/pkg/jwts"
+	"github.com/Linxhhh/webook/pkg/res"
+	"github.com/gin-gonic/gin"
+)
+
+type NotificationHandler struct {
+	svc *service.NotificationService
+}
+
+func NewNotificationHandler(svc *service.NotificationService) *NotificationHandler {
+	return &NotificationHandler{
+		svc: svc,
+	}
+}
+
+func (hdl *NotificationHandler) RegistryRouter(router *gin.Engine) {
+	ng := router.Group("notifications")
+	ng.GET("", hdl.List)                    // 通知列表
+	ng.GET("unread_count", hdl.UnreadCount) // 未读数量
+	ng.POST("read", hdl.MarkRead)           // 标记已读
+}
+
+/*
+List 获取通知列表API：
+使用游标分页，id 为上一页最后一条通知的 ID，首页不需要传递
+*/
+func (hdl *NotificationHandler) List(ctx *gin.Context) {
+
+	// 绑定参数
+	var (
+		lastId int64
+		limit  = 20
+		err    error
+	)
+	if id := ctx.Query("id"); id != "" {
+		if lastId, err = strconv.ParseInt(id, 10, 64); err != nil {
+			res.FailWithMsg("参数错误", ctx)
+			return
+		}
+	}
+	if l := ctx.Query("limit"); l != "" {
+		if limit, err = strconv.Atoi(l); err != nil || limit <= 0 || limit > 50 {
+			res.FailWithMsg("参数错误", ctx)
+			return
+		}
+	}
+
+	// 获取用户 Token
+	_claims, _ := ctx.Get("claims")
+	claims := _claims.(*jwts.CustomClaims)
+
+	// 调用下层服务
+	list, err := hdl.svc.List(ctx, claims.UserId, lastId, limit)
+	if err != nil {
+		res.FailWithMsg("系统错误", ctx)
+		return
+	}
+	res.OKWithData(list, ctx)
+}
+
+// UnreadCount 获取未读通知数量
+func (hdl *NotificationHandler) UnreadCount(ctx *gin.Context) {
+
+	// 获取用户 Token
+	_claims, _ := ctx.Get("claims")
+	claims := _claims.(*jwts.CustomClaims)
+
+	// 调用下层服务
+	cnt, err := hdl.svc.UnreadCount(ctx, claims.UserId)
+	if err != nil {
+		res.FailWithMsg("系统错误", ctx)
+		return
+	}
+	res.OKWithData(gin.H{"unreadCount": cnt}, ctx)
+}
+
+// MarkRead 标记通知为已读，all 为 true 时标记全部通知
+func (hdl *NotificationHandler) MarkRead(ctx *gin.Context) {
+
+	// 绑定参数
+	type Req struct {
+		Id  int64 `json:"id"`
+		All bool  `json:"all"`
+	}
+	var req Req
+	if err := ctx.ShouldBindJSON(&req); err != nil || (req.Id == 0 && !req.All) {
+		res.FailWithMsg("参数错误", ctx)
+		return
+	}
+
+	// 获取用户 Token
+	_claims, _ := ctx.Get("claims")
+	claims := _claims.(*jwts.CustomClaims)
+
+	// 调用下层服务
+	var err error
+	if req.All {
+		err = hdl.svc.MarkAllRead(ctx, claims.UserId)
+	} else {
+		err = hdl.svc.MarkRead(ctx, claims.UserId, req.Id)
+	}
+	if err != nil {
+		res.FailWithMsg("系统错误", ctx)
+		return
+	}
+	res.OKWithMsg("操作成功", ctx)
+}
@@ -0,0 +1,217 @@
+package app
+
+import (
+	"errors"
+	"log"
+	"net/http"
+
+	"github.com/Linxhhh/webook/internal/service"
+	"github.com/Linxhhh/webook/pkg/jwts"
+	"github.com/Linxhhh/webook/pkg/res"
+	"github.com/gin-gonic/gin"
+)
+
+/*
+state 同时保存在 HttpOnly Cookie 中，回调时必须和参数中的 state 一致，
+防止攻击者把自己获取的 state 和授权码植入受害者的回调（登录 CSRF）；有效期和 Redis 中的 state 一致
+*/
+const (
+	oauth2StateCookie = "oauth2_state"
+	oauth2StateMaxAge = 10 * 60
+)
+
+type OAuth2Handler struct {
+	svc     *service.OAuth2Service
+	userSvc *service.UserService
+	sessSvc *service.SessionService
+	jwt     *jwts.JWT
+}
+
+func NewOAuth2Handler(svc *service.OAuth2Service, userSvc *service.UserService, sessSvc *service.SessionService, jwt *jwts.JWT) *OAuth2Handler {
+	return &OAuth2Handler{
+		svc:     svc,
+		userSvc: userSvc,
+		sessSvc: sessSvc,
+		jwt:     jwt,
+	}
+}
+
+func (hdl *OAuth2Handler) RegistryRouter(router *gin.Engine) {
+	og := router.Group("oauth2")
+	og.GET(":provider/authurl", hdl.AuthURL)   // 获取授权地址
+	og.GET(":provider/callback", hdl.Callback) // 授权回调：登录或注册
+
+	// 已登录用户绑定第三方账号
+	ug := router.Group("user")
+	ug.GET("bind/oauth2/:provider/authurl", hdl.BindAuthURL) // 获取绑定的授权地址
+	ug.POST("bind/oauth2/:provider", hdl.Bind)               // 授权回调：绑定
+	ug.DELETE("bind/oauth2/:provider", hdl.Unbind)           // 解绑
+}
+
+// AuthURL 获取第三方平台的授权地址，用于登录
+func (hdl *OAuth2Handler) AuthURL(ctx *gin.Context) {
+	hdl.authURL(ctx, 0)
+}
+
+// BindAuthURL 获取第三方平台的授权地址，用于已登录用户绑定
+func (hdl *OAuth2Handler) BindAuthURL(ctx *gin.Context) {
+
+	// 获取用户 Token
+	_claims, _ := ctx.Get("claims")
+	claims := _claims.(*jwts.CustomClaims)
+
+	hdl.authURL(ctx, claims.UserId)
+}
+
+// authURL 生成授权地址，并把 state 写入 Cookie
+func (hdl *OAuth2Handler) authURL(ctx *gin.Context, uid int64) {
+
+	// 调用下层服务
+	url, state, err := hdl.svc.AuthURL(ctx, ctx.Param("provider"), uid)
+	if err != nil {
+		if errors.Is(err, service.ErrOAuth2ProviderNotFound) {
+			res.FailWithMsg("不支持的登录方式", ctx)
+			return
+		}
+		res.FailWithMsg("系统错误", ctx)
+		return
+	}
+
+	// 把 state 绑定到当前浏览器
+	ctx.SetSameSite(http.SameSiteLaxMode)
+	ctx.SetCookie(oauth2StateCookie, state, oauth2StateMaxAge, "/", "", ctx.Request.TLS != nil, true)
+	res.OKWithData(gin.H{"url": url}, ctx)
+}
+
+// checkStateCookie 校验 Cookie 中的 state 和参数一致，校验之后删除 Cookie
+func checkStateCookie(ctx *gin.Context, state string) bool {
+	cookie, err := ctx.Cookie(oauth2StateCookie)
+	ctx.SetSameSite(http.SameSiteLaxMode)
+	ctx.SetCookie(oauth2StateCookie, "", -1, "/", "", ctx.Request.TLS != nil, true)
+	return err == nil && cookie == state
+}
+
+/*
+Callback 授权回调API：
+校验 state（Cookie 和 Redis）之后，使用授权码获取第三方用户信息，查找或创建用户，返回用户 Token
+*/
+func (hdl *OAuth2Handler) Callback(ctx *gin.Context) {
+
+	// 绑定参数
+	code, state := ctx.Query("code"), ctx.Query("state")
+	if code == "" || state == "" {
+		res.FailWithMsg("参数错误", ctx)
+		return
+	}
+
+	// 校验 state 是否由当前浏览器发起
+	if !checkStateCookie(ctx, state) {
+		res.FailWithMsg("非法请求", ctx)
+		return
+	}
+
+	// 调用下层服务
+	uid, err := hdl.svc.Login(ctx, ctx.Param("provider"), code, state)
+	switch {
+	case err == nil:
+	case errors.Is(err, service.ErrOAuth2ProviderNotFound):
+		res.FailWithMsg("不支持的登录方式", ctx)
+		return
+	case errors.Is(err, service.ErrInvalidOAuth2State):
+		res.FailWithMsg("非法请求", ctx)
+		return
+	case errors.Is(err, service.ErrOAuth2Failed):
+		log.Println("第三方授权失败：err : ", err.Error())
+		res.FailWithMsg("授权失败", ctx)
+		return
+	default:
+		res.FailWithMsg("系统错误", ctx)
+		return
+	}
+
+	// 检查账号是否被封禁
+	user, err := hdl.userSvc.LoginInfo(ctx, uid)
+	switch err {
+	case nil:
+	case service.ErrUserBanned:
+		res.FailWithMsg("账号已被封禁", ctx)
+		return
+	default:
+		res.FailWithMsg("系统错误", ctx)
+		return
+	}
+
+	// 生成 Token
+	if err = setLoginToken(ctx, hdl.jwt, hdl.sessSvc, user); err != nil {
+		res.FailWithMsg("生成用户令牌错误！", ctx)
+		return
+	}
+	res.OKWithMsg("登陆成功", ctx)
+}
+
+/*
+Bind 绑定第三方账号API：
+授权地址通过 BindAuthURL 获取，state 只能由当前用户使用，前端拿到授权码之后调用该接口
+*/
+func (hdl *OAuth2Handler) Bind(ctx *gin.Context) {
+
+	// 绑定参数
+	type Req struct {
+		Code  string `json:"code" binding:"required"`
+		State string `json:"state" binding:"required"`
+	}
+	var req Req
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		res.FailWithMsg("参数错误", ctx)
+		return
+	}
+
+	// 校验 state 是否由当前浏览器发起
+	if !checkStateCookie(ctx, req.State) {
+		res.FailWithMsg("非法请求", ctx)
+		return
+	}
+
+	// 获取用户 Token
+	_claims, _ := ctx.Get("claims")
+	claims := _claims.(*jwts.CustomClaims)
+
+	// 调用下层服务
+	err := hdl.svc.Bind(ctx, claims.UserId, ctx.Param("provider"), req.Code, req.State)
+	switch {
+	case err == nil:
+		res.OKWithMsg("绑定成功", ctx)
+	case errors.Is(err, service.ErrOAuth2ProviderNotFound):
+		res.FailWithMsg("不支持的登录方式", ctx)
+	case errors.Is(err, service.ErrInvalidOAuth2State):
+		res.FailWithMsg("非法请求", ctx)
+	case errors.Is(err, service.ErrOAuth2Failed):
+		log.Println("第三方授权失败：err : ", err.Error())
+		res.FailWithMsg("授权失败", ctx)
+	case errors.Is(err, service.ErrAccountBound):
+		res.FailWithMsg("第三方账号已被绑定", ctx)
+	default:
+		res.FailWithMsg("系统错误", ctx)
+	}
+}
+
+// Unbind 解绑第三方账号
+func (hdl *OAuth2Handler) Unbind(ctx *gin.Context) {
+
+	// 获取用户 Token
+	_claims, _ := ctx.Get("claims")
+	claims := _claims.(*jwts.CustomClaims)
+
+	// 调用下层服务
+	err := hdl.userSvc.UnbindOAuth2(ctx, claims.UserId, ctx.Param("provider"))
+	switch err {
+	case nil:
+		res.OKWithMsg("解绑成功", ctx)
+	case service.ErrBindingNotFound:
+		res.FailWithMsg("未绑定该第三方账号", ctx)
+	case service.ErrLastLoginMethod:
+		res.FailWithMsg("至少需要保留一种登录方式", ctx)
+	default:
+		res.FailWithMsg("系统错误", ctx)
+	}
+}
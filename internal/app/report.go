@@ -0,0 +1,76 @@
+package app
+
+import (
+	"unicode/utf8"
+
+	"github.com/Linxhhh/webook/internal/domain"
+	"github.com/Linxhhh/webook/internal/service"
+	"github.com/Linxhhh/webook/pkg/jwts"
+	"github.com/Linxhhh/webook/pkg/res"
+	"github.com/gin-gonic/gin"
+)
+
+type ReportHandler struct {
+	svc *service.ReportService
+}
+
+func NewReportHandler(svc *service.ReportService) *ReportHandler {
+	return &ReportHandler{
+		svc: svc,
+	}
+}
+
+func (hdl *ReportHandler) RegistryRouter(router *gin.Engine) {
+	pg := router.Group("pub")
+	pg.POST("report", hdl.Report) // 举报帖子、评论或用户
+}
+
+/*
+Report 举报API：
+biz 为 article、comment 或 user，reason 为 spam、abuse、porn、illegal 或 other，detail 为补充说明
+*/
+func (hdl *ReportHandler) Report(ctx *gin.Context) {
+
+	// 绑定参数
+	type Req struct {
+		Biz    string `json:"biz" binding:"required"`
+		BizId  int64  `json:"bizId" binding:"required"`
+		Reason string `json:"reason" binding:"required"`
+		Detail string `json:"detail"`
+	}
+	var req Req
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		res.FailWithMsg("参数错误", ctx)
+		return
+	}
+	reason, ok := domain.ParseReportReason(req.Reason)
+	if !ok || utf8.RuneCountInString(req.Detail) > 500 {
+		res.FailWithMsg("参数错误", ctx)
+		return
+	}
+
+	// 获取用户 Token
+	_claims, _ := ctx.Get("claims")
+	claims := _claims.(*jwts.CustomClaims)
+
+	// 调用下层服务
+	err := hdl.svc.Report(ctx, domain.Report{
+		Uid:    claims.UserId,
+		Biz:    req.Biz,
+		BizId:  req.BizId,
+		Reason: reason,
+		Detail: req.Detail,
+	})
+	switch err {
+	case nil:
+		res.OKWithMsg("举报成功", ctx)
+	case service.ErrDuplicateReport:
+		res.FailWithMsg("已经举报过了", ctx)
+	case service.ErrReportTargetNotFound:
+		res.FailWithMsg("举报对象不存在", ctx)
+	case service.ErrReportSelf:
+		res.FailWithMsg("不能举报自己", ctx)
+	default:
+		res.FailWithMsg("系统错误", ctx)
+	}
+}
@@ -1,6 +1,9 @@
 package app
 
 import (
+	"fmt"
+	"log"
+	"strconv"
 	"time"
 	"unicode/utf8"
 
@@ -16,27 +19,48 @@ import (
 
 const (
 	biz                  = "login"
+	bizResetPassword     = "reset_password"
+	bizUnlockAccount     = "unlock_account"
 	emailRegexPattern    = `^\w+([-+.]\\w+)*@\w+([-.]\w+)*\.\w+([-.]\w+)*$`
 	passwordRegexPattern = `^(?=.*[A-Za-z])(?=.*\d)(?=.*[@$!%*#?.&])[A-Za-z\d@$!%*#?.&]{8,}$`
 	phoneRegexPattern    = `^(\+?0?86\-?)?1[345789]\d{9}$`
 )
 
 type UserHandler struct {
-	svc     *service.UserService
-	codeSvc *service.CodeService
+	svc      *service.UserService
+	codeSvc  *service.CodeService
+	sessSvc  *service.SessionService
+	guardSvc *service.LoginGuardService
+	jwt      *jwts.JWT
 }
 
-func NewUserHandler(svc *service.UserService, codeSvc *service.CodeService) *UserHandler {
+func NewUserHandler(svc *service.UserService, codeSvc *service.CodeService, sessSvc *service.SessionService,
+	guardSvc *service.LoginGuardService, jwt *jwts.JWT) *UserHandler {
 	return &UserHandler{
-		svc: svc,
-		codeSvc: codeSvc,
+		svc:      svc,
+		codeSvc:  codeSvc,
+		sessSvc:  sessSvc,
+		guardSvc: guardSvc,
+		jwt:      jwt,
 	}
 }
 
 func (hdl *UserHandler) RegistryRouter(router *gin.Engine) {
 	ug := router.Group("user")
-	ug.POST("signup", hdl.SignUp)    // 用户注册
-	ug.POST("login", hdl.LoginByJWT) // 用户登录
+	ug.POST("signup", hdl.SignUp)              // 用户注册
+	ug.POST("login", hdl.LoginByJWT)           // 用户登录
+	ug.POST("logout", hdl.Logout)              // 退出登录
+	ug.POST("refresh_token", hdl.RefreshToken) // 刷新短令牌
+
+	ug.POST("password/change", hdl.ChangePassword)        // 修改密码
+	ug.POST("password/forgot", hdl.SendResetPasswordCode) // 找回密码：发送验证码
+	ug.POST("password/reset", hdl.ResetPassword)          // 找回密码：校验验证码，设置新密码
+
+	ug.POST("unlock/send", hdl.SendUnlockCode) // 账号锁定：发送短信验证码
+	ug.POST("unlock", hdl.Unlock)              // 账号锁定：校验验证码，解除锁定
+
+	ug.GET("sessions", hdl.Sessions)           // 登录设备列表
+	ug.DELETE("sessions/:id", hdl.KickSession) // 下线指定设备
 
 	ug.PUT("sms/send", hdl.SendSmsCode)      // 短信验证码登录：发送验证码
 	ug.POST("sms/verify", hdl.VerifySmsCode) // 短信验证码登录：校验验证码
@@ -129,16 +153,42 @@ func (hdl *UserHandler) LoginBySession(ctx *gin.Context) {
 		return
 	}
 
+	// 登录防护检查
+	ip := ctx.ClientIP()
+	if wait, err := hdl.guardSvc.Check(ctx, req.Email, ip); err != nil {
+		switch err {
+		case service.ErrAccountLocked:
+			res.FailWithMsg(fmt.Sprintf("登录失败次数过多，账号已被锁定，请 %d 分钟后重试或通过短信验证码解锁", minutes(wait)), ctx)
+		case service.ErrIpLocked:
+			res.FailWithMsg(fmt.Sprintf("登录失败次数过多，请 %d 分钟后重试", minutes(wait)), ctx)
+		case service.ErrLoginTooFrequent:
+			res.FailWithMsg(fmt.Sprintf("登录过于频繁，请 %d 秒后重试", int(wait.Seconds())+1), ctx)
+		default:
+			res.FailWithMsg("系统错误", ctx)
+		}
+		return
+	}
+
 	// 调用服务
 	user, err := hdl.svc.Login(ctx, req.Email, req.Password)
-	if err == service.ErrInvalidEmailOrPassword {
+	switch err {
+	case nil:
+	case service.ErrInvalidEmailOrPassword:
+		if err = hdl.guardSvc.Fail(ctx, req.Email, ip); err != nil {
+			log.Println("记录登录失败次数失败：err : ", err.Error())
+		}
 		res.FailWithMsg("邮箱或密码错误", ctx)
 		return
-	}
-	if err != nil {
+	case service.ErrUserBanned:
+		res.FailWithMsg("账号已被封禁", ctx)
+		return
+	default:
 		res.FailWithMsg("系统错误", ctx)
 		return
 	}
+	if err = hdl.guardSvc.Succeed(ctx, req.Email); err != nil {
+		log.Println("清空登录失败次数失败：err : ", err.Error())
+	}
 
 	// 设置 Session
 	session := sessions.Default(ctx)
@@ -168,30 +218,494 @@ func (hdl *UserHandler) LoginByJWT(ctx *gin.Context) {
 		return
 	}
 
+	// 登录防护检查
+	ip := ctx.ClientIP()
+	if wait, err := hdl.guardSvc.Check(ctx, req.Email, ip); err != nil {
+		switch err {
+		case service.ErrAccountLocked:
+			res.FailWithMsg(fmt.Sprintf("登录失败次数过多，账号已被锁定，请 %d 分钟后重试或通过短信验证码解锁", minutes(wait)), ctx)
+		case service.ErrIpLocked:
+			res.FailWithMsg(fmt.Sprintf("登录失败次数过多，请 %d 分钟后重试", minutes(wait)), ctx)
+		case service.ErrLoginTooFrequent:
+			res.FailWithMsg(fmt.Sprintf("登录过于频繁，请 %d 秒后重试", int(wait.Seconds())+1), ctx)
+		default:
+			res.FailWithMsg("系统错误", ctx)
+		}
+		return
+	}
+
 	// 调用服务
 	user, err := hdl.svc.Login(ctx, req.Email, req.Password)
-	if err == service.ErrInvalidEmailOrPassword {
+	switch err {
+	case nil:
+	case service.ErrInvalidEmailOrPassword:
+		if err = hdl.guardSvc.Fail(ctx, req.Email, ip); err != nil {
+			log.Println("记录登录失败次数失败：err : ", err.Error())
+		}
 		res.FailWithMsg("邮箱或密码错误", ctx)
 		return
-	}
-	if err != nil {
+	case service.ErrUserBanned:
+		res.FailWithMsg("账号已被封禁", ctx)
+		return
+	default:
 		res.FailWithMsg("系统错误", ctx)
 		return
 	}
+	if err = hdl.guardSvc.Succeed(ctx, req.Email); err != nil {
+		log.Println("清空登录失败次数失败：err : ", err.Error())
+	}
 
 	// 生成 Token
-	token, err := jwts.GenToken(jwts.JwtPayload{
-		UserId:    user.Id,
+	if err = setLoginToken(ctx, hdl.jwt, hdl.sessSvc, user); err != nil {
+		res.FailWithMsg("生成用户令牌错误！", ctx)
+		return
+	}
+	res.OKWithMsg("登陆成功", ctx)
+}
+
+// setLoginToken 为新会话生成长短令牌，通过响应头返回
+func setLoginToken(ctx *gin.Context, j *jwts.JWT, sessSvc *service.SessionService, user domain.User) error {
+	uid := user.Id
+	payload := jwts.JwtPayload{
+		UserId:    uid,
 		UserAgent: ctx.GetHeader("User-Agent"),
+		Ssid:      jwts.NewSsid(),
+		Role:      uint8(user.Role),
+	}
+	token, err := j.GenToken(payload)
+	if err != nil {
+		return err
+	}
+	refreshToken, err := j.GenRefreshToken(payload)
+	if err != nil {
+		return err
+	}
+
+	// 记录登录设备
+	err = sessSvc.Create(ctx, domain.Session{
+		Uid:       uid,
+		Ssid:      payload.Ssid,
+		UserAgent: payload.UserAgent,
+		Ip:        ctx.ClientIP(),
+		ExpireAt:  time.Now().Add(j.RefreshExpire()),
 	})
 	if err != nil {
-		res.FailWithMsg("生成用户令牌错误！", ctx)
+		return err
+	}
+
+	ctx.Header("jwt-token", token)
+	ctx.Header("refresh-token", refreshToken)
+	return nil
+}
+
+/*
+RefreshToken 刷新短令牌API：
+校验请求头中的长令牌和用户代理，会话未退出登录时，签发新的短令牌
+*/
+func (hdl *UserHandler) RefreshToken(ctx *gin.Context) {
+
+	// 获取长令牌
+	claims, err := hdl.jwt.ParseRefreshToken(ctx.GetHeader("refresh-token"))
+	if err != nil {
+		res.FailWithMsg("令牌错误", ctx)
 		return
 	}
 
-	// 返回用户token
+	// 对用户代理进行校验，和 AuthByJWT 保持一致，长令牌只能在签发时的设备上使用
+	if claims.UserAgent != ctx.GetHeader("User-Agent") {
+		res.FailWithMsg("用户代理更改", ctx)
+		return
+	}
+
+	// 校验会话
+	err = hdl.sessSvc.Check(ctx, claims.Ssid)
+	switch err {
+	case nil:
+	case service.ErrSessionRevoked:
+		res.FailWithMsg("会话已失效", ctx)
+		return
+	default:
+		res.FailWithMsg("系统错误", ctx)
+		return
+	}
+
+	// 重新获取角色，并检查账号是否被封禁
+	user, err := hdl.svc.LoginInfo(ctx, claims.UserId)
+	switch err {
+	case nil:
+	case service.ErrUserBanned:
+		res.FailWithMsg("账号已被封禁", ctx)
+		return
+	default:
+		res.FailWithMsg("系统错误", ctx)
+		return
+	}
+
+	// 生成短令牌，沿用原来的会话 ID
+	token, err := hdl.jwt.GenToken(jwts.JwtPayload{
+		UserId:    claims.UserId,
+		UserAgent: claims.UserAgent,
+		Ssid:      claims.Ssid,
+		Role:      uint8(user.Role),
+	})
+	if err != nil {
+		res.FailWithMsg("生成用户令牌错误！", ctx)
+		return
+	}
 	ctx.Header("jwt-token", token)
-	res.OKWithMsg("登陆成功", ctx)
+	res.OKWithMsg("刷新成功", ctx)
+}
+
+/*
+Logout 退出登录API：
+使当前会话失效，该会话的长短令牌都无法继续使用
+*/
+func (hdl *UserHandler) Logout(ctx *gin.Context) {
+
+	// 获取用户 Token
+	_claims, _ := ctx.Get("claims")
+	claims := _claims.(*jwts.CustomClaims)
+
+	// 调用下层服务
+	if err := hdl.sessSvc.Logout(ctx, claims.Ssid, hdl.jwt.RefreshExpire()); err != nil {
+		res.FailWithMsg("系统错误", ctx)
+		return
+	}
+	res.OKWithMsg("退出成功", ctx)
+}
+
+// Sessions 获取当前登录的设备列表
+func (hdl *UserHandler) Sessions(ctx *gin.Context) {
+
+	// 获取用户 Token
+	_claims, _ := ctx.Get("claims")
+	claims := _claims.(*jwts.CustomClaims)
+
+	// 调用下层服务
+	list, err := hdl.sessSvc.List(ctx, claims.UserId, claims.Ssid)
+	if err != nil {
+		res.FailWithMsg("系统错误", ctx)
+		return
+	}
+	res.OKWithData(list, ctx)
+}
+
+// KickSession 下线指定设备，该设备的长短令牌立即失效
+func (hdl *UserHandler) KickSession(ctx *gin.Context) {
+
+	// 绑定参数
+	id, err := strconv.ParseInt(ctx.Param("id"), 10, 64)
+	if err != nil {
+		res.FailWithMsg("参数错误", ctx)
+		return
+	}
+
+	// 获取用户 Token
+	_claims, _ := ctx.Get("claims")
+	claims := _claims.(*jwts.CustomClaims)
+
+	// 调用下层服务
+	err = hdl.sessSvc.Kick(ctx, claims.UserId, id)
+	switch err {
+	case nil:
+		res.OKWithMsg("下线成功", ctx)
+	case service.ErrSessionNotFound:
+		res.FailWithMsg("设备不存在", ctx)
+	default:
+		res.FailWithMsg("系统错误", ctx)
+	}
+}
+
+/*
+ChangePassword 修改密码API：
+校验原密码，设置新密码之后，其他设备上的会话全部失效
+*/
+func (hdl *UserHandler) ChangePassword(ctx *gin.Context) {
+
+	// 绑定参数
+	type Req struct {
+		OldPassword     string `json:"oldPassword" binding:"required"`
+		Password        string `json:"password" binding:"required"`
+		ConfirmPassword string `json:"confirmPassword" binding:"required"`
+	}
+	var req Req
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		res.FailWithMsg("参数错误", ctx)
+		return
+	}
+
+	// 校验密码
+	if !checkNewPassword(ctx, req.Password, req.ConfirmPassword) {
+		return
+	}
+
+	// 获取用户 Token
+	_claims, _ := ctx.Get("claims")
+	claims := _claims.(*jwts.CustomClaims)
+
+	// 调用下层服务
+	err := hdl.svc.ChangePassword(ctx, claims.UserId, req.OldPassword, req.Password)
+	switch err {
+	case nil:
+	case service.ErrInvalidPassword:
+		res.FailWithMsg("原密码错误", ctx)
+		return
+	case service.ErrPasswordNotSet:
+		res.FailWithMsg("未设置密码，请通过找回密码设置", ctx)
+		return
+	default:
+		res.FailWithMsg("系统错误", ctx)
+		return
+	}
+
+	// 其他设备下线
+	if err = hdl.sessSvc.RevokeAll(ctx, claims.UserId, claims.Ssid); err != nil {
+		log.Println("修改密码后下线其他设备失败：err : ", err.Error())
+	}
+	res.OKWithMsg("修改成功", ctx)
+}
+
+/*
+SendResetPasswordCode 找回密码API：
+向手机号码或邮箱发送验证码，账号不存在时同样返回成功，避免泄露账号是否注册
+*/
+func (hdl *UserHandler) SendResetPasswordCode(ctx *gin.Context) {
+
+	// 绑定参数
+	type Req struct {
+		Phone string `json:"phone"`
+		Email string `json:"email"`
+	}
+	var req Req
+	if err := ctx.ShouldBindJSON(&req); err != nil || (req.Phone == "") == (req.Email == "") {
+		res.FailWithMsg("参数错误", ctx)
+		return
+	}
+
+	// 查找用户
+	_, err := hdl.svc.SearchId(ctx, req.Phone, req.Email)
+	switch err {
+	case nil:
+	case service.ErrUserNotFound:
+		res.OKWithMsg("验证码发送成功", ctx)
+		return
+	default:
+		res.FailWithMsg("系统错误", ctx)
+		return
+	}
+
+	// 调用下层服务
+	if req.Phone != "" {
+		err = hdl.codeSvc.Send(ctx, bizResetPassword, service.CodeChannelSMS, req.Phone)
+	} else {
+		err = hdl.codeSvc.Send(ctx, bizResetPassword, service.CodeChannelEmail, req.Email)
+	}
+	switch err {
+	case nil:
+		res.OKWithMsg("验证码发送成功", ctx)
+	case service.ErrSendCodeTooMany:
+		res.FailWithMsg("验证码发送频繁", ctx)
+	default:
+		res.FailWithMsg("系统错误", ctx)
+	}
+}
+
+/*
+ResetPassword 重置密码API：
+校验验证码之后设置新密码，并让所有设备下线
+*/
+func (hdl *UserHandler) ResetPassword(ctx *gin.Context) {
+
+	// 绑定参数
+	type Req struct {
+		Phone           string `json:"phone"`
+		Email           string `json:"email"`
+		Code            string `json:"code" binding:"required"`
+		Password        string `json:"password" binding:"required"`
+		ConfirmPassword string `json:"confirmPassword" binding:"required"`
+	}
+	var req Req
+	if err := ctx.ShouldBindJSON(&req); err != nil || (req.Phone == "") == (req.Email == "") {
+		res.FailWithMsg("参数错误", ctx)
+		return
+	}
+
+	// 校验密码
+	if !checkNewPassword(ctx, req.Password, req.ConfirmPassword) {
+		return
+	}
+
+	// 验证码校验
+	target := req.Phone
+	if target == "" {
+		target = req.Email
+	}
+	err := hdl.codeSvc.Verify(ctx, bizResetPassword, target, req.Code)
+	switch err {
+	case nil:
+	case service.ErrVerifyCodeFailed:
+		res.FailWithMsg("校验失败", ctx)
+		return
+	case service.ErrVerifyCodeTooMany:
+		res.FailWithMsg("校验频繁", ctx)
+		return
+	default:
+		res.FailWithMsg("系统错误", ctx)
+		return
+	}
+
+	// 调用下层服务
+	uid, err := hdl.svc.SearchId(ctx, req.Phone, req.Email)
+	if err != nil {
+		res.FailWithMsg("系统错误", ctx)
+		return
+	}
+	if err = hdl.svc.ResetPassword(ctx, uid, req.Password); err != nil {
+		res.FailWithMsg("系统错误", ctx)
+		return
+	}
+
+	// 所有设备下线
+	if err = hdl.sessSvc.RevokeAll(ctx, uid, ""); err != nil {
+		log.Println("重置密码后下线设备失败：err : ", err.Error())
+	}
+	res.OKWithMsg("重置成功", ctx)
+}
+
+/*
+SendUnlockCode 发送解锁验证码API：
+账号被锁定并且绑定了手机号码时，向该手机号码发送验证码；
+为了避免泄露邮箱是否注册或被锁定，其他情况同样返回发送成功
+*/
+func (hdl *UserHandler) SendUnlockCode(ctx *gin.Context) {
+
+	// 绑定参数
+	type Req struct {
+		Email string `json:"email" binding:"required"`
+	}
+	var req Req
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		res.FailWithMsg("参数错误", ctx)
+		return
+	}
+
+	// 检查账号是否被锁定
+	locked, err := hdl.guardSvc.IsLocked(ctx, req.Email)
+	if err != nil {
+		res.FailWithMsg("系统错误", ctx)
+		return
+	}
+	if !locked {
+		res.OKWithMsg("验证码发送成功", ctx)
+		return
+	}
+
+	// 查找绑定的手机号码
+	phone, err := hdl.lockedPhone(ctx, req.Email)
+	if err != nil {
+		res.FailWithMsg("系统错误", ctx)
+		return
+	}
+	if phone == "" {
+		res.OKWithMsg("验证码发送成功", ctx)
+		return
+	}
+
+	// 调用下层服务
+	err = hdl.codeSvc.Send(ctx, bizUnlockAccount, service.CodeChannelSMS, phone)
+	switch err {
+	case nil:
+		res.OKWithMsg("验证码发送成功", ctx)
+	case service.ErrSendCodeTooMany:
+		res.FailWithMsg("验证码发送频繁", ctx)
+	default:
+		res.FailWithMsg("系统错误", ctx)
+	}
+}
+
+/*
+Unlock 解除账号锁定API：
+校验发送到绑定手机号码的验证码，通过后解除锁定并清空登录失败次数
+*/
+func (hdl *UserHandler) Unlock(ctx *gin.Context) {
+
+	// 绑定参数
+	type Req struct {
+		Email string `json:"email" binding:"required"`
+		Code  string `json:"code" binding:"required"`
+	}
+	var req Req
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		res.FailWithMsg("参数错误", ctx)
+		return
+	}
+
+	// 查找绑定的手机号码
+	phone, err := hdl.lockedPhone(ctx, req.Email)
+	if err != nil {
+		res.FailWithMsg("系统错误", ctx)
+		return
+	}
+	if phone == "" {
+		res.FailWithMsg("校验失败", ctx)
+		return
+	}
+
+	// 验证码校验
+	err = hdl.codeSvc.Verify(ctx, bizUnlockAccount, phone, req.Code)
+	switch err {
+	case nil:
+	case service.ErrVerifyCodeFailed:
+		res.FailWithMsg("校验失败", ctx)
+		return
+	case service.ErrVerifyCodeTooMany:
+		res.FailWithMsg("校验频繁", ctx)
+		return
+	default:
+		res.FailWithMsg("系统错误", ctx)
+		return
+	}
+
+	// 调用下层服务
+	if err = hdl.guardSvc.Unlock(ctx, req.Email); err != nil {
+		res.FailWithMsg("系统错误", ctx)
+		return
+	}
+	res.OKWithMsg("解锁成功", ctx)
+}
+
+// lockedPhone 获取邮箱对应账号绑定的手机号码，账号不存在或者没有绑定时返回空字符串
+func (hdl *UserHandler) lockedPhone(ctx *gin.Context, email string) (string, error) {
+	uid, err := hdl.svc.SearchId(ctx, "", email)
+	if err == service.ErrUserNotFound {
+		return "", nil
+	}
+	if err != nil {
+		return "", err
+	}
+	u, err := hdl.svc.Profile(ctx, uid)
+	return u.Phone, err
+}
+
+// minutes 向上取整的分钟数
+func minutes(d time.Duration) int {
+	return int((d + time.Minute - time.Nanosecond) / time.Minute)
+}
+
+// checkNewPassword 校验新密码，校验失败时直接返回响应
+func checkNewPassword(ctx *gin.Context, password, confirmPassword string) bool {
+	if password != confirmPassword {
+		res.FailWithMsg("两次密码不一致", ctx)
+		return false
+	}
+	if ok, err := isValidPassword(password); err != nil {
+		res.FailWithMsg("系统错误", ctx)
+		return false
+	} else if !ok {
+		res.FailWithMsg("密码必须包含字母、数字、特殊字符，并且不少于八位", ctx)
+		return false
+	}
+	return true
 }
 
 /*
@@ -220,7 +734,7 @@ func (hdl *UserHandler) SendSmsCode(ctx *gin.Context) {
 	}
 
 	// 调用底层服务
-	err := hdl.codeSvc.Send(ctx, biz, req.Phone)
+	err := hdl.codeSvc.Send(ctx, biz, service.CodeChannelSMS, req.Phone)
 	switch err {
 	case nil:
 		res.OKWithMsg("短信发送成功", ctx)
@@ -273,24 +787,29 @@ func (hdl *UserHandler) VerifySmsCode(ctx *gin.Context) {
 	uid, err := hdl.svc.FindOrCreate(ctx, req.Phone)
 	switch err {
 	case nil:
-	case service.ErrDuplicateEmailorPhone:  // 这种情况是，一个未注册用户，通过验证码同时登录两台设备
+	case service.ErrDuplicateEmailorPhone: // 这种情况是，一个未注册用户，通过验证码同时登录两台设备
+	default:
+		res.FailWithMsg("系统错误", ctx)
+		return
+	}
+
+	// 检查账号是否被封禁
+	user, err := hdl.svc.LoginInfo(ctx, uid)
+	switch err {
+	case nil:
+	case service.ErrUserBanned:
+		res.FailWithMsg("账号已被封禁", ctx)
+		return
 	default:
 		res.FailWithMsg("系统错误", ctx)
 		return
 	}
 
 	// 生成 Token
-	token, err := jwts.GenToken(jwts.JwtPayload{
-		UserId:    uid,
-		UserAgent: ctx.GetHeader("User-Agent"),
-	})
-	if err != nil {
+	if err = setLoginToken(ctx, hdl.jwt, hdl.sessSvc, user); err != nil {
 		res.FailWithMsg("生成用户令牌错误！", ctx)
 		return
 	}
-
-	// 返回用户token
-	ctx.Header("jwt-token", token)
 	res.OKWithMsg("登陆成功", ctx)
 }
 
@@ -399,6 +918,7 @@ func (hdl *UserHandler) Profile(ctx *gin.Context) {
 		NickName     string `json:"nickName"`
 		Birthday     string `json:"birthday"`
 		Introduction string `json:"introduction"`
+		Role         string `json:"role"`
 	}
 	resp := ProfileResp{
 		Email:        user.Email,
@@ -406,6 +926,7 @@ func (hdl *UserHandler) Profile(ctx *gin.Context) {
 		NickName:     user.NickName,
 		Birthday:     user.Birthday.Format("2006-01-02"),
 		Introduction: user.Introduction,
+		Role:         user.Role.String(),
 	}
 	res.OKWithData(resp, ctx)
 }
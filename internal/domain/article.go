@@ -1,6 +1,10 @@
 package domain
 
-import "time"
+import (
+	"time"
+
+	"github.com/Linxhhh/webook/pkg/markdown"
+)
 
 type Article struct {
 	Id       int64         `json:"id"`
@@ -13,6 +17,17 @@ type Article struct {
 
 	// 需要通过 AuthorId 查询，只有查询线上库时，才显示 AuthorName
 	AuthorName string `json:"authorName"`
+
+	// 审核不通过的原因，只有作者查询制作库时可见
+	RejectReason string `json:"rejectReason,omitempty"`
+
+	// 定时发表的时间，为零值时表示立即发表
+	PublishAt time.Time `json:"publishAt"`
+
+	// 渲染之后的 HTML、纯文本摘要和目录，发表时由 Content 渲染，只在线上库中使用
+	Html     string             `json:"html,omitempty"`
+	Abstract string             `json:"abstract,omitempty"`
+	Toc      []markdown.Heading `json:"toc,omitempty"`
 }
 
 // 帖子列表
@@ -29,16 +44,16 @@ type ArticleListElem struct {
 type ArticleStatus uint8
 
 const (
-	ArticleStatusUnpublished = iota // 未发表
-	ArticleStatusPublished          // 已发表
-	ArticleStatusPrivate            // 私有
+	ArticleStatusUnpublished   = iota // 未发表
+	ArticleStatusPublished            // 已发表
+	ArticleStatusPrivate              // 私有
+	ArticleStatusPendingReview        // 审核中
+	ArticleStatusRejected             // 审核不通过
+	ArticleStatusScheduled            // 审核通过，等待定时发表
+	ArticleStatusHidden               // 举报数量达到阈值被隐藏，等待管理员处理，只在线上库中使用
 )
 
-// 获取文章内容摘要
+// 获取文章内容摘要，去掉 markdown 标记
 func Abstract(content string) string {
-	str := []rune(content)
-	if len(str) > 128 {
-		str = str[:128]
-	}
-	return string(str)
+	return markdown.Abstract(content, markdown.AbstractLength)
 }
@@ -0,0 +1,27 @@
+package domain
+
+import (
+	"time"
+
+	"github.com/Linxhhh/webook/pkg/diff"
+)
+
+// ArticleRevision 帖子的历史版本，Status 为保存时的状态，版本列表中不包含 Content
+type ArticleRevision struct {
+	Id        int64         `json:"id"`
+	ArticleId int64         `json:"articleId"`
+	AuthorId  int64         `json:"authorId"`
+	Version   int64         `json:"version"`
+	Title     string        `json:"title"`
+	Content   string        `json:"content,omitempty"`
+	Status    ArticleStatus `json:"status"`
+	Ctime     time.Time     `json:"ctime"`
+}
+
+// ArticleRevisionDiff 两个版本之间按行对比的结果
+type ArticleRevisionDiff struct {
+	From      ArticleRevision `json:"from"`
+	To        ArticleRevision `json:"to"`
+	TitleDiff bool            `json:"titleDiff"` // 标题是否有变化
+	Lines     []diff.Line     `json:"lines"`
+}
@@ -0,0 +1,11 @@
+package domain
+
+// AsyncSms 所有服务商都发送失败后，等待异步重试的短信
+type AsyncSms struct {
+	Id       int64
+	TplId    string
+	Args     []string
+	Numbers  []string
+	RetryCnt int
+	RetryMax int
+}
@@ -0,0 +1,24 @@
+package domain
+
+import "time"
+
+/*
+评论，按照 <biz, bizId> 关联到被评论的资源：
+顶级评论的 RootId 和 ParentId 都为 0，回复的 RootId 为所属的顶级评论，ParentId 为直接回复的评论
+*/
+type Comment struct {
+	Id       int64     `json:"id"`
+	Uid      int64     `json:"uid"`
+	Biz      string    `json:"biz"`
+	BizId    int64     `json:"bizId"`
+	RootId   int64     `json:"rootId"`
+	ParentId int64     `json:"parentId"`
+	Content  string    `json:"content"`
+	Ctime    time.Time `json:"ctime"`
+
+	// 需要通过 Uid 查询
+	UserName string `json:"userName"`
+
+	// 直接回复的评论的作者，用于通知
+	ParentUid int64 `json:"parentUid"`
+}
@@ -7,20 +7,48 @@ import (
 )
 
 type FeedEvent struct {
-	Id    int64
-	Uid   int64
-	Type  string
-	Ctime time.Time
-	Ext   ExtendFields
+	Id     int64
+	Uid    int64
+	Type   string
+	Ctime  time.Time
+	Ext    ExtendFields
+	Source FeedSource // 事件来自发件箱还是收件箱，两个表的 ID 相互独立
 }
 
+// FeedSource Feed 事件的来源
+type FeedSource uint8
+
+const (
+	FeedSourcePull FeedSource = iota + 1 // 发件箱（拉模型）
+	FeedSourcePush                       // 收件箱（推模型）
+)
+
 const (
 	ArticleFeedEvent = "article_feed_event"
 	ReadFeedEvent    = "read_feed_event"
 	LikeFeedEvent    = "like_feed_event"
 	CollectFeedEvent = "coll_feed_event"
+	FollowFeedEvent  = "follow_feed_event"
 )
 
+// FeedElem Feed 流的展示元素，在 FeedEvent 的基础上补充帖子、作者和互动数据
+type FeedElem struct {
+	Event       FeedEvent
+	Actor       User // 触发事件的用户，例如点赞、收藏、关注的用户
+	Article     Article
+	Interaction Interaction
+}
+
+/*
+FeedCursor Feed 流的分页游标（时间戳 + 来源 + 事件 ID）：
+发件箱和收件箱的事件 ID 相互独立，时间戳相同时先按照来源、再按照事件 ID 排序
+*/
+type FeedCursor struct {
+	Timestamp int64
+	Source    FeedSource
+	Id        int64
+}
+
 /*
 拓展字段，Feed 应该可以推送帖子、点赞消息、收藏消息、关注消息等。
 */
@@ -8,6 +8,7 @@ type Interaction struct {
 	ReadCnt     int64  `json:"readCnt"`
 	LikeCnt     int64  `json:"likeCnt"`
 	CollectCnt  int64  `json:"collectCnt"`
+	CommentCnt  int64  `json:"commentCnt"`
 
 	// 上面数据是一篇帖子的公共数据
 	// 下面数据是针对具体用户的数据
@@ -0,0 +1,18 @@
+package domain
+
+import "time"
+
+// 锁定对象的类型
+const (
+	LockTargetEmail = "email"
+	LockTargetIp    = "ip"
+)
+
+// LoginLockout 登录失败次数过多导致的锁定
+type LoginLockout struct {
+	TargetType string
+	Target     string
+	Ip         string
+	Failures   int64
+	LockUntil  time.Time
+}
@@ -0,0 +1,29 @@
+package domain
+
+import "time"
+
+// Notification 用户通知
+type Notification struct {
+	Id      int64            `json:"id"`
+	Uid     int64            `json:"uid"` // 接收通知的用户
+	Type    NotificationType `json:"type"`
+	ActorId int64            `json:"actorId"` // 触发通知的用户
+	Biz     string           `json:"biz"`
+	BizId   int64            `json:"bizId"`
+	Content string           `json:"content"` // 例如帖子标题、评论内容
+	IsRead  bool             `json:"isRead"`
+	Ctime   time.Time        `json:"ctime"`
+
+	// 需要通过 ActorId 查询
+	ActorName string `json:"actorName"`
+}
+
+// 通知类型
+type NotificationType string
+
+const (
+	NotificationLike    NotificationType = "like"    // 点赞了你的帖子
+	NotificationCollect NotificationType = "collect" // 收藏了你的帖子
+	NotificationFollow  NotificationType = "follow"  // 关注了你
+	NotificationComment NotificationType = "comment" // 评论了你的帖子，或者回复了你的评论
+)
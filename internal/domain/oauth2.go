@@ -0,0 +1,9 @@
+package domain
+
+// OAuth2Info 第三方平台返回的用户信息
+type OAuth2Info struct {
+	Provider string // 第三方平台，例如 github、wechat
+	OpenId   string // 用户在该平台的唯一标识
+	UnionId  string // 同一开放平台下多个应用共享的标识，可能为空
+	NickName string
+}
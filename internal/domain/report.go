@@ -0,0 +1,74 @@
+package domain
+
+import "time"
+
+// 可以被举报的资源
+const (
+	ReportBizArticle = "article"
+	ReportBizComment = "comment"
+	ReportBizUser    = "user"
+)
+
+// ReportReason 举报原因分类
+type ReportReason uint8
+
+const (
+	ReportReasonSpam    ReportReason = iota // 垃圾广告
+	ReportReasonAbuse                       // 辱骂攻击
+	ReportReasonPorn                        // 色情低俗
+	ReportReasonIllegal                     // 违法违规
+	ReportReasonOther                       // 其他
+)
+
+var reportReasonNames = []string{"spam", "abuse", "porn", "illegal", "other"}
+
+func (r ReportReason) String() string {
+	if int(r) < len(reportReasonNames) {
+		return reportReasonNames[r]
+	}
+	return "unknown"
+}
+
+// MarshalText 序列化为原因名称
+func (r ReportReason) MarshalText() ([]byte, error) {
+	return []byte(r.String()), nil
+}
+
+// ParseReportReason 把原因名称转换为举报原因
+func ParseReportReason(name string) (ReportReason, bool) {
+	for i, n := range reportReasonNames {
+		if n == name {
+			return ReportReason(i), true
+		}
+	}
+	return ReportReasonOther, false
+}
+
+// ReportStatus 举报的处理状态
+type ReportStatus uint8
+
+const (
+	ReportStatusPending   ReportStatus = iota // 待处理
+	ReportStatusAccepted                      // 举报成立
+	ReportStatusDismissed                     // 举报驳回
+)
+
+// Report 举报，按照 <biz, bizId> 关联到被举报的资源，同一个用户对同一个资源只能举报一次
+type Report struct {
+	Id     int64        `json:"id"`
+	Uid    int64        `json:"uid"`
+	Biz    string       `json:"biz"`
+	BizId  int64        `json:"bizId"`
+	Reason ReportReason `json:"reason"`
+	Detail string       `json:"detail"`
+	Status ReportStatus `json:"status"`
+	Ctime  time.Time    `json:"ctime"`
+}
+
+// ReportTarget 按照被举报的资源聚合的待处理举报
+type ReportTarget struct {
+	Biz   string    `json:"biz"`
+	BizId int64     `json:"bizId"`
+	Count int64     `json:"count"`
+	Utime time.Time `json:"utime"` // 最近一次举报的时间
+}
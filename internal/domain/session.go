@@ -0,0 +1,17 @@
+package domain
+
+import "time"
+
+// Session 用户在某个设备上的登录会话
+type Session struct {
+	Id        int64     `json:"id"`
+	Uid       int64     `json:"-"`
+	Ssid      string    `json:"-"`
+	Device    string    `json:"device"`
+	UserAgent string    `json:"userAgent"`
+	Ip        string    `json:"ip"`
+	LastSeen  time.Time `json:"lastSeen"`
+	ExpireAt  time.Time `json:"-"`
+	Ctime     time.Time `json:"ctime"`
+	Current   bool      `json:"current"` // 是否为发起请求的会话
+}
@@ -12,4 +12,42 @@ type User struct {
 	NickName     string
 	Birthday     time.Time
 	Introduction string
+	Role         Role
+	Status       UserStatus
 }
+
+// Role 用户角色，数值越大权限越高
+type Role uint8
+
+const (
+	RoleUser      Role = iota // 普通用户
+	RoleModerator             // 版主
+	RoleAdmin                 // 管理员
+)
+
+var roleNames = []string{"user", "moderator", "admin"}
+
+func (r Role) String() string {
+	if int(r) < len(roleNames) {
+		return roleNames[r]
+	}
+	return "unknown"
+}
+
+// ParseRole 把角色名称转换为角色
+func ParseRole(name string) (Role, bool) {
+	for i, n := range roleNames {
+		if n == name {
+			return Role(i), true
+		}
+	}
+	return RoleUser, false
+}
+
+// UserStatus 账号状态
+type UserStatus uint8
+
+const (
+	UserStatusNormal UserStatus = iota // 正常
+	UserStatusBanned                   // 封禁
+)
@@ -2,7 +2,6 @@ package events
 
 import (
 	"context"
-	"log"
 	"strconv"
 	"time"
 
@@ -13,14 +12,17 @@ import (
 )
 
 type ArticleEventConsumer struct {
-	client sarama.Client
-	svc    *service.FeedEventService
+	client   sarama.Client
+	producer sarama.SyncProducer // 死信队列
+	svc      *service.FeedEventService
+	runner   *samarax.GroupRunner
 }
 
-func NewArticleEventConsumer(client sarama.Client, svc *service.FeedEventService) *ArticleEventConsumer {
+func NewArticleEventConsumer(client sarama.Client, producer sarama.SyncProducer, svc *service.FeedEventService) *ArticleEventConsumer {
 	return &ArticleEventConsumer{
-		svc:    svc,
-		client: client,
+		svc:      svc,
+		client:   client,
+		producer: producer,
 	}
 }
 
@@ -32,13 +34,16 @@ func (r *ArticleEventConsumer) Start() error {
 		return err
 	}
 
-	go func() {
-		err := cg.Consume(context.Background(), []string{TopicArticleEvent}, samarax.NewConsumer[ArticleEvent](r.Consume))
-		if err != nil {
-			log.Println("退出了消费循环异常", err)
-		}
-	}()
-	return err
+	r.runner = samarax.StartGroup(cg, []string{TopicArticleEvent}, samarax.NewConsumer[ArticleEvent](r.Consume).WithDLQ(r.producer, samarax.DefaultRetryConfig))
+	return nil
+}
+
+// Close 停止消费
+func (r *ArticleEventConsumer) Close() error {
+	if r.runner == nil {
+		return nil
+	}
+	return r.runner.Close()
 }
 
 // Consume 消费 ArticleEvent
@@ -48,7 +53,7 @@ func (r *ArticleEventConsumer) Consume(msg *sarama.ConsumerMessage, evt ArticleE
 	defer cancel()
 
 	return r.svc.CreateFeedEvent(ctx, domain.FeedEvent{
-		Type: TopicArticleEvent,
+		Type: domain.ArticleFeedEvent,
 		Ext: map[string]string{
 			"uid":   strconv.FormatInt(evt.Uid, 10),
 			"aid":   strconv.FormatInt(evt.Aid, 10),
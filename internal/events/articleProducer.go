@@ -12,6 +12,12 @@ type ArticleEvent struct {
 	Title string
 }
 
+// ArticleReviewEvent 帖子提交发表之后，等待审核
+type ArticleReviewEvent struct {
+	Uid int64
+	Aid int64
+}
+
 type ArticleEventProducer struct {
 	producer sarama.SyncProducer
 }
@@ -31,3 +37,15 @@ func (s *ArticleEventProducer) ProduceEvent(evt ArticleEvent) error {
 	})
 	return err
 }
+
+func (s *ArticleEventProducer) ProduceReviewEvent(evt ArticleReviewEvent) error {
+	val, err := json.Marshal(evt)
+	if err != nil {
+		return err
+	}
+	_, _, err = s.producer.SendMessage(&sarama.ProducerMessage{
+		Topic: TopicArticleReview,
+		Value: sarama.StringEncoder(val),
+	})
+	return err
+}
@@ -0,0 +1,83 @@
+package events
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/IBM/sarama"
+	"github.com/Linxhhh/webook/internal/domain"
+	"github.com/Linxhhh/webook/internal/service"
+	samarax "github.com/Linxhhh/webook/pkg/saramax"
+)
+
+/*
+ArticleReviewConsumer 帖子审核：
+消费 ArticleReviewEvent，审核通过之后再生成 feed 流事件
+*/
+type ArticleReviewConsumer struct {
+	client      sarama.Client
+	producer    sarama.SyncProducer // 死信队列
+	svc         *service.ArticleService
+	artProducer *ArticleEventProducer
+	runner      *samarax.GroupRunner
+}
+
+func NewArticleReviewConsumer(client sarama.Client, producer sarama.SyncProducer, svc *service.ArticleService,
+	artProducer *ArticleEventProducer) *ArticleReviewConsumer {
+	return &ArticleReviewConsumer{
+		client:      client,
+		producer:    producer,
+		svc:         svc,
+		artProducer: artProducer,
+	}
+}
+
+// Start 启动 goroutine 消费事件
+func (r *ArticleReviewConsumer) Start() error {
+
+	cg, err := sarama.NewConsumerGroupFromClient("articleReview", r.client)
+	if err != nil {
+		return err
+	}
+
+	r.runner = samarax.StartGroup(cg, []string{TopicArticleReview}, samarax.NewConsumer[ArticleReviewEvent](r.Consume).WithDLQ(r.producer, samarax.DefaultRetryConfig))
+	return nil
+}
+
+// Close 停止消费
+func (r *ArticleReviewConsumer) Close() error {
+	if r.runner == nil {
+		return nil
+	}
+	return r.runner.Close()
+}
+
+// Consume 消费 ArticleReviewEvent
+func (r *ArticleReviewConsumer) Consume(msg *sarama.ConsumerMessage, evt ArticleReviewEvent) error {
+
+	// 外部审核服务可能比较慢，超时时间放宽一些
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	art, err := r.svc.Review(ctx, evt.Aid)
+	if errors.Is(err, service.ErrArticleNotPending) {
+		// 已经审核过，或者作者又修改了帖子（会有新的审核事件）：
+		// 审核通过之后推送 feed 流事件失败时，重试会走到这里，帖子已发表则补发事件，等待定时发表的帖子由定时任务推送
+		art, err = r.svc.Reviewed(ctx, evt.Aid)
+		if errors.Is(err, service.ErrArticleNotFound) {
+			return nil
+		}
+	}
+	if err != nil || art.Status != domain.ArticleStatusPublished {
+		// 审核不通过，或者等待定时发表（发表时由定时任务推送）
+		return err
+	}
+
+	// 审核通过 —— feed 流推送
+	return r.artProducer.ProduceEvent(ArticleEvent{
+		Uid:   art.AuthorId,
+		Aid:   art.Id,
+		Title: art.Title,
+	})
+}
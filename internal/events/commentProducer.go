@@ -0,0 +1,37 @@
+package events
+
+import (
+	"encoding/json"
+
+	"github.com/IBM/sarama"
+)
+
+// CommentEvent 评论事件，ParentUid 为被回复的评论的作者，顶级评论为 0
+type CommentEvent struct {
+	Uid       int64
+	Biz       string
+	BizId     int64
+	CommentId int64
+	ParentUid int64
+	Content   string
+}
+
+type CommentEventProducer struct {
+	producer sarama.SyncProducer
+}
+
+func NewCommentEventProducer(producer sarama.SyncProducer) *CommentEventProducer {
+	return &CommentEventProducer{producer: producer}
+}
+
+func (s *CommentEventProducer) ProduceEvent(evt CommentEvent) error {
+	val, err := json.Marshal(evt)
+	if err != nil {
+		return err
+	}
+	_, _, err = s.producer.SendMessage(&sarama.ProducerMessage{
+		Topic: TopicCommentEvent,
+		Value: sarama.StringEncoder(val),
+	})
+	return err
+}
@@ -0,0 +1,68 @@
+package events
+
+import (
+	"context"
+	"strconv"
+	"time"
+
+	"github.com/IBM/sarama"
+	"github.com/Linxhhh/webook/internal/domain"
+	"github.com/Linxhhh/webook/internal/service"
+	samarax "github.com/Linxhhh/webook/pkg/saramax"
+)
+
+// FollowEventConsumer 消费关注事件，通知被关注的用户
+type FollowEventConsumer struct {
+	client   sarama.Client
+	producer sarama.SyncProducer // 死信队列
+	svc      *service.FeedEventService
+	runner   *samarax.GroupRunner
+}
+
+func NewFollowEventConsumer(client sarama.Client, producer sarama.SyncProducer, svc *service.FeedEventService) *FollowEventConsumer {
+	return &FollowEventConsumer{
+		client:   client,
+		producer: producer,
+		svc:      svc,
+	}
+}
+
+// Start 启动 goroutine 消费事件
+func (r *FollowEventConsumer) Start() error {
+
+	cg, err := sarama.NewConsumerGroupFromClient("followFeed", r.client)
+	if err != nil {
+		return err
+	}
+
+	r.runner = samarax.StartGroup(cg, []string{TopicFollowEvent},
+		samarax.NewConsumer[FollowEvent](r.Consume).WithDLQ(r.producer, samarax.DefaultRetryConfig))
+	return nil
+}
+
+// Close 停止消费
+func (r *FollowEventConsumer) Close() error {
+	if r.runner == nil {
+		return nil
+	}
+	return r.runner.Close()
+}
+
+// Consume 消费 FollowEvent，取消关注不通知
+func (r *FollowEventConsumer) Consume(msg *sarama.ConsumerMessage, evt FollowEvent) error {
+
+	if !evt.Follow {
+		return nil
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	return r.svc.CreateFeedEvent(ctx, domain.FeedEvent{
+		Type: domain.FollowFeedEvent,
+		Ext: map[string]string{
+			"uid":      strconv.FormatInt(evt.Follower, 10),
+			"followee": strconv.FormatInt(evt.Followee, 10),
+		},
+	})
+}
@@ -0,0 +1,34 @@
+package events
+
+import (
+	"encoding/json"
+
+	"github.com/IBM/sarama"
+)
+
+// FollowEvent 关注事件，Follow 为 false 表示取消关注
+type FollowEvent struct {
+	Follower int64
+	Followee int64
+	Follow   bool
+}
+
+type FollowEventProducer struct {
+	producer sarama.SyncProducer
+}
+
+func NewFollowEventProducer(producer sarama.SyncProducer) *FollowEventProducer {
+	return &FollowEventProducer{producer: producer}
+}
+
+func (s *FollowEventProducer) ProduceEvent(evt FollowEvent) error {
+	val, err := json.Marshal(evt)
+	if err != nil {
+		return err
+	}
+	_, _, err = s.producer.SendMessage(&sarama.ProducerMessage{
+		Topic: TopicFollowEvent,
+		Value: sarama.StringEncoder(val),
+	})
+	return err
+}
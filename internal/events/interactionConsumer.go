@@ -0,0 +1,76 @@
+package events
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/IBM/sarama"
+	"github.com/Linxhhh/webook/internal/domain"
+	"github.com/Linxhhh/webook/internal/service"
+	samarax "github.com/Linxhhh/webook/pkg/saramax"
+)
+
+// InteractionEventConsumer 消费点赞、收藏事件，通知帖子作者
+type InteractionEventConsumer struct {
+	client   sarama.Client
+	producer sarama.SyncProducer // 死信队列
+	svc      *service.FeedEventService
+	runner   *samarax.GroupRunner
+}
+
+func NewInteractionEventConsumer(client sarama.Client, producer sarama.SyncProducer, svc *service.FeedEventService) *InteractionEventConsumer {
+	return &InteractionEventConsumer{
+		client:   client,
+		producer: producer,
+		svc:      svc,
+	}
+}
+
+// Start 启动 goroutine 消费事件
+func (r *InteractionEventConsumer) Start() error {
+
+	cg, err := sarama.NewConsumerGroupFromClient("interactionFeed", r.client)
+	if err != nil {
+		return err
+	}
+
+	r.runner = samarax.StartGroup(cg, []string{TopicLikeEvent, TopicCollectEvent},
+		samarax.NewConsumer[InteractionEvent](r.Consume).WithDLQ(r.producer, samarax.DefaultRetryConfig))
+	return nil
+}
+
+// Close 停止消费
+func (r *InteractionEventConsumer) Close() error {
+	if r.runner == nil {
+		return nil
+	}
+	return r.runner.Close()
+}
+
+// Consume 消费 InteractionEvent，根据 topic 区分点赞和收藏
+func (r *InteractionEventConsumer) Consume(msg *sarama.ConsumerMessage, evt InteractionEvent) error {
+
+	var typ string
+	switch msg.Topic {
+	case TopicLikeEvent:
+		typ = domain.LikeFeedEvent
+	case TopicCollectEvent:
+		typ = domain.CollectFeedEvent
+	default:
+		return fmt.Errorf("未知的 topic %s", msg.Topic)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	return r.svc.CreateFeedEvent(ctx, domain.FeedEvent{
+		Type: typ,
+		Ext: map[string]string{
+			"uid": strconv.FormatInt(evt.Uid, 10),
+			"biz": evt.Biz,
+			"aid": strconv.FormatInt(evt.BizId, 10),
+		},
+	})
+}
@@ -0,0 +1,42 @@
+package events
+
+import (
+	"encoding/json"
+
+	"github.com/IBM/sarama"
+)
+
+// InteractionEvent 点赞、收藏事件，Uid 为点赞或收藏的用户
+type InteractionEvent struct {
+	Uid   int64
+	Biz   string
+	BizId int64
+}
+
+type InteractionEventProducer struct {
+	producer sarama.SyncProducer
+}
+
+func NewInteractionEventProducer(producer sarama.SyncProducer) *InteractionEventProducer {
+	return &InteractionEventProducer{producer: producer}
+}
+
+func (s *InteractionEventProducer) ProduceLikeEvent(evt InteractionEvent) error {
+	return s.produce(TopicLikeEvent, evt)
+}
+
+func (s *InteractionEventProducer) ProduceCollectEvent(evt InteractionEvent) error {
+	return s.produce(TopicCollectEvent, evt)
+}
+
+func (s *InteractionEventProducer) produce(topic string, evt InteractionEvent) error {
+	val, err := json.Marshal(evt)
+	if err != nil {
+		return err
+	}
+	_, _, err = s.producer.SendMessage(&sarama.ProducerMessage{
+		Topic: topic,
+		Value: sarama.StringEncoder(val),
+	})
+	return err
+}
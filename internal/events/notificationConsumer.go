@@ -0,0 +1,110 @@
+package events
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/IBM/sarama"
+	"github.com/Linxhhh/webook/internal/domain"
+	"github.com/Linxhhh/webook/internal/service"
+	samarax "github.com/Linxhhh/webook/pkg/saramax"
+)
+
+// NotificationEventConsumer 消费点赞、收藏、关注、评论事件，生成用户通知
+type NotificationEventConsumer struct {
+	client   sarama.Client
+	producer sarama.SyncProducer // 死信队列
+	svc      *service.NotificationService
+	runner   *samarax.GroupRunner
+}
+
+func NewNotificationEventConsumer(client sarama.Client, producer sarama.SyncProducer, svc *service.NotificationService) *NotificationEventConsumer {
+	return &NotificationEventConsumer{
+		client:   client,
+		producer: producer,
+		svc:      svc,
+	}
+}
+
+// Start 启动 goroutine 消费事件
+func (r *NotificationEventConsumer) Start() error {
+
+	cg, err := sarama.NewConsumerGroupFromClient("notification", r.client)
+	if err != nil {
+		return err
+	}
+
+	// 不同 topic 的消息体不同，先保留原始 JSON，再按照 topic 反序列化
+	r.runner = samarax.StartGroup(cg, []string{TopicLikeEvent, TopicCollectEvent, TopicFollowEvent, TopicCommentEvent},
+		samarax.NewConsumer[json.RawMessage](r.Consume).WithDLQ(r.producer, samarax.DefaultRetryConfig))
+	return nil
+}
+
+// Close 停止消费
+func (r *NotificationEventConsumer) Close() error {
+	if r.runner == nil {
+		return nil
+	}
+	return r.runner.Close()
+}
+
+func (r *NotificationEventConsumer) Consume(msg *sarama.ConsumerMessage, raw json.RawMessage) error {
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	switch msg.Topic {
+	case TopicLikeEvent, TopicCollectEvent:
+		var evt InteractionEvent
+		if err := json.Unmarshal(raw, &evt); err != nil {
+			return err
+		}
+		typ := domain.NotificationLike
+		if msg.Topic == TopicCollectEvent {
+			typ = domain.NotificationCollect
+		}
+		return r.svc.NotifyArticleAuthor(ctx, typ, evt.Uid, evt.BizId, "")
+
+	case TopicFollowEvent:
+		var evt FollowEvent
+		if err := json.Unmarshal(raw, &evt); err != nil {
+			return err
+		}
+		if !evt.Follow {
+			return nil
+		}
+		return r.svc.Notify(ctx, domain.Notification{
+			Uid:     evt.Followee,
+			Type:    domain.NotificationFollow,
+			ActorId: evt.Follower,
+			Biz:     "user",
+			BizId:   evt.Followee,
+		})
+
+	case TopicCommentEvent:
+		var evt CommentEvent
+		if err := json.Unmarshal(raw, &evt); err != nil {
+			return err
+		}
+		// 回复通知被回复的评论作者，顶级评论通知帖子作者
+		if evt.ParentUid > 0 {
+			return r.svc.Notify(ctx, domain.Notification{
+				Uid:     evt.ParentUid,
+				Type:    domain.NotificationComment,
+				ActorId: evt.Uid,
+				Biz:     evt.Biz,
+				BizId:   evt.BizId,
+				Content: evt.Content,
+			})
+		}
+		if evt.Biz != "article" {
+			return nil
+		}
+		return r.svc.NotifyArticleAuthor(ctx, domain.NotificationComment, evt.Uid, evt.BizId, evt.Content)
+
+	default:
+		return fmt.Errorf("未知的 topic %s", msg.Topic)
+	}
+}
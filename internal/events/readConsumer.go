@@ -2,7 +2,6 @@ package events
 
 import (
 	"context"
-	"log"
 	"time"
 
 	"github.com/IBM/sarama"
@@ -10,40 +9,53 @@ import (
 	samarax "github.com/Linxhhh/webook/pkg/saramax"
 )
 
+// Consumer 事件消费者，Start 启动消费，Close 停止消费并等待正在处理的消息完成
 type Consumer interface {
 	Start() error
+	Close() error
 }
 
 type ReadEventConsumer struct {
-	repo   repository.InteractionRepository
-	client sarama.Client
+	repo     repository.InteractionRepository
+	client   sarama.Client
+	producer sarama.SyncProducer // 死信队列
+	runner   *samarax.GroupRunner
+
+	// 批量消费：攒够 batchSize 条消息，或者等待 interval 之后，写入一次
+	batchSize int
+	interval  time.Duration
 }
 
-func NewReadEventConsumer(repo repository.InteractionRepository, client sarama.Client) *ReadEventConsumer {
+func NewReadEventConsumer(repo repository.InteractionRepository, client sarama.Client, producer sarama.SyncProducer,
+	batchSize int, interval time.Duration) *ReadEventConsumer {
 	return &ReadEventConsumer{
-		repo: repo, 
-		client: client,
+		repo:      repo,
+		client:    client,
+		producer:  producer,
+		batchSize: batchSize,
+		interval:  interval,
 	}
 }
 
-/*
-
 func (i *ReadEventConsumer) Start() error {
-	cg, err := sarama.NewConsumerGroupFromClient("interaction  ", i.client)
+	cg, err := sarama.NewConsumerGroupFromClient("interaction", i.client)
 	if err != nil {
 		return err
 	}
-	go func() {
-		er := cg.Consume(context.Background(), []string{TopicReadEvent}, samarax.NewBatchHandler[ReadEvent](i.BatchConsume))
-		if er != nil {
-			log.Print("退出消费", er)
-		}
-	}()
-	return err
+	i.runner = samarax.StartGroup(cg, []string{TopicReadEvent}, samarax.NewBatchConsumer[ReadEvent](i.BatchConsume, i.batchSize, i.interval).
+		WithDLQ(i.producer, samarax.DefaultRetryConfig))
+	return nil
+}
+
+// Close 停止消费
+func (i *ReadEventConsumer) Close() error {
+	if i.runner == nil {
+		return nil
+	}
+	return i.runner.Close()
 }
 
-func (i *ReadEventConsumer) BatchConsume(msgs []*sarama.ConsumerMessage,
-	events []ReadEvent) error {
+func (i *ReadEventConsumer) BatchConsume(msgs []*sarama.ConsumerMessage, events []ReadEvent) error {
 	bizs := make([]string, 0, len(events))
 	bizIds := make([]int64, 0, len(events))
 	for _, evt := range events {
@@ -54,28 +66,3 @@ func (i *ReadEventConsumer) BatchConsume(msgs []*sarama.ConsumerMessage,
 	defer cancel()
 	return i.repo.BatchIncrReadCnt(ctx, bizs, bizIds)
 }
-
-*/
-
-// ------------------------------------------------ 以下是 ‘单消费’ 的版本 -----------------------------------------------------------
-
-func (i *ReadEventConsumer) StartV1() error {
-	cg, err := sarama.NewConsumerGroupFromClient("interactive", i.client)
-	if err != nil {
-		return err
-	}
-	go func() {
-		er := cg.Consume(context.Background(), []string{TopicReadEvent}, samarax.NewConsumer[ReadEvent](i.Consume))
-		if er != nil {
-			log.Print("退出消费", er)
-		}
-	}()
-	return err
-}
-
-func (i *ReadEventConsumer) Consume(msg *sarama.ConsumerMessage,
-	event ReadEvent) error {
-	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
-	defer cancel()
-	return i.repo.IncrReadCnt(ctx, "article", event.Aid)
-}
\ No newline at end of file
@@ -1,8 +1,11 @@
 package events
 
 const (
-	TopicArticleEvent = "article_feed"
-	TopicReadEvent    = "article_read"
-	TopicLikeEvent    = "article_like"
-	TopicCollectEvent = "article_coll"
+	TopicArticleEvent  = "article_feed"
+	TopicArticleReview = "article_review"
+	TopicReadEvent     = "article_read"
+	TopicLikeEvent     = "article_like"
+	TopicCollectEvent  = "article_coll"
+	TopicFollowEvent   = "user_follow"
+	TopicCommentEvent  = "comment"
 )
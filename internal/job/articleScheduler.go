@@ -0,0 +1,145 @@
+package job
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"time"
+
+	"github.com/Linxhhh/webook/internal/domain"
+	"github.com/Linxhhh/webook/internal/events"
+	"github.com/Linxhhh/webook/internal/repository"
+	"github.com/Linxhhh/webook/internal/service"
+)
+
+const articleSchedulerLock = "job:article_scheduler"
+
+/*
+ArticleScheduler 定时发表：
+每隔 interval 尝试获取 MySQL 分布式锁，只有持有锁的实例发表到期的帖子并推送 feed 流事件；
+锁的过期时间是 interval 的 3 倍，持有锁的实例每发表一批帖子前续约，宕机之后由其它实例接管
+*/
+type ArticleScheduler struct {
+	svc      *service.ArticleService
+	lockRepo repository.LockRepository
+	producer *events.ArticleEventProducer
+	owner    string
+	interval time.Duration
+	batch    int
+
+	ctx    context.Context
+	cancel context.CancelFunc
+	done   chan struct{}
+}
+
+func NewArticleScheduler(svc *service.ArticleService, lockRepo repository.LockRepository, producer *events.ArticleEventProducer) *ArticleScheduler {
+	ctx, cancel := context.WithCancel(context.Background())
+	return &ArticleScheduler{
+		svc:      svc,
+		lockRepo: lockRepo,
+		producer: producer,
+		owner:    lockOwner(),
+		interval: 10 * time.Second,
+		batch:    100,
+		ctx:      ctx,
+		cancel:   cancel,
+		done:     make(chan struct{}),
+	}
+}
+
+// lockOwner 使用主机名、进程号和随机数区分不同的实例
+func lockOwner() string {
+	host, _ := os.Hostname()
+	b := make([]byte, 4)
+	_, _ = rand.Read(b)
+	return fmt.Sprintf("%s-%d-%s", host, os.Getpid(), hex.EncodeToString(b))
+}
+
+// Start 启动定时发表任务
+func (s *ArticleScheduler) Start() error {
+	go s.loop()
+	return nil
+}
+
+// Close 停止定时发表任务，并释放分布式锁，让其它实例尽快接管
+func (s *ArticleScheduler) Close() error {
+	s.cancel()
+	<-s.done
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	return s.lockRepo.Unlock(ctx, articleSchedulerLock, s.owner)
+}
+
+func (s *ArticleScheduler) loop() {
+	defer close(s.done)
+	ticker := time.NewTicker(s.interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-s.ctx.Done():
+			return
+		case <-ticker.C:
+			s.publish()
+		}
+	}
+}
+
+/*
+publish 持有锁时，按照发表时间分批发表所有到期的帖子，直到没有到期的帖子或者任务被关闭：
+每批开始前续约，续约失败时停止，避免锁过期之后和其它实例重复发表；
+单个帖子发表失败时记录日志并跳过，不阻塞后面的帖子，下一次执行时重试
+*/
+func (s *ArticleScheduler) publish() {
+	var after domain.Article
+	for s.ctx.Err() == nil {
+		ok, err := s.lockRepo.TryLock(s.ctx, articleSchedulerLock, s.owner, 3*s.interval)
+		if err != nil {
+			log.Printf("获取定时发表锁失败，err : %s", err)
+			return
+		}
+		if !ok {
+			return
+		}
+
+		ctx, cancel := context.WithTimeout(s.ctx, s.interval)
+		arts, err := s.svc.DueScheduled(ctx, after, s.batch)
+		if err != nil {
+			cancel()
+			log.Printf("获取定时发表帖子失败，err : %s", err)
+			return
+		}
+		for _, art := range arts {
+			s.publishOne(ctx, art)
+		}
+		cancel()
+
+		if len(arts) < s.batch {
+			return
+		}
+		after = arts[len(arts)-1]
+	}
+}
+
+// publishOne 发表一个到期的帖子并推送 feed 流事件，作者已经取消或修改的帖子直接跳过
+func (s *ArticleScheduler) publishOne(ctx context.Context, art domain.Article) {
+	err := s.svc.PublishScheduled(ctx, art)
+	if errors.Is(err, service.ErrArticleNotScheduled) {
+		return
+	}
+	if err != nil {
+		log.Printf("定时发表失败，aid : %d，err : %s", art.Id, err)
+		return
+	}
+	if err = s.producer.ProduceEvent(events.ArticleEvent{
+		Uid:   art.AuthorId,
+		Aid:   art.Id,
+		Title: art.Title,
+	}); err != nil {
+		log.Printf("定时发表 ArticleEvent 生成错误，aid : %d，err : %s", art.Id, err)
+	}
+}
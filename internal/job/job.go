@@ -0,0 +1,7 @@
+package job
+
+// Job 后台任务，随应用启动和关闭
+type Job interface {
+	Start() error
+	Close() error
+}
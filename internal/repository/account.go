@@ -0,0 +1,62 @@
+package repository
+
+import (
+	"context"
+	"log"
+
+	"github.com/Linxhhh/webook/internal/repository/cache"
+	"github.com/Linxhhh/webook/internal/repository/dao"
+)
+
+var ErrMergeSelf = dao.ErrMergeSelf
+
+type AccountRepository interface {
+	Merge(ctx context.Context, dst, src int64) error
+}
+
+type CacheAccountRepository struct {
+	dao        dao.AccountDAO
+	userCache  cache.UserCache
+	artCache   cache.ArticleCache
+	follCache  cache.FollowCache
+	interCache cache.InteractionCache
+}
+
+func NewAccountRepository(dao dao.AccountDAO, userCache cache.UserCache, artCache cache.ArticleCache,
+	follCache cache.FollowCache, interCache cache.InteractionCache) AccountRepository {
+	return &CacheAccountRepository{
+		dao:        dao,
+		userCache:  userCache,
+		artCache:   artCache,
+		follCache:  follCache,
+		interCache: interCache,
+	}
+}
+
+// Merge 合并成功后清理相关缓存，清理失败只记录日志，等待缓存过期
+func (repo *CacheAccountRepository) Merge(ctx context.Context, dst, src int64) error {
+	res, err := repo.dao.Merge(ctx, dst, src)
+	if err == dao.ErrRecordNotFound {
+		return ErrUserNotFound
+	}
+	if err != nil {
+		return err
+	}
+
+	var errs []error
+	for _, uid := range []int64{dst, src} {
+		errs = append(errs, repo.userCache.Del(ctx, uid), repo.artCache.DelFirstPage(ctx, uid))
+	}
+	for _, uid := range res.FollowUids {
+		errs = append(errs, repo.follCache.Del(ctx, uid))
+	}
+	for i := range res.Bizs {
+		errs = append(errs, repo.interCache.Del(ctx, res.Bizs[i], res.BizIds[i]))
+	}
+	for _, e := range errs {
+		if e != nil {
+			log.Println("账号合并后清理缓存失败：err : ", e.Error())
+		}
+	}
+	return nil
+}
@@ -2,23 +2,34 @@ package repository
 
 import (
 	"context"
+	"encoding/json"
 	"time"
 
 	"github.com/Linxhhh/webook/internal/domain"
 	"github.com/Linxhhh/webook/internal/repository/cache"
 	"github.com/Linxhhh/webook/internal/repository/dao"
+	"github.com/Linxhhh/webook/pkg/markdown"
 )
 
 var (
 	ErrIncorrectArticleorAuthor = dao.ErrIncorrectArticleorAuthor
 	ErrArticleNotFound = dao.ErrRecordNotFound
+	ErrArticleNotPending = dao.ErrArticleNotPending
+	ErrArticleNotScheduled = dao.ErrArticleNotScheduled
 )
 
 type ArticleRepository interface {
 	Insert(ctx context.Context, article domain.Article) (int64, error)
 	Update(ctx context.Context, article domain.Article) error
-	Sync(ctx context.Context, article domain.Article) (int64, error)
 	SyncStatus(ctx context.Context, uid int64, aid int64, status domain.ArticleStatus) error
+	GetPending(ctx context.Context, aid int64) (domain.Article, error)
+	Approve(ctx context.Context, article domain.Article) error
+	Reject(ctx context.Context, article domain.Article, reason string) error
+	UpdatePubStatus(ctx context.Context, aid int64, from, to domain.ArticleStatus) (bool, error)
+	GetDueScheduled(ctx context.Context, now time.Time, after domain.Article, limit int) ([]domain.Article, error)
+	PublishScheduled(ctx context.Context, article domain.Article) error
+	CancelSchedule(ctx context.Context, uid int64, aid int64) error
+	Reschedule(ctx context.Context, uid int64, aid int64, publishAt time.Time) error
 	CountByAuthor(ctx context.Context, uid int64) (int64, error)
 	GetListByAuthor(ctx context.Context, uid int64, offset, limit int) ([]domain.ArticleListElem, error)
 	GetById(ctx context.Context, aid int64) (domain.Article, error)
@@ -42,10 +53,11 @@ func NewArticleRepository(dao dao.ArticleDAO, cache cache.ArticleCache) ArticleR
 func (repo *CacheArticleRepository) Insert(ctx context.Context, article domain.Article) (int64, error) {
 
 	aid, err := repo.dao.Insert(ctx, dao.Article{
-		Title:    article.Title,
-		Content:  article.Content,
-		AuthorId: article.AuthorId,
-		Status:   uint8(article.Status),
+		Title:     article.Title,
+		Content:   article.Content,
+		AuthorId:  article.AuthorId,
+		Status:    uint8(article.Status),
+		PublishAt: toMilli(article.PublishAt),
 	})
 	if err == nil {
 		// 清除首页缓存
@@ -60,44 +72,152 @@ func (repo *CacheArticleRepository) Update(ctx context.Context, article domain.A
 	repo.cache.DelFirstPage(ctx, article.AuthorId)
 
 	err := repo.dao.Update(ctx, dao.Article{
+		Id:        article.Id,
+		Title:     article.Title,
+		Content:   article.Content,
+		AuthorId:  article.AuthorId,
+		Status:    uint8(article.Status),
+		PublishAt: toMilli(article.PublishAt),
+	})
+	if err == nil {
+		// 清除首页缓存和帖子缓存
+		repo.cache.DelFirstPage(ctx, article.AuthorId)
+		repo.cache.Del(ctx, article.Id)
+	}
+	return err
+}
+
+func (repo *CacheArticleRepository) SyncStatus(ctx context.Context, uid int64, aid int64, status domain.ArticleStatus) error {
+
+	err := repo.dao.SyncStatus(ctx, uid, aid, uint8(status))
+	if err == nil {
+		// 清除首页缓存和线上库帖子缓存
+		repo.cache.DelFirstPage(ctx, uid)
+		repo.cache.DelPub(ctx, aid)
+	}
+	return err
+}
+
+func (repo *CacheArticleRepository) GetPending(ctx context.Context, aid int64) (domain.Article, error) {
+	art, err := repo.dao.GetPending(ctx, aid)
+	if err != nil {
+		return domain.Article{}, err
+	}
+	return domain.Article{
+		Id:       art.Id,
+		Title:    art.Title,
+		Content:  art.Content,
+		AuthorId: art.AuthorId,
+		Ctime:    time.UnixMilli(art.Ctime),
+		Utime:    time.UnixMilli(art.Utime),
+		Status:   domain.ArticleStatus(art.Status),
+
+		PublishAt: fromMilli(art.PublishAt),
+	}, nil
+}
+
+func (repo *CacheArticleRepository) Approve(ctx context.Context, article domain.Article) error {
+
+	err := repo.dao.Approve(ctx, dao.Article{
 		Id:       article.Id,
 		Title:    article.Title,
 		Content:  article.Content,
 		AuthorId: article.AuthorId,
+		Status:   uint8(article.Status),
+		Utime:    article.Utime.UnixMilli(),
+		Html:     article.Html,
+		Abstract: article.Abstract,
+		Toc:      encodeToc(article.Toc),
 	})
 	if err == nil {
-		// 清除首页缓存
+		// 清除首页缓存、制作库和线上库帖子缓存
 		repo.cache.DelFirstPage(ctx, article.AuthorId)
+		repo.cache.Del(ctx, article.Id)
+		repo.cache.DelPub(ctx, article.Id)
 	}
 	return err
 }
 
-func (repo *CacheArticleRepository) Sync(ctx context.Context, article domain.Article) (int64, error) {
+func (repo *CacheArticleRepository) Reject(ctx context.Context, article domain.Article, reason string) error {
+
+	err := repo.dao.Reject(ctx, article.Id, article.Utime.UnixMilli(), reason)
+	if err == nil {
+		// 清除首页缓存和帖子缓存
+		repo.cache.DelFirstPage(ctx, article.AuthorId)
+		repo.cache.Del(ctx, article.Id)
+	}
+	return err
+}
+
+func (repo *CacheArticleRepository) UpdatePubStatus(ctx context.Context, aid int64, from, to domain.ArticleStatus) (bool, error) {
+
+	ok, err := repo.dao.UpdatePubStatus(ctx, aid, uint8(from), uint8(to))
+	if ok {
+		// 清除线上库帖子缓存
+		repo.cache.DelPub(ctx, aid)
+	}
+	return ok, err
+}
+
+func (repo *CacheArticleRepository) GetDueScheduled(ctx context.Context, now time.Time, after domain.Article, limit int) ([]domain.Article, error) {
+	arts, err := repo.dao.GetDueScheduled(ctx, now.UnixMilli(), toMilli(after.PublishAt), after.Id, limit)
+	if err != nil {
+		return nil, err
+	}
+	res := make([]domain.Article, 0, len(arts))
+	for _, art := range arts {
+		res = append(res, domain.Article{
+			Id:        art.Id,
+			Title:     art.Title,
+			Content:   art.Content,
+			AuthorId:  art.AuthorId,
+			Ctime:     time.UnixMilli(art.Ctime),
+			Utime:     time.UnixMilli(art.Utime),
+			Status:    domain.ArticleStatus(art.Status),
+			PublishAt: fromMilli(art.PublishAt),
+		})
+	}
+	return res, nil
+}
+
+func (repo *CacheArticleRepository) PublishScheduled(ctx context.Context, article domain.Article) error {
 
-	aid, err := repo.dao.Sync(ctx, dao.Article{
+	err := repo.dao.PublishScheduled(ctx, dao.Article{
 		Id:       article.Id,
 		Title:    article.Title,
 		Content:  article.Content,
 		AuthorId: article.AuthorId,
-		Status:   uint8(article.Status),
+		Utime:    article.Utime.UnixMilli(),
+		Html:     article.Html,
+		Abstract: article.Abstract,
+		Toc:      encodeToc(article.Toc),
 	})
 	if err == nil {
-		go func() {
-			// 清除首页缓存
-			repo.cache.DelFirstPage(ctx, article.AuthorId)
-			// 设置帖子缓存
-			repo.cache.SetPub(ctx, article)
-		}()
+		// 清除首页缓存、制作库和线上库帖子缓存
+		repo.cache.DelFirstPage(ctx, article.AuthorId)
+		repo.cache.Del(ctx, article.Id)
+		repo.cache.DelPub(ctx, article.Id)
 	}
-	return aid, err
+	return err
 }
 
-func (repo *CacheArticleRepository) SyncStatus(ctx context.Context, uid int64, aid int64, status domain.ArticleStatus) error {
+func (repo *CacheArticleRepository) CancelSchedule(ctx context.Context, uid int64, aid int64) error {
 
-	err := repo.dao.SyncStatus(ctx, uid, aid, uint8(status))
+	err := repo.dao.CancelSchedule(ctx, uid, aid)
 	if err == nil {
-		// 清除首页缓存
+		// 清除首页缓存和帖子缓存
 		repo.cache.DelFirstPage(ctx, uid)
+		repo.cache.Del(ctx, aid)
+	}
+	return err
+}
+
+func (repo *CacheArticleRepository) Reschedule(ctx context.Context, uid int64, aid int64, publishAt time.Time) error {
+
+	err := repo.dao.Reschedule(ctx, uid, aid, publishAt.UnixMilli())
+	if err == nil {
+		// 清除帖子缓存
+		repo.cache.Del(ctx, aid)
 	}
 	return err
 }
@@ -145,16 +265,7 @@ func (repo *CacheArticleRepository) GetListByAuthor(ctx context.Context, uid int
 			// 预加载第一个帖子
 			const size = 1024 * 1024
 			if len(arts[0].Content) < size {
-				article := domain.Article{
-					Id:       arts[0].Id,
-					Title:    arts[0].Title,
-					Content:  arts[0].Content,
-					AuthorId: arts[0].AuthorId,
-					Ctime:    time.UnixMilli(arts[0].Ctime),
-					Utime:    time.UnixMilli(arts[0].Utime),
-					Status:   domain.ArticleStatus(arts[0].Status),
-				}
-				repo.cache.Set(ctx, article)
+				repo.cache.Set(ctx, convertToArticleDomain(arts[0]))
 			}
 		}()
 	}
@@ -175,15 +286,7 @@ func (repo *CacheArticleRepository) GetById(ctx context.Context, aid int64) (dom
 	if err != nil {
 		return domain.Article{}, err
 	}
-	article = domain.Article{
-		Id:       art.Id,
-		Title:    art.Title,
-		Content:  art.Content,
-		AuthorId: art.AuthorId,
-		Ctime:    time.UnixMilli(art.Ctime),
-		Utime:    time.UnixMilli(art.Utime),
-		Status:   domain.ArticleStatus(art.Status),
-	}
+	article = convertToArticleDomain(art)
 
 	// 回写缓存
 	go func() {
@@ -214,6 +317,9 @@ func (repo *CacheArticleRepository) GetPubById(ctx context.Context, aid int64) (
 		Ctime:    time.UnixMilli(art.Ctime),
 		Utime:    time.UnixMilli(art.Utime),
 		Status:   domain.ArticleStatus(art.Status),
+		Html:     art.Html,
+		Abstract: art.Abstract,
+		Toc:      decodeToc(art.Toc),
 	}
 
 	// 回写缓存
@@ -239,6 +345,7 @@ func (repo *CacheArticleRepository) GetPubList(ctx context.Context, startTime ti
 			Ctime:    time.UnixMilli(elem.Ctime),
 			Utime:    time.UnixMilli(elem.Utime),
 			Status:   domain.ArticleStatus(elem.Status),
+			Abstract: elem.Abstract,
 		})
 	}
 	return artList, err
@@ -259,7 +366,59 @@ func (repo *CacheArticleRepository) SearchByTitle(ctx context.Context, title str
 			Ctime:    time.UnixMilli(elem.Ctime),
 			Utime:    time.UnixMilli(elem.Utime),
 			Status:   domain.ArticleStatus(elem.Status),
+			Abstract: elem.Abstract,
 		})
 	}
 	return artList, err
 }
+
+// toMilli 零值时间转换为 0
+// convertToArticleDomain 转换制作库帖子，GetById 和首页预加载共用，保证两处缓存的内容一致
+func convertToArticleDomain(art dao.Article) domain.Article {
+	return domain.Article{
+		Id:       art.Id,
+		Title:    art.Title,
+		Content:  art.Content,
+		AuthorId: art.AuthorId,
+		Ctime:    time.UnixMilli(art.Ctime),
+		Utime:    time.UnixMilli(art.Utime),
+		Status:   domain.ArticleStatus(art.Status),
+
+		RejectReason: art.RejectReason,
+		PublishAt:    fromMilli(art.PublishAt),
+	}
+}
+
+func toMilli(t time.Time) int64 {
+	if t.IsZero() {
+		return 0
+	}
+	return t.UnixMilli()
+}
+
+// fromMilli 0 转换为零值时间
+func fromMilli(ms int64) time.Time {
+	if ms == 0 {
+		return time.Time{}
+	}
+	return time.UnixMilli(ms)
+}
+
+// encodeToc 目录序列化为 JSON 存储
+func encodeToc(toc []markdown.Heading) string {
+	if len(toc) == 0 {
+		return ""
+	}
+	val, _ := json.Marshal(toc)
+	return string(val)
+}
+
+// decodeToc 反序列化目录，格式错误时忽略
+func decodeToc(val string) []markdown.Heading {
+	if val == "" {
+		return nil
+	}
+	var toc []markdown.Heading
+	_ = json.Unmarshal([]byte(val), &toc)
+	return toc
+}
@@ -0,0 +1,59 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/Linxhhh/webook/internal/domain"
+	"github.com/Linxhhh/webook/internal/repository/dao"
+)
+
+var ErrRevisionNotFound = dao.ErrRecordNotFound
+
+type ArticleRevisionRepository interface {
+	GetList(ctx context.Context, aid int64, offset, limit int) ([]domain.ArticleRevision, error)
+	GetById(ctx context.Context, id int64) (domain.ArticleRevision, error)
+}
+
+type GormArticleRevisionRepository struct {
+	dao dao.ArticleRevisionDAO
+}
+
+func NewArticleRevisionRepository(dao dao.ArticleRevisionDAO) ArticleRevisionRepository {
+	return &GormArticleRevisionRepository{
+		dao: dao,
+	}
+}
+
+func (repo *GormArticleRevisionRepository) GetList(ctx context.Context, aid int64, offset, limit int) ([]domain.ArticleRevision, error) {
+	revs, err := repo.dao.GetList(ctx, aid, offset, limit)
+	if err != nil {
+		return nil, err
+	}
+	res := make([]domain.ArticleRevision, 0, len(revs))
+	for _, r := range revs {
+		res = append(res, convertToRevisionDomain(r))
+	}
+	return res, nil
+}
+
+func (repo *GormArticleRevisionRepository) GetById(ctx context.Context, id int64) (domain.ArticleRevision, error) {
+	r, err := repo.dao.GetById(ctx, id)
+	if err != nil {
+		return domain.ArticleRevision{}, err
+	}
+	return convertToRevisionDomain(r), nil
+}
+
+func convertToRevisionDomain(r dao.ArticleRevision) domain.ArticleRevision {
+	return domain.ArticleRevision{
+		Id:        r.Id,
+		ArticleId: r.ArticleId,
+		AuthorId:  r.AuthorId,
+		Version:   r.Version,
+		Title:     r.Title,
+		Content:   r.Content,
+		Status:    domain.ArticleStatus(r.Status),
+		Ctime:     time.UnixMilli(r.Ctime),
+	}
+}
@@ -0,0 +1,82 @@
+package repository
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"github.com/Linxhhh/webook/internal/domain"
+	"github.com/Linxhhh/webook/internal/repository/dao"
+)
+
+var ErrNoAsyncSms = dao.ErrRecordNotFound
+
+type AsyncSmsRepository interface {
+	Add(ctx context.Context, s domain.AsyncSms) error
+	Preempt(ctx context.Context, interval time.Duration) (domain.AsyncSms, error)
+	MarkSuccess(ctx context.Context, id int64) error
+	MarkFailed(ctx context.Context, s domain.AsyncSms) error
+}
+
+type asyncSmsConfig struct {
+	TplId   string   `json:"tplId"`
+	Args    []string `json:"args"`
+	Numbers []string `json:"numbers"`
+}
+
+type GormAsyncSmsRepository struct {
+	dao dao.AsyncSmsDAO
+}
+
+func NewAsyncSmsRepository(dao dao.AsyncSmsDAO) AsyncSmsRepository {
+	return &GormAsyncSmsRepository{
+		dao: dao,
+	}
+}
+
+func (repo *GormAsyncSmsRepository) Add(ctx context.Context, s domain.AsyncSms) error {
+	cfg, err := json.Marshal(asyncSmsConfig{
+		TplId:   s.TplId,
+		Args:    s.Args,
+		Numbers: s.Numbers,
+	})
+	if err != nil {
+		return err
+	}
+	return repo.dao.Insert(ctx, dao.AsyncSms{
+		Config:   string(cfg),
+		RetryMax: s.RetryMax,
+	})
+}
+
+// Preempt 抢占一条距离上次发送超过 interval 的短信
+func (repo *GormAsyncSmsRepository) Preempt(ctx context.Context, interval time.Duration) (domain.AsyncSms, error) {
+	s, err := repo.dao.Preempt(ctx, time.Now().Add(-interval).UnixMilli())
+	if err != nil {
+		return domain.AsyncSms{}, err
+	}
+	var cfg asyncSmsConfig
+	if err = json.Unmarshal([]byte(s.Config), &cfg); err != nil {
+		return domain.AsyncSms{}, err
+	}
+	return domain.AsyncSms{
+		Id:       s.Id,
+		TplId:    cfg.TplId,
+		Args:     cfg.Args,
+		Numbers:  cfg.Numbers,
+		RetryCnt: s.RetryCnt,
+		RetryMax: s.RetryMax,
+	}, nil
+}
+
+func (repo *GormAsyncSmsRepository) MarkSuccess(ctx context.Context, id int64) error {
+	return repo.dao.MarkSuccess(ctx, id)
+}
+
+func (repo *GormAsyncSmsRepository) MarkFailed(ctx context.Context, s domain.AsyncSms) error {
+	return repo.dao.MarkFailed(ctx, dao.AsyncSms{
+		Id:       s.Id,
+		RetryCnt: s.RetryCnt,
+		RetryMax: s.RetryMax,
+	})
+}
@@ -16,8 +16,10 @@ type ArticleCache interface {
 	DelFirstPage(ctx context.Context, uid int64) error
 	Get(ctx context.Context, id int64) (domain.Article, error)
 	Set(ctx context.Context, art domain.Article) error
+	Del(ctx context.Context, id int64) error
 	GetPub(ctx context.Context, id int64) (domain.Article, error)
 	SetPub(ctx context.Context, art domain.Article) error
+	DelPub(ctx context.Context, id int64) error
 }
 
 type RedisArticleCache struct {
@@ -80,6 +82,7 @@ func (ac *RedisArticleCache) DelFirstPage(ctx context.Context, uid int64) error
 - key
 - Get
 - Set
+- Del
 */
 
 func (ac *RedisArticleCache) key(id int64) string {
@@ -112,11 +115,18 @@ func (ac *RedisArticleCache) Set(ctx context.Context, art domain.Article) error
 	return ac.cmd.Set(key, val, 10 * time.Minute).Err()
 }
 
+func (ac *RedisArticleCache) Del(ctx context.Context, id int64) error {
+
+	// 删除 kv
+	return ac.cmd.Del(ac.key(id)).Err()
+}
+
 /*
 缓存线上库的帖子详情
 - pubKey
 - GetPub
 - SetPub
+- DelPub
 */
 
 func (ac *RedisArticleCache) pubKey(id int64) string {
@@ -147,4 +157,10 @@ func (ac *RedisArticleCache) SetPub(ctx context.Context, art domain.Article) err
 		return err
 	}
 	return ac.cmd.Set(key, val, 10 * time.Minute).Err()
-}
\ No newline at end of file
+}
+
+func (ac *RedisArticleCache) DelPub(ctx context.Context, id int64) error {
+
+	// 删除 kv
+	return ac.cmd.Del(ac.pubKey(id)).Err()
+}
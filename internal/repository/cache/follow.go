@@ -19,6 +19,7 @@ const (
 type FollowCache interface {
 	Get(ctx context.Context, uid int64) (domain.FollowData, error)
 	Set(ctx context.Context, data domain.FollowData) error
+	Del(ctx context.Context, uid int64) error
 }
 
 type RedisFollowCache struct {
@@ -66,3 +67,7 @@ func (c *RedisFollowCache) Set(ctx context.Context, data domain.FollowData) erro
 	}
 	return c.cmd.Expire(key, c.expiresAt).Err()
 }
+
+func (c *RedisFollowCache) Del(ctx context.Context, uid int64) error {
+	return c.cmd.Del(c.key(uid)).Err()
+}
@@ -17,13 +17,17 @@ var luaIncrCnt string
 const fieldReadCnt = "read_cnt"
 const fieldLikeCnt = "like_cnt"
 const fieldCollectCnt = "collect_cnt"
+const fieldCommentCnt = "comment_cnt"
 
 type InteractionCache interface {
 	IncrReadCnt(ctx context.Context, biz string, bizId int64) error
+	BatchIncrReadCnt(ctx context.Context, bizs []string, bizIds []int64) error
 	IncrLikeCnt(ctx context.Context, biz string, bizId int64) error
 	DecrLikeCnt(ctx context.Context, biz string, bizId int64) error
+	IncrCommentCnt(ctx context.Context, biz string, bizId int64, delta int64) error
 	Get(ctx context.Context, biz string, bizId int64) (domain.Interaction, error)
 	Set(ctx context.Context, biz string, bizId int64, interaction domain.Interaction) error
+	Del(ctx context.Context, biz string, bizId int64) error
 }
 
 type RedisInteractionCache struct {
@@ -47,6 +51,22 @@ func (i *RedisInteractionCache) IncrReadCnt(ctx context.Context, biz string, biz
 	return i.cmd.Eval(luaIncrCnt, []string{key}, fieldReadCnt, 1).Err()
 }
 
+// BatchIncrReadCnt 按照 key 聚合阅读量，再通过一次 pipeline 更新
+func (i *RedisInteractionCache) BatchIncrReadCnt(ctx context.Context, bizs []string, bizIds []int64) error {
+	deltas := make(map[string]int64, len(bizs))
+	for j := 0; j < len(bizs); j++ {
+		deltas[i.key(bizs[j], bizIds[j])]++
+	}
+
+	pipe := i.cmd.Pipeline()
+	defer pipe.Close()
+	for key, delta := range deltas {
+		pipe.Eval(luaIncrCnt, []string{key}, fieldReadCnt, delta)
+	}
+	_, err := pipe.Exec()
+	return err
+}
+
 func (i *RedisInteractionCache) IncrLikeCnt(ctx context.Context, biz string, bizId int64) error {
 	key := i.key(biz, bizId)
 	return i.cmd.Eval(luaIncrCnt, []string{key}, fieldLikeCnt, 1).Err()
@@ -57,6 +77,11 @@ func (i *RedisInteractionCache) DecrLikeCnt(ctx context.Context, biz string, biz
 	return i.cmd.Eval(luaIncrCnt, []string{key}, fieldLikeCnt, -1).Err()
 }
 
+func (i *RedisInteractionCache) IncrCommentCnt(ctx context.Context, biz string, bizId int64, delta int64) error {
+	key := i.key(biz, bizId)
+	return i.cmd.Eval(luaIncrCnt, []string{key}, fieldCommentCnt, delta).Err()
+}
+
 func (i *RedisInteractionCache) Get(ctx context.Context, biz string, bizId int64) (domain.Interaction, error) {
 	key := i.key(biz, bizId)
 	res, err := i.cmd.HGetAll(key).Result()
@@ -71,6 +96,7 @@ func (i *RedisInteractionCache) Get(ctx context.Context, biz string, bizId int64
 	ia.ReadCnt, _ = strconv.ParseInt(res[fieldReadCnt], 10, 64)
 	ia.LikeCnt, _ = strconv.ParseInt(res[fieldLikeCnt], 10, 64)
 	ia.CollectCnt, _ = strconv.ParseInt(res[fieldCollectCnt], 10, 64)
+	ia.CommentCnt, _ = strconv.ParseInt(res[fieldCommentCnt], 10, 64)
 	return ia, nil
 }
 
@@ -87,5 +113,12 @@ func (i *RedisInteractionCache) Set(ctx context.Context, biz string, bizId int64
 	if err := i.cmd.HSet(key, fieldCollectCnt, ia.CollectCnt).Err(); err != nil {
 		return err
 	}
+	if err := i.cmd.HSet(key, fieldCommentCnt, ia.CommentCnt).Err(); err != nil {
+		return err
+	}
 	return i.cmd.Expire(key, i.expiresAt).Err()
 }
+
+func (i *RedisInteractionCache) Del(ctx context.Context, biz string, bizId int64) error {
+	return i.cmd.Del(i.key(biz, bizId)).Err()
+}
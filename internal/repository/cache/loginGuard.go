@@ -0,0 +1,121 @@
+package cache
+
+import (
+	"context"
+	_ "embed"
+	"fmt"
+	"time"
+
+	"github.com/go-redis/redis"
+)
+
+//go:embed lua/incrFailure.lua
+var LuaIncrFailure string
+
+/*
+LoginGuardCache 登录防护：
+按照邮箱和 IP 统计窗口内的登录失败次数，记录下一次允许登录的时间，以及邮箱和 IP 的锁定状态
+*/
+type LoginGuardCache interface {
+	IncrFailure(ctx context.Context, email, ip string, window time.Duration) (int64, int64, error)
+	ResetFailure(ctx context.Context, email string) error
+	SetDelay(ctx context.Context, email string, delay time.Duration) error
+	GetDelay(ctx context.Context, email string) (time.Duration, error)
+	LockEmail(ctx context.Context, email string, expiration time.Duration) error
+	LockIp(ctx context.Context, ip string, expiration time.Duration) error
+	GetLock(ctx context.Context, email, ip string) (time.Duration, time.Duration, error)
+	UnlockEmail(ctx context.Context, email string) error
+}
+
+type RedisLoginGuardCache struct {
+	cmd redis.Cmdable
+}
+
+func NewLoginGuardCache(cmd redis.Cmdable) LoginGuardCache {
+	return &RedisLoginGuardCache{
+		cmd: cmd,
+	}
+}
+
+func (c *RedisLoginGuardCache) failKey(typ, target string) string {
+	return fmt.Sprintf("login:fail:%s:%s", typ, target)
+}
+
+func (c *RedisLoginGuardCache) delayKey(email string) string {
+	return fmt.Sprintf("login:delay:email:%s", email)
+}
+
+func (c *RedisLoginGuardCache) lockKey(typ, target string) string {
+	return fmt.Sprintf("login:lock:%s:%s", typ, target)
+}
+
+// IncrFailure 累加邮箱和 IP 的失败次数，返回累加之后的值
+func (c *RedisLoginGuardCache) IncrFailure(ctx context.Context, email, ip string, window time.Duration) (int64, int64, error) {
+	keys := []string{c.failKey("email", email), c.failKey("ip", ip)}
+	res, err := c.cmd.Eval(LuaIncrFailure, keys, window.Milliseconds()).Result()
+	if err != nil {
+		return 0, 0, err
+	}
+	cnts, ok := res.([]interface{})
+	if !ok || len(cnts) != 2 {
+		return 0, 0, fmt.Errorf("登录失败计数格式错误：%v", res)
+	}
+	emailCnt, _ := cnts[0].(int64)
+	ipCnt, _ := cnts[1].(int64)
+	return emailCnt, ipCnt, nil
+}
+
+// ResetFailure 清空邮箱的失败次数和等待时间，IP 的失败次数不受影响
+func (c *RedisLoginGuardCache) ResetFailure(ctx context.Context, email string) error {
+	return c.cmd.Del(c.failKey("email", email), c.delayKey(email)).Err()
+}
+
+func (c *RedisLoginGuardCache) SetDelay(ctx context.Context, email string, delay time.Duration) error {
+	return c.cmd.Set(c.delayKey(email), "", delay).Err()
+}
+
+// GetDelay 距离下一次允许登录的时间，0 表示可以立即登录
+func (c *RedisLoginGuardCache) GetDelay(ctx context.Context, email string) (time.Duration, error) {
+	return c.ttl(c.delayKey(email))
+}
+
+func (c *RedisLoginGuardCache) LockEmail(ctx context.Context, email string, expiration time.Duration) error {
+	return c.lock(c.lockKey("email", email), c.failKey("email", email), expiration)
+}
+
+func (c *RedisLoginGuardCache) LockIp(ctx context.Context, ip string, expiration time.Duration) error {
+	return c.lock(c.lockKey("ip", ip), c.failKey("ip", ip), expiration)
+}
+
+// lock 设置锁定，同时清空失败次数，锁定结束后重新计数
+func (c *RedisLoginGuardCache) lock(lockKey, failKey string, expiration time.Duration) error {
+	pipe := c.cmd.TxPipeline()
+	pipe.Set(lockKey, "", expiration)
+	pipe.Del(failKey)
+	_, err := pipe.Exec()
+	return err
+}
+
+// GetLock 返回邮箱和 IP 剩余的锁定时间，0 表示没有被锁定
+func (c *RedisLoginGuardCache) GetLock(ctx context.Context, email, ip string) (time.Duration, time.Duration, error) {
+	emailTTL, err := c.ttl(c.lockKey("email", email))
+	if err != nil {
+		return 0, 0, err
+	}
+	ipTTL, err := c.ttl(c.lockKey("ip", ip))
+	return emailTTL, ipTTL, err
+}
+
+// UnlockEmail 解除邮箱的锁定，并清空失败次数和等待时间
+func (c *RedisLoginGuardCache) UnlockEmail(ctx context.Context, email string) error {
+	return c.cmd.Del(c.lockKey("email", email), c.failKey("email", email), c.delayKey(email)).Err()
+}
+
+// ttl 键不存在或者没有过期时间时返回 0
+func (c *RedisLoginGuardCache) ttl(key string) (time.Duration, error) {
+	ttl, err := c.cmd.PTTL(key).Result()
+	if err != nil || ttl < 0 {
+		return 0, err
+	}
+	return ttl, nil
+}
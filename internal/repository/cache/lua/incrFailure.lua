@@ -0,0 +1,14 @@
+local window = tonumber(ARGV[1])
+local res = {}
+
+for i, key in ipairs(KEYS) do
+    local cnt = redis.call("incr", key)
+    if cnt == 1 then
+        -- 第一次失败，开始计时
+        redis.call("pexpire", key, window)
+    end
+    res[i] = cnt
+end
+
+-- 返回每个计数器累加之后的值
+return res
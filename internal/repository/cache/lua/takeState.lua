@@ -0,0 +1,13 @@
+local key = KEYS[1]
+
+local uid = redis.call("get", key)
+if not uid then
+    return -1
+end
+
+-- state 只能使用一次
+redis.call("del", key)
+return tonumber(uid)
+
+-- 返回 -1，表示 state 无效或已过期
+-- 返回其他值，表示发起授权的用户 ID（登录时为 0）
@@ -0,0 +1,52 @@
+package cache
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/go-redis/redis"
+)
+
+const fieldUnreadCnt = "unread_cnt"
+
+type NotificationCache interface {
+	IncrUnreadCnt(ctx context.Context, uid int64, delta int64) error
+	GetUnreadCnt(ctx context.Context, uid int64) (int64, error)
+	SetUnreadCnt(ctx context.Context, uid int64, cnt int64) error
+}
+
+type RedisNotificationCache struct {
+	cmd       redis.Cmdable
+	expiresAt time.Duration
+}
+
+func NewNotificationCache(cmd redis.Cmdable) NotificationCache {
+	return &RedisNotificationCache{
+		cmd:       cmd,
+		expiresAt: 30 * time.Minute,
+	}
+}
+
+func (c *RedisNotificationCache) key(uid int64) string {
+	return fmt.Sprintf("notification:%d", uid)
+}
+
+// IncrUnreadCnt 只有 key 存在时才更新未读数量，不存在时等待下次查询回写
+func (c *RedisNotificationCache) IncrUnreadCnt(ctx context.Context, uid int64, delta int64) error {
+	key := c.key(uid)
+	return c.cmd.Eval(luaIncrCnt, []string{key}, fieldUnreadCnt, delta).Err()
+}
+
+func (c *RedisNotificationCache) GetUnreadCnt(ctx context.Context, uid int64) (int64, error) {
+	key := c.key(uid)
+	return c.cmd.HGet(key, fieldUnreadCnt).Int64()
+}
+
+func (c *RedisNotificationCache) SetUnreadCnt(ctx context.Context, uid int64, cnt int64) error {
+	key := c.key(uid)
+	if err := c.cmd.HSet(key, fieldUnreadCnt, cnt).Err(); err != nil {
+		return err
+	}
+	return c.cmd.Expire(key, c.expiresAt).Err()
+}
@@ -0,0 +1,57 @@
+package cache
+
+import (
+	"context"
+	_ "embed"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/go-redis/redis"
+)
+
+//go:embed lua/takeState.lua
+var LuaTakeState string
+
+var ErrInvalidOAuth2State = errors.New("state 无效或已过期")
+
+/*
+OAuth2StateCache 保存发起授权时生成的 state，防止 CSRF：
+state 对应发起授权的用户 ID，登录时为 0，绑定时为当前登录的用户
+*/
+type OAuth2StateCache interface {
+	SetState(ctx context.Context, provider, state string, uid int64) error
+	VerifyState(ctx context.Context, provider, state string) (int64, error)
+}
+
+type RedisOAuth2StateCache struct {
+	cmd       redis.Cmdable
+	expiresAt time.Duration
+}
+
+func NewOAuth2StateCache(cmd redis.Cmdable) OAuth2StateCache {
+	return &RedisOAuth2StateCache{
+		cmd:       cmd,
+		expiresAt: 10 * time.Minute,
+	}
+}
+
+func (c *RedisOAuth2StateCache) key(provider, state string) string {
+	return fmt.Sprintf("oauth2:state:%s:%s", provider, state)
+}
+
+func (c *RedisOAuth2StateCache) SetState(ctx context.Context, provider, state string, uid int64) error {
+	return c.cmd.Set(c.key(provider, state), uid, c.expiresAt).Err()
+}
+
+// VerifyState state 只能使用一次，校验的同时删除，返回发起授权的用户 ID
+func (c *RedisOAuth2StateCache) VerifyState(ctx context.Context, provider, state string) (int64, error) {
+	uid, err := c.cmd.Eval(LuaTakeState, []string{c.key(provider, state)}).Int64()
+	if err != nil {
+		return 0, err
+	}
+	if uid < 0 {
+		return 0, ErrInvalidOAuth2State
+	}
+	return uid, nil
+}
@@ -0,0 +1,49 @@
+package cache
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/go-redis/redis"
+)
+
+// SessionCache 记录已经退出登录的会话 ID，以及会话最近一次回写活跃时间的标记
+type SessionCache interface {
+	Revoke(ctx context.Context, ssid string, expiration time.Duration) error
+	IsRevoked(ctx context.Context, ssid string) (bool, error)
+	MarkSeen(ctx context.Context, ssid string, interval time.Duration) (bool, error)
+}
+
+type RedisSessionCache struct {
+	cmd redis.Cmdable
+}
+
+func NewSessionCache(cmd redis.Cmdable) SessionCache {
+	return &RedisSessionCache{
+		cmd: cmd,
+	}
+}
+
+func (c *RedisSessionCache) key(ssid string) string {
+	return fmt.Sprintf("user:ssid:%s", ssid)
+}
+
+// Revoke 过期时间不小于长令牌的有效期，过期后令牌本身已经失效
+func (c *RedisSessionCache) Revoke(ctx context.Context, ssid string, expiration time.Duration) error {
+	return c.cmd.Set(c.key(ssid), "", expiration).Err()
+}
+
+func (c *RedisSessionCache) IsRevoked(ctx context.Context, ssid string) (bool, error) {
+	cnt, err := c.cmd.Exists(c.key(ssid)).Result()
+	return cnt > 0, err
+}
+
+func (c *RedisSessionCache) seenKey(ssid string) string {
+	return fmt.Sprintf("user:ssid:seen:%s", ssid)
+}
+
+// MarkSeen 在 interval 内只有第一次调用返回 true，用于限制活跃时间的回写频率
+func (c *RedisSessionCache) MarkSeen(ctx context.Context, ssid string, interval time.Duration) (bool, error) {
+	return c.cmd.SetNX(c.seenKey(ssid), "", interval).Result()
+}
@@ -0,0 +1,114 @@
+package repository
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/Linxhhh/webook/internal/domain"
+	"github.com/Linxhhh/webook/internal/repository/cache"
+	"github.com/Linxhhh/webook/internal/repository/dao"
+)
+
+var (
+	ErrIncorrectCommentorUser = dao.ErrIncorrectCommentorUser
+	ErrCommentNotFound        = dao.ErrRecordNotFound
+)
+
+type CommentRepository interface {
+	Create(ctx context.Context, c domain.Comment) (int64, error)
+	Delete(ctx context.Context, uid int64, id int64) error
+	GetById(ctx context.Context, id int64) (domain.Comment, error)
+	GetTopList(ctx context.Context, biz string, bizId int64, lastId int64, limit int) ([]domain.Comment, error)
+	GetReplies(ctx context.Context, rootId int64, lastId int64, limit int) ([]domain.Comment, error)
+}
+
+type CacheCommentRepository struct {
+	dao        dao.CommentDAO
+	interCache cache.InteractionCache
+}
+
+func NewCommentRepository(dao dao.CommentDAO, interCache cache.InteractionCache) CommentRepository {
+	return &CacheCommentRepository{
+		dao:        dao,
+		interCache: interCache,
+	}
+}
+
+func (repo *CacheCommentRepository) Create(ctx context.Context, c domain.Comment) (int64, error) {
+	id, err := repo.dao.Insert(ctx, dao.Comment{
+		Uid:      c.Uid,
+		Biz:      c.Biz,
+		BizId:    c.BizId,
+		RootId:   c.RootId,
+		ParentId: c.ParentId,
+		Content:  c.Content,
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	// 评论数量 +1，评论已经写入数据库，缓存失败只记录日志
+	if err = repo.interCache.IncrCommentCnt(ctx, c.Biz, c.BizId, 1); err != nil {
+		log.Println("增加评论数量缓存失败：err : ", err.Error())
+	}
+	return id, nil
+}
+
+func (repo *CacheCommentRepository) Delete(ctx context.Context, uid int64, id int64) error {
+	c, cnt, err := repo.dao.Delete(ctx, uid, id)
+	if err != nil {
+		return err
+	}
+
+	// 评论数量 -n，评论已经从数据库删除，缓存失败只记录日志
+	if err = repo.interCache.IncrCommentCnt(ctx, c.Biz, c.BizId, -cnt); err != nil {
+		log.Println("减少评论数量缓存失败：err : ", err.Error())
+	}
+	return nil
+}
+
+func (repo *CacheCommentRepository) GetById(ctx context.Context, id int64) (domain.Comment, error) {
+	c, err := repo.dao.GetById(ctx, id)
+	if err != nil {
+		return domain.Comment{}, err
+	}
+	return convertToCommentDomain(c), nil
+}
+
+func (repo *CacheCommentRepository) GetTopList(ctx context.Context, biz string, bizId int64, lastId int64, limit int) ([]domain.Comment, error) {
+	list, err := repo.dao.GetTopList(ctx, biz, bizId, lastId, limit)
+	if err != nil {
+		return nil, err
+	}
+	res := make([]domain.Comment, 0, len(list))
+	for _, c := range list {
+		res = append(res, convertToCommentDomain(c))
+	}
+	return res, nil
+}
+
+func (repo *CacheCommentRepository) GetReplies(ctx context.Context, rootId int64, lastId int64, limit int) ([]domain.Comment, error) {
+	list, err := repo.dao.GetReplies(ctx, rootId, lastId, limit)
+	if err != nil {
+		return nil, err
+	}
+	res := make([]domain.Comment, 0, len(list))
+	for _, c := range list {
+		res = append(res, convertToCommentDomain(c))
+	}
+	return res, nil
+}
+
+func convertToCommentDomain(c dao.Comment) domain.Comment {
+	return domain.Comment{
+		Id:       c.Id,
+		Uid:      c.Uid,
+		Biz:      c.Biz,
+		BizId:    c.BizId,
+		RootId:   c.RootId,
+		ParentId: c.ParentId,
+		Content:  c.Content,
+		Ctime:    time.UnixMilli(c.Ctime),
+	}
+}
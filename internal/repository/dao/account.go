@@ -0,0 +1,336 @@
+package dao
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var ErrMergeSelf = errors.New("不能合并同一个账号")
+
+// AccountDAO 账号合并，涉及用户、帖子、关注、互动等多张表
+type AccountDAO interface {
+	Merge(ctx context.Context, dst, src int64) (MergeResult, error)
+}
+
+// MergeResult 合并过程中计数发生变化的数据，用于清理缓存
+type MergeResult struct {
+	Bizs       []string
+	BizIds     []int64
+	FollowUids []int64
+}
+
+type GormAccountDAO struct {
+	master *gorm.DB
+}
+
+func NewAccountDAO(m *gorm.DB) AccountDAO {
+	return &GormAccountDAO{
+		master: m,
+	}
+}
+
+/*
+Merge 把账号 src 合并到账号 dst：
+在同一个事务中迁移帖子、评论、通知、Feed、第三方账号、举报、点赞、收藏和关注，
+重复的举报、点赞、收藏、关注只保留一份，并修正计数，最后删除 src
+*/
+func (dao *GormAccountDAO) Merge(ctx context.Context, dst, src int64) (MergeResult, error) {
+	var res MergeResult
+	if dst == src {
+		return res, ErrMergeSelf
+	}
+
+	err := dao.master.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		now := time.Now().UnixMilli()
+
+		// 锁定两个用户
+		var users []User
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("id IN ?", []int64{dst, src}).Find(&users).Error
+		if err != nil {
+			return err
+		}
+		if len(users) != 2 {
+			return ErrRecordNotFound
+		}
+
+		// 直接迁移的数据
+		moves := []struct {
+			model  any
+			column string
+		}{
+			{&Article{}, "author_id"},
+			{&PublishedArticle{}, "author_id"},
+			{&ArticleRevision{}, "author_id"},
+			{&Comment{}, "uid"},
+			{&Notification{}, "uid"},
+			{&Notification{}, "actor_id"},
+			{&FeedPullEvent{}, "uid"},
+			{&FeedPushEvent{}, "uid"},
+			{&UserOAuthBinding{}, "uid"},
+		}
+		for _, m := range moves {
+			err = tx.Model(m.model).Where(m.column+" = ?", src).Update(m.column, dst).Error
+			if err != nil {
+				return err
+			}
+		}
+
+		// 举报
+		if err = dao.mergeReports(tx, dst, src); err != nil {
+			return err
+		}
+
+		// 点赞和收藏
+		if err = dao.mergeLikes(tx, dst, src, now, &res); err != nil {
+			return err
+		}
+		if err = dao.mergeCollections(tx, dst, src, now, &res); err != nil {
+			return err
+		}
+
+		// 关注关系
+		if err = dao.mergeFollows(tx, dst, src, now, &res); err != nil {
+			return err
+		}
+
+		// 邮箱、手机号码：dst 没有时使用 src 的，需要先清空 src 以免唯一索引冲突
+		var target, source User
+		for _, u := range users {
+			if u.Id == dst {
+				target = u
+			} else {
+				source = u
+			}
+		}
+		updates := map[string]any{"u_time": now}
+		if !target.Email.Valid && source.Email.Valid {
+			updates["email"] = source.Email
+			if target.Password == "" {
+				updates["password"] = source.Password
+			}
+		}
+		if !target.Phone.Valid && source.Phone.Valid {
+			updates["phone"] = source.Phone
+		}
+		err = tx.Model(&User{}).Where("id = ?", src).Updates(map[string]any{
+			"email": sql.NullString{},
+			"phone": sql.NullString{},
+		}).Error
+		if err != nil {
+			return err
+		}
+		if err = tx.Model(&User{}).Where("id = ?", dst).Updates(updates).Error; err != nil {
+			return err
+		}
+		return tx.Delete(&User{}, src).Error
+	})
+	return res, err
+}
+
+// mergeLikes 迁移点赞记录，两个账号都点赞过的，点赞量 -1
+// mergeReports 迁移举报，dst 已经举报过同一个资源时删除 src 的举报，同一个用户只计一次
+func (dao *GormAccountDAO) mergeReports(tx *gorm.DB, dst, src int64) error {
+	var reports []Report
+	if err := tx.Where("uid = ?", src).Find(&reports).Error; err != nil {
+		return err
+	}
+	for _, r := range reports {
+		var cnt int64
+		err := tx.Model(&Report{}).Where("uid = ? AND biz = ? AND biz_id = ?", dst, r.Biz, r.BizId).Count(&cnt).Error
+		if err != nil {
+			return err
+		}
+		if cnt > 0 {
+			err = tx.Delete(&Report{}, r.Id).Error
+		} else {
+			err = tx.Model(&Report{}).Where("id = ?", r.Id).Update("uid", dst).Error
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (dao *GormAccountDAO) mergeLikes(tx *gorm.DB, dst, src int64, now int64, res *MergeResult) error {
+	var likes []UserLike
+	if err := tx.Where("uid = ?", src).Find(&likes).Error; err != nil {
+		return err
+	}
+	for _, l := range likes {
+		var exist UserLike
+		err := tx.Where("uid = ? AND biz = ? AND biz_id = ?", dst, l.Biz, l.BizId).First(&exist).Error
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			if err = tx.Model(&UserLike{}).Where("id = ?", l.Id).Update("uid", dst).Error; err != nil {
+				return err
+			}
+			continue
+		}
+		if err != nil {
+			return err
+		}
+
+		if err = tx.Delete(&UserLike{}, l.Id).Error; err != nil {
+			return err
+		}
+		if l.Status != 1 {
+			continue
+		}
+		if exist.Status == 1 {
+			// 重复点赞，只保留一份
+			err = tx.Model(&Interaction{}).Where("biz = ? AND biz_id = ?", l.Biz, l.BizId).
+				Updates(map[string]any{
+					"like_cnt": gorm.Expr("`like_cnt` - 1"),
+					"utime":    now,
+				}).Error
+			res.Bizs = append(res.Bizs, l.Biz)
+			res.BizIds = append(res.BizIds, l.BizId)
+		} else {
+			// dst 已经取消点赞，使用 src 的点赞，点赞量不变
+			err = tx.Model(&UserLike{}).Where("id = ?", exist.Id).
+				Updates(map[string]any{"status": 1, "utime": now}).Error
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// mergeCollections 迁移收藏记录，两个账号都收藏过的，收藏量 -1
+func (dao *GormAccountDAO) mergeCollections(tx *gorm.DB, dst, src int64, now int64, res *MergeResult) error {
+	var cols []UserCollection
+	if err := tx.Where("uid = ?", src).Find(&cols).Error; err != nil {
+		return err
+	}
+	for _, c := range cols {
+		var exist UserCollection
+		err := tx.Where("uid = ? AND biz = ? AND biz_id = ?", dst, c.Biz, c.BizId).First(&exist).Error
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			if err = tx.Model(&UserCollection{}).Where("id = ?", c.Id).Update("uid", dst).Error; err != nil {
+				return err
+			}
+			continue
+		}
+		if err != nil {
+			return err
+		}
+
+		if err = tx.Delete(&UserCollection{}, c.Id).Error; err != nil {
+			return err
+		}
+		if c.Status != 1 {
+			continue
+		}
+		if exist.Status == 1 {
+			// 重复收藏，只保留一份
+			err = tx.Model(&Interaction{}).Where("biz = ? AND biz_id = ?", c.Biz, c.BizId).
+				Updates(map[string]any{
+					"collect_cnt": gorm.Expr("`collect_cnt` - 1"),
+					"utime":       now,
+				}).Error
+			res.Bizs = append(res.Bizs, c.Biz)
+			res.BizIds = append(res.BizIds, c.BizId)
+		} else {
+			// dst 已经取消收藏，使用 src 的收藏，收藏量不变
+			err = tx.Model(&UserCollection{}).Where("id = ?", exist.Id).
+				Updates(map[string]any{"status": 1, "utime": now}).Error
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+/*
+mergeFollows 迁移关注关系：
+重复的关系和合并后变成自己关注自己的关系直接删除，最后重新统计受影响用户的关注数据
+*/
+func (dao *GormAccountDAO) mergeFollows(tx *gorm.DB, dst, src int64, now int64, res *MergeResult) error {
+	var relations []FollowRelation
+	err := tx.Where("follower = ? OR followee = ?", src, src).Find(&relations).Error
+	if err != nil {
+		return err
+	}
+
+	affected := map[int64]struct{}{dst: {}}
+	for _, r := range relations {
+		follower, followee := r.Follower, r.Followee
+		if follower == src {
+			follower = dst
+		}
+		if followee == src {
+			followee = dst
+		}
+		affected[r.Follower] = struct{}{}
+		affected[r.Followee] = struct{}{}
+
+		var exist FollowRelation
+		err = tx.Where("follower = ? AND followee = ?", follower, followee).First(&exist).Error
+		switch {
+		case follower == followee:
+			// 自己关注自己
+			err = tx.Delete(&FollowRelation{}, r.Id).Error
+		case errors.Is(err, gorm.ErrRecordNotFound):
+			err = tx.Model(&FollowRelation{}).Where("id = ?", r.Id).
+				Updates(map[string]any{"follower": follower, "followee": followee, "utime": now}).Error
+		case err != nil:
+		default:
+			// 重复关注，只保留一份
+			if r.Status && !exist.Status {
+				err = tx.Model(&FollowRelation{}).Where("id = ?", exist.Id).
+					Updates(map[string]any{"status": true, "utime": now}).Error
+				if err != nil {
+					return err
+				}
+			}
+			err = tx.Delete(&FollowRelation{}, r.Id).Error
+		}
+		if err != nil {
+			return err
+		}
+	}
+
+	// 重新统计关注数据
+	delete(affected, src)
+	if err = tx.Where("uid = ?", src).Delete(&FollowData{}).Error; err != nil {
+		return err
+	}
+	for uid := range affected {
+		var followers, followees int64
+		err = tx.Model(&FollowRelation{}).Where("followee = ? AND status = ?", uid, true).Count(&followers).Error
+		if err != nil {
+			return err
+		}
+		err = tx.Model(&FollowRelation{}).Where("follower = ? AND status = ?", uid, true).Count(&followees).Error
+		if err != nil {
+			return err
+		}
+		err = tx.Clauses(clause.OnConflict{
+			DoUpdates: clause.Assignments(map[string]any{
+				"followers": followers,
+				"followees": followees,
+				"utime":     now,
+			}),
+		}).Create(&FollowData{
+			Uid:       uid,
+			Followers: followers,
+			Followees: followees,
+			Ctime:     now,
+			Utime:     now,
+		}).Error
+		if err != nil {
+			return err
+		}
+		res.FollowUids = append(res.FollowUids, uid)
+	}
+	res.FollowUids = append(res.FollowUids, src)
+	return nil
+}
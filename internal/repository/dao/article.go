@@ -10,13 +10,24 @@ import (
 	"gorm.io/gorm/clause"
 )
 
-var ErrIncorrectArticleorAuthor = errors.New("帖子或作者ID错误")
+var (
+	ErrIncorrectArticleorAuthor = errors.New("帖子或作者ID错误")
+	ErrArticleNotPending        = errors.New("帖子不在审核中")
+	ErrArticleNotScheduled      = errors.New("帖子没有定时发表")
+)
 
 type ArticleDAO interface {
 	Insert(ctx context.Context, article Article) (int64, error)
 	Update(ctx context.Context, article Article) error
-	Sync(ctx context.Context, article Article) (int64, error)
 	SyncStatus(ctx context.Context, uid int64, aid int64, status uint8) error
+	GetPending(ctx context.Context, aid int64) (Article, error)
+	Approve(ctx context.Context, article Article) error
+	Reject(ctx context.Context, aid int64, utime int64, reason string) error
+	UpdatePubStatus(ctx context.Context, aid int64, from, to uint8) (bool, error)
+	GetDueScheduled(ctx context.Context, now int64, afterAt, afterId int64, limit int) ([]Article, error)
+	PublishScheduled(ctx context.Context, article Article) error
+	CancelSchedule(ctx context.Context, uid int64, aid int64) error
+	Reschedule(ctx context.Context, uid int64, aid int64, publishAt int64) error
 	CountByAuthor(ctx context.Context, uid int64) (int64, error)
 	GetListByAuthor(ctx context.Context, uid int64, offset, limit int) ([]Article, error)
 	GetById(ctx context.Context, aid int64) (Article, error)
@@ -53,60 +64,49 @@ func (dao *GormArticleDAO) Insert(ctx context.Context, article Article) (int64,
 	article.Ctime = now
 	article.Utime = now
 
-	// 插入新记录
-	err := dao.master.WithContext(ctx).Create(&article).Error
+	// 插入新记录，同时记录第一个版本
+	err := dao.master.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&article).Error; err != nil {
+			return err
+		}
+		return insertRevision(tx, article)
+	})
 	return article.Id, err
 }
 
-// Update 更新帖子
+// Update 更新帖子，同时记录一个新的版本
 func (dao *GormArticleDAO) Update(ctx context.Context, article Article) error {
 
 	// 下面的更新语句，不会忽略为空值的字段！！！
 	now := time.Now().UnixMilli()
-	res := dao.master.WithContext(ctx).Model(&article).
-		Where("id = ? AND author_id = ?", article.Id, article.AuthorId).Updates(map[string]any{
-		"title":   article.Title,
-		"content": article.Content,
-		"status":  article.Status,
-		"utime":   now,
-	})
-	if res.Error != nil {
-		return res.Error
-	}
-
-	// 如果没有更新数据，则是 ID 或 AuthorId 错误
-	if res.RowsAffected == 0 {
-		return errors.New("ArticleId 或者 AuthorId 错误")
-	}
-	return nil
-}
-
-// Sync 使用事务，先存储制作库，再同步线上库
-func (dao *GormArticleDAO) Sync(ctx context.Context, article Article) (int64, error) {
-
-	// 使用事务
-	err := dao.master.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
-
-		var err error
-
-		// 存储制作库
-		if article.Id > 0 {
-			err = dao.Update(ctx, article)
-		} else {
-			article.Id, err = dao.Insert(ctx, article)
+	return dao.master.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		res := tx.Model(&article).
+			Where("id = ? AND author_id = ?", article.Id, article.AuthorId).Updates(map[string]any{
+			"title":         article.Title,
+			"content":       article.Content,
+			"status":        article.Status,
+			"reject_reason": "",
+			"publish_at":    article.PublishAt,
+			"utime":         now,
+		})
+		if res.Error != nil {
+			return res.Error
 		}
-		if err != nil {
-			return err
+
+		// 如果没有更新数据，则是 ID 或 AuthorId 错误
+		if res.RowsAffected == 0 {
+			return errors.New("ArticleId 或者 AuthorId 错误")
 		}
 
-		// 同步到线上库（Insert or Update）
-		err = dao.upsert(ctx, tx, article)
-		return err
+		article.Utime = now
+		return insertRevision(tx, article)
 	})
-	return article.Id, err
 }
 
-// upsert 新建帖子，或者更新帖子到线上库中
+/*
+upsert 新建帖子，或者更新帖子到线上库中：
+因为举报被隐藏的帖子保持隐藏，只有管理员处理举报之后才能恢复，作者重新发表不能绕过
+*/
 func (dao *GormArticleDAO) upsert(ctx context.Context, tx *gorm.DB, article Article) error {
 
 	// 类型转换
@@ -118,10 +118,13 @@ func (dao *GormArticleDAO) upsert(ctx context.Context, tx *gorm.DB, article Arti
 	// upsert 语义
 	err := tx.WithContext(ctx).Clauses(clause.OnConflict{
 		DoUpdates: clause.Assignments(map[string]interface{}{
-			"title":   pa.Title,
-			"content": pa.Content,
-			"status":  pa.Status,
-			"utime":   pa.Utime,
+			"title":    pa.Title,
+			"content":  pa.Content,
+			"status":   gorm.Expr("IF(status = ?, status, ?)", ArticleStatusHidden, pa.Status),
+			"html":     pa.Html,
+			"abstract": pa.Abstract,
+			"toc":      pa.Toc,
+			"utime":    pa.Utime,
 		}),
 	}).Create(&pa).Error
 	return err
@@ -147,8 +150,8 @@ func (dao *GormArticleDAO) SyncStatus(ctx context.Context, uid int64, aid int64,
 			return ErrIncorrectArticleorAuthor
 		}
 
-		// 撤销线上库的帖子
-		return tx.Model(&PublishedArticle{}).Where("id = ? AND author_id = ?", aid, uid).Updates(map[string]any{
+		// 撤销线上库的帖子，因为举报被隐藏的帖子保持隐藏
+		return tx.Model(&PublishedArticle{}).Where("id = ? AND author_id = ? AND status <> ?", aid, uid, ArticleStatusHidden).Updates(map[string]any{
 			"utime":  now,
 			"status": status,
 		}).Error
@@ -156,6 +159,160 @@ func (dao *GormArticleDAO) SyncStatus(ctx context.Context, uid int64, aid int64,
 	return err
 }
 
+// GetPending 从主库获取审核中的帖子，避免主从延迟读到旧的状态
+func (dao *GormArticleDAO) GetPending(ctx context.Context, aid int64) (Article, error) {
+	var art Article
+	err := dao.master.WithContext(ctx).Where("id = ? AND status = ?", aid, ArticleStatusPendingReview).First(&art).Error
+	if err == gorm.ErrRecordNotFound {
+		return Article{}, ErrArticleNotPending
+	}
+	return art, err
+}
+
+/*
+Approve 审核通过：
+使用事务，只有制作库中的帖子仍然是送审时的版本（状态和更新时间都没有变化）才修改为 article.Status，
+状态为已发表时，再把送审的内容同步到线上库；状态为定时发表时，等到发表时间再同步
+*/
+func (dao *GormArticleDAO) Approve(ctx context.Context, article Article) error {
+	now := time.Now().UnixMilli()
+	return dao.master.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		res := tx.Model(&Article{}).
+			Where("id = ? AND status = ? AND utime = ?", article.Id, ArticleStatusPendingReview, article.Utime).
+			Updates(map[string]any{
+				"status": article.Status,
+				"utime":  now,
+			})
+		if res.Error != nil {
+			return res.Error
+		}
+		if res.RowsAffected == 0 {
+			return ErrArticleNotPending
+		}
+		if article.Status != ArticleStatusPublished {
+			return nil
+		}
+
+		article.RejectReason = ""
+		return dao.upsert(ctx, tx, article)
+	})
+}
+
+// Reject 审核不通过，只处理送审时的版本
+func (dao *GormArticleDAO) Reject(ctx context.Context, aid int64, utime int64, reason string) error {
+	res := dao.master.WithContext(ctx).Model(&Article{}).
+		Where("id = ? AND status = ? AND utime = ?", aid, ArticleStatusPendingReview, utime).
+		Updates(map[string]any{
+			"status":        ArticleStatusRejected,
+			"reject_reason": reason,
+			"utime":         time.Now().UnixMilli(),
+		})
+	if res.Error != nil {
+		return res.Error
+	}
+	if res.RowsAffected == 0 {
+		return ErrArticleNotPending
+	}
+	return nil
+}
+
+// UpdatePubStatus 只修改线上库的帖子状态，状态为 from 时才修改，返回是否修改成功
+func (dao *GormArticleDAO) UpdatePubStatus(ctx context.Context, aid int64, from, to uint8) (bool, error) {
+	res := dao.master.WithContext(ctx).Model(&PublishedArticle{}).
+		Where("id = ? AND status = ?", aid, from).
+		Updates(map[string]any{
+			"status": to,
+			"utime":  time.Now().UnixMilli(),
+		})
+	return res.RowsAffected > 0, res.Error
+}
+
+/*
+GetDueScheduled 从主库获取已经到达发表时间的定时发表帖子：
+按照（发表时间，帖子 ID）递增排序，只返回游标 <afterAt, afterId> 之后的帖子，发表失败的帖子不会阻塞后面的帖子
+*/
+func (dao *GormArticleDAO) GetDueScheduled(ctx context.Context, now int64, afterAt, afterId int64, limit int) ([]Article, error) {
+	var arts []Article
+	err := dao.master.WithContext(ctx).
+		Where("status = ? AND publish_at <= ?", ArticleStatusScheduled, now).
+		Where("publish_at > ? OR (publish_at = ? AND id > ?)", afterAt, afterAt, afterId).
+		Order("publish_at, id").Limit(limit).Find(&arts).Error
+	return arts, err
+}
+
+/*
+PublishScheduled 发表到期的定时发表帖子：
+使用事务，和 Approve 一样先修改制作库，再同步到线上库；
+只有帖子仍然是读取时的版本（状态和更新时间都没有变化）才发表，避免覆盖作者取消或修改后的帖子
+*/
+func (dao *GormArticleDAO) PublishScheduled(ctx context.Context, article Article) error {
+	now := time.Now().UnixMilli()
+	return dao.master.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		res := tx.Model(&Article{}).
+			Where("id = ? AND status = ? AND utime = ? AND publish_at <= ?", article.Id, ArticleStatusScheduled, article.Utime, now).
+			Updates(map[string]any{
+				"status":     ArticleStatusPublished,
+				"publish_at": 0,
+				"utime":      now,
+			})
+		if res.Error != nil {
+			return res.Error
+		}
+		if res.RowsAffected == 0 {
+			return ErrArticleNotScheduled
+		}
+
+		article.Status = ArticleStatusPublished
+		article.RejectReason = ""
+		article.PublishAt = 0
+		return dao.upsert(ctx, tx, article)
+	})
+}
+
+// CancelSchedule 取消定时发表（审核中或者等待发表），帖子变为未发表
+func (dao *GormArticleDAO) CancelSchedule(ctx context.Context, uid int64, aid int64) error {
+	res := dao.master.WithContext(ctx).Model(&Article{}).
+		Where("id = ? AND author_id = ? AND publish_at > 0 AND status IN (?, ?)", aid, uid,
+			ArticleStatusPendingReview, ArticleStatusScheduled).
+		Updates(map[string]any{
+			"status":     ArticleStatusUnpublished,
+			"publish_at": 0,
+			"utime":      time.Now().UnixMilli(),
+		})
+	if res.Error != nil {
+		return res.Error
+	}
+	if res.RowsAffected == 0 {
+		return ErrArticleNotScheduled
+	}
+	return nil
+}
+
+/*
+Reschedule 修改定时发表的时间：
+内容没有变化，所以不需要重新审核；
+不修改更新时间，否则审核中的帖子会被 Approve 当作已经修改过的版本
+*/
+func (dao *GormArticleDAO) Reschedule(ctx context.Context, uid int64, aid int64, publishAt int64) error {
+	db := dao.master.WithContext(ctx).Model(&Article{}).
+		Where("id = ? AND author_id = ? AND publish_at > 0 AND status IN (?, ?)", aid, uid,
+			ArticleStatusPendingReview, ArticleStatusScheduled)
+	res := db.Session(&gorm.Session{}).Update("publish_at", publishAt)
+	if res.Error != nil || res.RowsAffected > 0 {
+		return res.Error
+	}
+
+	// 发表时间没有变化时，MySQL 返回的影响行数也是 0
+	var cnt int64
+	if err := db.Session(&gorm.Session{}).Count(&cnt).Error; err != nil {
+		return err
+	}
+	if cnt == 0 {
+		return ErrArticleNotScheduled
+	}
+	return nil
+}
+
 // CountByAuthor 获取作者的制作库帖子总数
 func (dao *GormArticleDAO) CountByAuthor(ctx context.Context, uid int64) (int64, error) {
     var count int64
@@ -188,7 +345,7 @@ func (dao *GormArticleDAO) GetPubById(ctx context.Context, aid int64) (Published
 func (dao *GormArticleDAO) GetPubList(ctx context.Context, startTime time.Time, offset, limit int) ([]PublishedArticle, error) {
 	var res []PublishedArticle
 	err := dao.RandSalve().WithContext(ctx).Order("utime DESC").
-		Where("utime > ?", startTime.UnixMilli()).Limit(limit).Offset(offset).Find(&res).Error
+		Where("utime > ? AND status = ?", startTime.UnixMilli(), ArticleStatusPublished).Limit(limit).Offset(offset).Find(&res).Error
 	return res, err
 }
 
@@ -196,10 +353,21 @@ func (dao *GormArticleDAO) GetPubList(ctx context.Context, startTime time.Time,
 func (dao *GormArticleDAO) SearchByTitle(ctx context.Context, title string, limit, offset int) ([]PublishedArticle, error) {
 	var res []PublishedArticle
 	err := dao.RandSalve().WithContext(ctx).Order("utime DESC").
-		Where("title like ?", "%"+title+"%").Limit(limit).Offset(offset).Find(&res).Error
+		Where("title like ? AND status = ?", "%"+title+"%", ArticleStatusPublished).Limit(limit).Offset(offset).Find(&res).Error
 	return res, err
 }
 
+// 帖子状态，和 domain.ArticleStatus 保持一致
+const (
+	ArticleStatusUnpublished uint8 = iota
+	ArticleStatusPublished
+	ArticleStatusPrivate
+	ArticleStatusPendingReview
+	ArticleStatusRejected
+	ArticleStatusScheduled
+	ArticleStatusHidden
+)
+
 // Article 制作库
 type Article struct {
 	Id       int64 `gorm:"primaryKey"`
@@ -209,6 +377,17 @@ type Article struct {
 	Status   uint8
 	Ctime    int64 // 创建时间
 	Utime    int64 // 更新时间
+
+	// 审核不通过的原因，只在制作库中使用
+	RejectReason string `gorm:"type:varchar(512)"`
+
+	// 定时发表的时间（毫秒），为 0 时表示立即发表，只在制作库中使用
+	PublishAt int64 `gorm:"index"`
+
+	// 渲染之后的 HTML、纯文本摘要和目录（JSON），只在线上库中使用
+	Html     string `gorm:"type:longtext"`
+	Abstract string `gorm:"type:varchar(512)"`
+	Toc      string `gorm:"type:text"`
 }
 
 // PublishedArticle 线上库
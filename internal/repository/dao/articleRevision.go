@@ -0,0 +1,71 @@
+package dao
+
+import (
+	"context"
+
+	"gorm.io/gorm"
+)
+
+type ArticleRevisionDAO interface {
+	GetList(ctx context.Context, aid int64, offset, limit int) ([]ArticleRevision, error)
+	GetById(ctx context.Context, id int64) (ArticleRevision, error)
+}
+
+type GormArticleRevisionDAO struct {
+	master *gorm.DB
+}
+
+func NewArticleRevisionDAO(m *gorm.DB) ArticleRevisionDAO {
+	return &GormArticleRevisionDAO{
+		master: m,
+	}
+}
+
+/*
+insertRevision 在保存帖子的事务中记录一个新的版本：
+更新制作库时已经锁住了帖子所在的行，所以同一个帖子的版本号不会冲突
+*/
+func insertRevision(tx *gorm.DB, article Article) error {
+	var version int64
+	err := tx.Model(&ArticleRevision{}).Select("COALESCE(MAX(version), 0)").
+		Where("article_id = ?", article.Id).Scan(&version).Error
+	if err != nil {
+		return err
+	}
+	return tx.Create(&ArticleRevision{
+		ArticleId: article.Id,
+		AuthorId:  article.AuthorId,
+		Version:   version + 1,
+		Title:     article.Title,
+		Content:   article.Content,
+		Status:    article.Status,
+		Ctime:     article.Utime,
+	}).Error
+}
+
+// GetList 获取帖子的版本列表，不包含内容，新版本在前
+func (dao *GormArticleRevisionDAO) GetList(ctx context.Context, aid int64, offset, limit int) ([]ArticleRevision, error) {
+	var res []ArticleRevision
+	err := dao.master.WithContext(ctx).Omit("content").Where("article_id = ?", aid).
+		Order("version DESC").Offset(offset).Limit(limit).Find(&res).Error
+	return res, err
+}
+
+// GetById 获取指定的版本
+func (dao *GormArticleRevisionDAO) GetById(ctx context.Context, id int64) (ArticleRevision, error) {
+	var res ArticleRevision
+	err := dao.master.WithContext(ctx).Where("id = ?", id).First(&res).Error
+	return res, err
+}
+
+// ArticleRevision 帖子的历史版本，每次保存或者提交发表都会记录一个版本，Status 为保存时的状态
+type ArticleRevision struct {
+	Id        int64 `gorm:"primaryKey,autoIncrement"`
+	ArticleId int64 `gorm:"uniqueIndex:article_version"`
+	AuthorId  int64
+	Version   int64 `gorm:"uniqueIndex:article_version"`
+	Title     string
+	Content   string
+	Status    uint8
+	Ctime     int64
+}
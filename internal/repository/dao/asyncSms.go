@@ -0,0 +1,115 @@
+package dao
+
+import (
+	"context"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type AsyncSmsDAO interface {
+	Insert(ctx context.Context, s AsyncSms) error
+	Preempt(ctx context.Context, before int64) (AsyncSms, error)
+	MarkSuccess(ctx context.Context, id int64) error
+	MarkFailed(ctx context.Context, s AsyncSms) error
+}
+
+type GormAsyncSmsDAO struct {
+	master *gorm.DB
+}
+
+func NewAsyncSmsDAO(m *gorm.DB) AsyncSmsDAO {
+	return &GormAsyncSmsDAO{
+		master: m,
+	}
+}
+
+// Insert 插入一条待重试的短信
+func (dao *GormAsyncSmsDAO) Insert(ctx context.Context, s AsyncSms) error {
+	now := time.Now().UnixMilli()
+	s.Status = AsyncSmsWaiting
+	s.Ctime = now
+	s.Utime = now
+	return dao.master.WithContext(ctx).Create(&s).Error
+}
+
+/*
+Preempt 抢占一条待重试的短信：
+选取更新时间早于 before 的等待中短信，或者发送超时（实例在发送过程中退出）的短信，
+再使用乐观锁（状态 + 更新时间）标记为发送中，被其他实例抢走时换下一条
+*/
+func (dao *GormAsyncSmsDAO) Preempt(ctx context.Context, before int64) (AsyncSms, error) {
+	db := dao.master.WithContext(ctx)
+	for {
+		now := time.Now()
+		var s AsyncSms
+		err := db.Where("(status = ? AND utime < ?) OR (status = ? AND utime < ?)",
+			AsyncSmsWaiting, before, AsyncSmsSending, now.Add(-asyncSmsSendingTimeout).UnixMilli()).
+			First(&s).Error
+		if err != nil {
+			return AsyncSms{}, err
+		}
+
+		res := db.Model(&AsyncSms{}).
+			Where("id = ? AND status = ? AND utime = ?", s.Id, s.Status, s.Utime).
+			Updates(map[string]any{
+				"status": AsyncSmsSending,
+				"utime":  now.UnixMilli(),
+			})
+		if res.Error != nil {
+			return AsyncSms{}, res.Error
+		}
+		if res.RowsAffected == 1 {
+			s.Status = AsyncSmsSending
+			s.Utime = now.UnixMilli()
+			return s, nil
+		}
+	}
+}
+
+// MarkSuccess 标记短信发送成功
+func (dao *GormAsyncSmsDAO) MarkSuccess(ctx context.Context, id int64) error {
+	return dao.master.WithContext(ctx).Model(&AsyncSms{}).
+		Where("id = ? AND status = ?", id, AsyncSmsSending).
+		Updates(map[string]any{
+			"status": AsyncSmsSuccess,
+			"utime":  time.Now().UnixMilli(),
+		}).Error
+}
+
+// MarkFailed 记录一次发送失败，达到最大重试次数后不再重试
+func (dao *GormAsyncSmsDAO) MarkFailed(ctx context.Context, s AsyncSms) error {
+	cnt := s.RetryCnt + 1
+	status := AsyncSmsWaiting
+	if cnt >= s.RetryMax {
+		status = AsyncSmsFailed
+	}
+	return dao.master.WithContext(ctx).Model(&AsyncSms{}).
+		Where("id = ? AND status = ?", s.Id, AsyncSmsSending).
+		Updates(map[string]any{
+			"retry_cnt": cnt,
+			"status":    status,
+			"utime":     time.Now().UnixMilli(),
+		}).Error
+}
+
+// 发送中的短信超过该时间没有结果，视为发送实例已经退出
+const asyncSmsSendingTimeout = time.Minute
+
+// 异步短信状态
+const (
+	AsyncSmsWaiting uint8 = iota
+	AsyncSmsSending
+	AsyncSmsSuccess
+	AsyncSmsFailed
+)
+
+type AsyncSms struct {
+	Id       int64  `gorm:"primaryKey,autoIncrement"`
+	Config   string // 短信内容，JSON 格式
+	RetryCnt int
+	RetryMax int
+	Status   uint8 `gorm:"index:status_utime"`
+	Ctime    int64
+	Utime    int64 `gorm:"index:status_utime"`
+}
@@ -0,0 +1,153 @@
+package dao
+
+import (
+	"context"
+	"errors"
+	"math/rand"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var ErrIncorrectCommentorUser = errors.New("评论或用户ID错误")
+
+type CommentDAO interface {
+	Insert(ctx context.Context, c Comment) (int64, error)
+	Delete(ctx context.Context, uid int64, id int64) (Comment, int64, error)
+	GetById(ctx context.Context, id int64) (Comment, error)
+	GetTopList(ctx context.Context, biz string, bizId int64, lastId int64, limit int) ([]Comment, error)
+	GetReplies(ctx context.Context, rootId int64, lastId int64, limit int) ([]Comment, error)
+}
+
+type GormCommentDAO struct {
+	master *gorm.DB
+	slaves []*gorm.DB
+}
+
+func NewCommentDAO(m *gorm.DB, s []*gorm.DB) CommentDAO {
+	return &GormCommentDAO{
+		master: m,
+		slaves: s,
+	}
+}
+
+func (dao *GormCommentDAO) RandSalve() *gorm.DB {
+	rand.Seed(time.Now().UnixNano())
+	randomSlave := dao.slaves[rand.Intn(len(dao.slaves))]
+	return randomSlave
+}
+
+// Insert 插入评论，同时评论数量 +1
+func (dao *GormCommentDAO) Insert(ctx context.Context, c Comment) (int64, error) {
+	now := time.Now().UnixMilli()
+	c.Ctime = now
+	c.Utime = now
+
+	// 开启事务
+	err := dao.master.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+
+		// 创建评论
+		if err := tx.Create(&c).Error; err != nil {
+			return err
+		}
+
+		// 评论数量 +1（upsert 语义）
+		return tx.Clauses(clause.OnConflict{
+			DoUpdates: clause.Assignments(map[string]interface{}{
+				"comment_cnt": gorm.Expr("`comment_cnt` + 1"),
+				"utime":       now,
+			}),
+		}).Create(&Interaction{
+			Biz:        c.Biz,
+			BizId:      c.BizId,
+			CommentCnt: 1,
+			Ctime:      now,
+			Utime:      now,
+		}).Error
+	})
+	return c.Id, err
+}
+
+// Delete 删除自己的评论，顶级评论会连同它的回复一起删除，同时扣减评论数量，返回被删除的评论和删除数量
+func (dao *GormCommentDAO) Delete(ctx context.Context, uid int64, id int64) (Comment, int64, error) {
+	now := time.Now().UnixMilli()
+	var (
+		c   Comment
+		cnt int64
+	)
+
+	// 开启事务
+	err := dao.master.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+
+		err := tx.Where("id = ? AND uid = ?", id, uid).First(&c).Error
+		if err == gorm.ErrRecordNotFound {
+			return ErrIncorrectCommentorUser
+		}
+		if err != nil {
+			return err
+		}
+
+		// 删除评论，顶级评论连同回复一起删除
+		db := tx.Where("id = ?", id)
+		if c.RootId == 0 {
+			db = db.Or("root_id = ?", id)
+		}
+		res := db.Delete(&Comment{})
+		if res.Error != nil {
+			return res.Error
+		}
+		cnt = res.RowsAffected
+
+		// 评论数量 -n
+		return tx.Model(&Interaction{}).
+			Where("biz = ? AND biz_id = ?", c.Biz, c.BizId).
+			Updates(map[string]interface{}{
+				"comment_cnt": gorm.Expr("`comment_cnt` - ?", cnt),
+				"utime":       now,
+			}).Error
+	})
+	return c, cnt, err
+}
+
+// GetById 获取指定评论
+func (dao *GormCommentDAO) GetById(ctx context.Context, id int64) (Comment, error) {
+	var c Comment
+	err := dao.RandSalve().WithContext(ctx).Where("id = ?", id).First(&c).Error
+	return c, err
+}
+
+// GetTopList 按照游标（评论 ID）获取顶级评论，新评论在前
+func (dao *GormCommentDAO) GetTopList(ctx context.Context, biz string, bizId int64, lastId int64, limit int) ([]Comment, error) {
+	var res []Comment
+	db := dao.RandSalve().WithContext(ctx).Where("biz = ? AND biz_id = ? AND root_id = 0", biz, bizId)
+	if lastId > 0 {
+		db = db.Where("id < ?", lastId)
+	}
+	err := db.Order("id DESC").Limit(limit).Find(&res).Error
+	return res, err
+}
+
+// GetReplies 按照游标（评论 ID）获取顶级评论下的回复，旧回复在前
+func (dao *GormCommentDAO) GetReplies(ctx context.Context, rootId int64, lastId int64, limit int) ([]Comment, error) {
+	var res []Comment
+	err := dao.RandSalve().WithContext(ctx).
+		Where("root_id = ? AND id > ?", rootId, lastId).
+		Order("id ASC").Limit(limit).Find(&res).Error
+	return res, err
+}
+
+type Comment struct {
+	Id  int64 `gorm:"primaryKey,autoIncrement"`
+	Uid int64
+
+	// <biz, bizId>
+	Biz   string `gorm:"type:varchar(128);index:biz_type_id"`
+	BizId int64  `gorm:"index:biz_type_id"`
+
+	RootId   int64 `gorm:"index"`
+	ParentId int64
+	Content  string
+	Ctime    int64
+	Utime    int64
+}
@@ -12,7 +12,7 @@ import (
 
 type FeedPullEventDAO interface {
 	CreatePullEvent(ctx context.Context, event FeedPullEvent) error
-	FindPullEvents(ctx context.Context, uids []int64, timestamp, limit int64) ([]FeedPullEvent, error)
+	FindPullEvents(ctx context.Context, uids []int64, timestamp, id, limit int64) ([]FeedPullEvent, error)
 	FindPullEventListWithTyp(ctx context.Context, typ string, uids []int64, timestamp, limit int64) ([]FeedPullEvent, error)
 }
 
@@ -50,12 +50,13 @@ func (f *feedPullEventDAO) FindPullEventListWithTyp(ctx context.Context, typ str
 	return events, err
 }
 
-func (f *feedPullEventDAO) FindPullEvents(ctx context.Context, uids []int64, timestamp, limit int64) ([]FeedPullEvent, error) {
+// FindPullEvents 按照游标（时间戳 + 事件 ID）查询发件箱
+func (f *feedPullEventDAO) FindPullEvents(ctx context.Context, uids []int64, timestamp, id, limit int64) ([]FeedPullEvent, error) {
 	var events []FeedPullEvent
 	err := f.RandSalve().WithContext(ctx).
 		Where("uid in ?", uids).
-		Where("ctime < ?", timestamp).
-		Order("ctime desc").
+		Where("ctime < ? OR (ctime = ? AND id < ?)", timestamp, timestamp, id).
+		Order("ctime desc, id desc").
 		Limit(int(limit)).
 		Find(&events).Error
 	return events, err
@@ -73,7 +74,7 @@ type FeedPullEvent struct {
 
 type FeedPushEventDAO interface {
 	CreatePushEvents(ctx context.Context, events []FeedPushEvent) error
-	GetPushEvents(ctx context.Context, uid int64, timestamp, limit int64) ([]FeedPushEvent, error)
+	GetPushEvents(ctx context.Context, uid int64, timestamp, id, limit int64) ([]FeedPushEvent, error)
 	GetPushEventsWithTyp(ctx context.Context, typ string, uid int64, timestamp, limit int64) ([]FeedPushEvent, error)
 }
 
@@ -111,12 +112,13 @@ func (f *feedPushEventDAO) GetPushEventsWithTyp(ctx context.Context, typ string,
 	return events, err
 }
 
-func (f *feedPushEventDAO) GetPushEvents(ctx context.Context, uid int64, timestamp, limit int64) ([]FeedPushEvent, error) {
+// GetPushEvents 按照游标（时间戳 + 事件 ID）查询收件箱
+func (f *feedPushEventDAO) GetPushEvents(ctx context.Context, uid int64, timestamp, id, limit int64) ([]FeedPushEvent, error) {
 	var events []FeedPushEvent
 	err := f.RandSalve().WithContext(ctx).
 		Where("uid = ?", uid).
-		Where("ctime < ?", timestamp).
-		Order("ctime desc").
+		Where("ctime < ? OR (ctime = ? AND id < ?)", timestamp, timestamp, id).
+		Order("ctime desc, id desc").
 		Limit(int(limit)).
 		Find(&events).Error
 	return events, err
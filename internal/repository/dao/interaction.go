@@ -2,6 +2,7 @@ package dao
 
 import (
 	"context"
+	"fmt"
 	"math/rand"
 	"time"
 
@@ -72,18 +73,39 @@ func (dao *GORMInteractionDAO) IncrReadCnt(ctx context.Context, biz string, bizI
 	}).Error
 }
 
-// BatchIncrReadCnt 批量增加阅读量
+// BatchIncrReadCnt 批量增加阅读量，先按照 <biz, bizId> 聚合，再使用一条多行 upsert 语句写入
 func (dao *GORMInteractionDAO) BatchIncrReadCnt(ctx context.Context, bizs []string, bizIds []int64) error {
-	return dao.master.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
-		txDAO := NewInteractionDAO(tx, nil)
-		for i := 0; i < len(bizs); i++ {
-			err := txDAO.IncrReadCnt(ctx, bizs[i], bizIds[i])
-			if err != nil {
-				return err
-			}
+	now := time.Now().UnixMilli()
+
+	// 按照 <biz, bizId> 聚合阅读量
+	idx := make(map[string]int, len(bizs))
+	rows := make([]Interaction, 0, len(bizs))
+	for i := 0; i < len(bizs); i++ {
+		key := fmt.Sprintf("%s:%d", bizs[i], bizIds[i])
+		if j, ok := idx[key]; ok {
+			rows[j].ReadCnt++
+			continue
 		}
+		idx[key] = len(rows)
+		rows = append(rows, Interaction{
+			Biz:     bizs[i],
+			BizId:   bizIds[i],
+			ReadCnt: 1,
+			Ctime:   now,
+			Utime:   now,
+		})
+	}
+	if len(rows) == 0 {
 		return nil
-	})
+	}
+
+	// upsert 语义
+	return dao.master.WithContext(ctx).Clauses(clause.OnConflict{
+		DoUpdates: clause.Assignments(map[string]interface{}{
+			"read_cnt": gorm.Expr("`read_cnt` + VALUES(`read_cnt`)"),
+			"utime":    now,
+		}),
+	}).Create(&rows).Error
 }
 
 // GetLike 获取点赞信息（是否点赞）
@@ -273,6 +295,7 @@ type Interaction struct {
 	ReadCnt    int64
 	LikeCnt    int64
 	CollectCnt int64
+	CommentCnt int64
 	Utime      int64
 	Ctime      int64
 }
\ No newline at end of file
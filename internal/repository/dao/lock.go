@@ -0,0 +1,72 @@
+package dao
+
+import (
+	"context"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type LockDAO interface {
+	TryLock(ctx context.Context, name, owner string, ttl time.Duration) (bool, error)
+	Unlock(ctx context.Context, name, owner string) error
+}
+
+type GormLockDAO struct {
+	master *gorm.DB
+}
+
+func NewLockDAO(m *gorm.DB) LockDAO {
+	return &GormLockDAO{
+		master: m,
+	}
+}
+
+/*
+TryLock 尝试获取分布式锁：
+锁不存在时插入一条记录；锁已经存在时，只有锁已经过期或者持有者是自己（续约）才能更新成功
+*/
+func (dao *GormLockDAO) TryLock(ctx context.Context, name, owner string, ttl time.Duration) (bool, error) {
+	now := time.Now().UnixMilli()
+	expireAt := now + ttl.Milliseconds()
+
+	// 锁不存在时插入
+	res := dao.master.WithContext(ctx).Clauses(clause.OnConflict{DoNothing: true}).Create(&DistributedLock{
+		Name:     name,
+		Owner:    owner,
+		ExpireAt: expireAt,
+		Ctime:    now,
+		Utime:    now,
+	})
+	if res.Error != nil {
+		return false, res.Error
+	}
+	if res.RowsAffected > 0 {
+		return true, nil
+	}
+
+	// 抢占过期的锁，或者续约
+	res = dao.master.WithContext(ctx).Model(&DistributedLock{}).
+		Where("name = ? AND (owner = ? OR expire_at < ?)", name, owner, now).
+		Updates(map[string]any{
+			"owner":     owner,
+			"expire_at": expireAt,
+			"utime":     now,
+		})
+	return res.RowsAffected > 0, res.Error
+}
+
+// Unlock 释放自己持有的锁
+func (dao *GormLockDAO) Unlock(ctx context.Context, name, owner string) error {
+	return dao.master.WithContext(ctx).Where("name = ? AND owner = ?", name, owner).Delete(&DistributedLock{}).Error
+}
+
+// DistributedLock 基于 MySQL 的分布式锁，ExpireAt 之后其它实例可以抢占
+type DistributedLock struct {
+	Name     string `gorm:"primaryKey;type:varchar(128)"`
+	Owner    string `gorm:"type:varchar(128)"`
+	ExpireAt int64
+	Ctime    int64
+	Utime    int64
+}
@@ -0,0 +1,55 @@
+package dao
+
+import (
+	"context"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type LoginLockoutDAO interface {
+	Insert(ctx context.Context, l LoginLockout) error
+	MarkUnlocked(ctx context.Context, targetType, target string) error
+}
+
+type GormLoginLockoutDAO struct {
+	master *gorm.DB
+}
+
+func NewLoginLockoutDAO(m *gorm.DB) LoginLockoutDAO {
+	return &GormLoginLockoutDAO{
+		master: m,
+	}
+}
+
+// Insert 记录一次锁定
+func (dao *GormLoginLockoutDAO) Insert(ctx context.Context, l LoginLockout) error {
+	now := time.Now().UnixMilli()
+	l.Ctime = now
+	l.Utime = now
+	return dao.master.WithContext(ctx).Create(&l).Error
+}
+
+// MarkUnlocked 记录提前解除锁定的时间，只更新还没有到期的锁定
+func (dao *GormLoginLockoutDAO) MarkUnlocked(ctx context.Context, targetType, target string) error {
+	now := time.Now().UnixMilli()
+	return dao.master.WithContext(ctx).Model(&LoginLockout{}).
+		Where("target_type = ? AND target = ? AND lock_until > ? AND unlock_time = 0", targetType, target, now).
+		Updates(map[string]any{
+			"unlock_time": now,
+			"utime":       now,
+		}).Error
+}
+
+// LoginLockout 登录锁定的审计记录，Target 为被锁定的邮箱或 IP，Ip 为触发锁定的请求 IP
+type LoginLockout struct {
+	Id         int64  `gorm:"primaryKey,autoIncrement"`
+	TargetType string `gorm:"type:varchar(16);index:target"`
+	Target     string `gorm:"type:varchar(128);index:target"`
+	Ip         string `gorm:"type:varchar(64)"`
+	Failures   int64
+	LockUntil  int64
+	UnlockTime int64
+	Ctime      int64
+	Utime      int64
+}
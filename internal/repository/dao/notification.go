@@ -0,0 +1,103 @@
+package dao
+
+import (
+	"context"
+	"math/rand"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type NotificationDAO interface {
+	Insert(ctx context.Context, n Notification) (int64, error)
+	GetList(ctx context.Context, uid int64, lastId int64, limit int) ([]Notification, error)
+	CountUnread(ctx context.Context, uid int64) (int64, error)
+	MarkRead(ctx context.Context, uid int64, id int64) (int64, error)
+	MarkAllRead(ctx context.Context, uid int64) error
+}
+
+type GormNotificationDAO struct {
+	master *gorm.DB
+	slaves []*gorm.DB
+}
+
+func NewNotificationDAO(m *gorm.DB, s []*gorm.DB) NotificationDAO {
+	return &GormNotificationDAO{
+		master: m,
+		slaves: s,
+	}
+}
+
+func (dao *GormNotificationDAO) RandSalve() *gorm.DB {
+	rand.Seed(time.Now().UnixNano())
+	randomSlave := dao.slaves[rand.Intn(len(dao.slaves))]
+	return randomSlave
+}
+
+// Insert 插入一条通知
+func (dao *GormNotificationDAO) Insert(ctx context.Context, n Notification) (int64, error) {
+	now := time.Now().UnixMilli()
+	n.Ctime = now
+	n.Utime = now
+	err := dao.master.WithContext(ctx).Create(&n).Error
+	return n.Id, err
+}
+
+// GetList 按照游标（通知 ID）获取通知列表，lastId 为 0 表示第一页
+func (dao *GormNotificationDAO) GetList(ctx context.Context, uid int64, lastId int64, limit int) ([]Notification, error) {
+	var res []Notification
+	db := dao.RandSalve().WithContext(ctx).Where("uid = ?", uid)
+	if lastId > 0 {
+		db = db.Where("id < ?", lastId)
+	}
+	err := db.Order("id DESC").Limit(limit).Find(&res).Error
+	return res, err
+}
+
+// CountUnread 获取未读通知数量
+func (dao *GormNotificationDAO) CountUnread(ctx context.Context, uid int64) (int64, error) {
+	var count int64
+	err := dao.RandSalve().WithContext(ctx).Model(&Notification{}).
+		Where("uid = ? AND status = ?", uid, NotificationUnread).Count(&count).Error
+	return count, err
+}
+
+// MarkRead 标记一条通知为已读，返回受影响的行数
+func (dao *GormNotificationDAO) MarkRead(ctx context.Context, uid int64, id int64) (int64, error) {
+	res := dao.master.WithContext(ctx).Model(&Notification{}).
+		Where("id = ? AND uid = ? AND status = ?", id, uid, NotificationUnread).
+		Updates(map[string]any{
+			"status": NotificationRead,
+			"utime":  time.Now().UnixMilli(),
+		})
+	return res.RowsAffected, res.Error
+}
+
+// MarkAllRead 标记全部通知为已读
+func (dao *GormNotificationDAO) MarkAllRead(ctx context.Context, uid int64) error {
+	return dao.master.WithContext(ctx).Model(&Notification{}).
+		Where("uid = ? AND status = ?", uid, NotificationUnread).
+		Updates(map[string]any{
+			"status": NotificationRead,
+			"utime":  time.Now().UnixMilli(),
+		}).Error
+}
+
+// 通知状态
+const (
+	NotificationUnread uint8 = iota
+	NotificationRead
+)
+
+type Notification struct {
+	Id      int64  `gorm:"primaryKey,autoIncrement"`
+	Uid     int64  `gorm:"index:uid_status"`
+	Status  uint8  `gorm:"index:uid_status"`
+	Type    string `gorm:"type:varchar(32)"`
+	ActorId int64
+	Biz     string `gorm:"type:varchar(128)"`
+	BizId   int64
+	Content string
+	Ctime   int64
+	Utime   int64
+}
@@ -0,0 +1,130 @@
+package dao
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/go-sql-driver/mysql"
+	"gorm.io/gorm"
+)
+
+var ErrDuplicateReport = errors.New("重复举报")
+
+// 举报的处理状态，和 domain.ReportStatus 保持一致
+const (
+	ReportStatusPending uint8 = iota
+	ReportStatusAccepted
+	ReportStatusDismissed
+)
+
+type ReportDAO interface {
+	Insert(ctx context.Context, r Report) (int64, error)
+	CountPending(ctx context.Context, biz string, bizId int64) (int64, error)
+	GetPendingTargets(ctx context.Context, offset, limit int) ([]ReportTarget, error)
+	GetPendingByTarget(ctx context.Context, biz string, bizId int64, limit int) ([]Report, error)
+	Resolve(ctx context.Context, biz string, bizId int64, status uint8, operator int64) error
+}
+
+type GormReportDAO struct {
+	master *gorm.DB
+}
+
+func NewReportDAO(m *gorm.DB) ReportDAO {
+	return &GormReportDAO{
+		master: m,
+	}
+}
+
+// Insert 使用事务，插入举报之后返回该资源待处理的举报数量，同一个用户重复举报时返回 ErrDuplicateReport
+func (dao *GormReportDAO) Insert(ctx context.Context, r Report) (int64, error) {
+	now := time.Now().UnixMilli()
+	r.Status = ReportStatusPending
+	r.Ctime = now
+	r.Utime = now
+
+	var cnt int64
+	err := dao.master.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		err := tx.Create(&r).Error
+		if mysqlErr, ok := err.(*mysql.MySQLError); ok {
+			const duplicateErr uint16 = 1062
+			if mysqlErr.Number == duplicateErr {
+				return ErrDuplicateReport
+			}
+		}
+		if err != nil {
+			return err
+		}
+		return tx.Model(&Report{}).
+			Where("biz = ? AND biz_id = ? AND status = ?", r.Biz, r.BizId, ReportStatusPending).
+			Count(&cnt).Error
+	})
+	return cnt, err
+}
+
+// CountPending 获取资源待处理的举报数量
+func (dao *GormReportDAO) CountPending(ctx context.Context, biz string, bizId int64) (int64, error) {
+	var cnt int64
+	err := dao.master.WithContext(ctx).Model(&Report{}).
+		Where("biz = ? AND biz_id = ? AND status = ?", biz, bizId, ReportStatusPending).
+		Count(&cnt).Error
+	return cnt, err
+}
+
+// GetPendingTargets 按照资源聚合待处理的举报，举报数量多的在前
+func (dao *GormReportDAO) GetPendingTargets(ctx context.Context, offset, limit int) ([]ReportTarget, error) {
+	var res []ReportTarget
+	err := dao.master.WithContext(ctx).Model(&Report{}).
+		Select("biz, biz_id, COUNT(*) AS cnt, MAX(ctime) AS utime").
+		Where("status = ?", ReportStatusPending).
+		Group("biz, biz_id").
+		Order("cnt DESC, utime DESC").
+		Offset(offset).Limit(limit).
+		Scan(&res).Error
+	return res, err
+}
+
+// GetPendingByTarget 获取资源待处理的举报明细，新举报在前
+func (dao *GormReportDAO) GetPendingByTarget(ctx context.Context, biz string, bizId int64, limit int) ([]Report, error) {
+	var res []Report
+	err := dao.master.WithContext(ctx).
+		Where("biz = ? AND biz_id = ? AND status = ?", biz, bizId, ReportStatusPending).
+		Order("id DESC").Limit(limit).Find(&res).Error
+	return res, err
+}
+
+// Resolve 处理资源所有待处理的举报
+func (dao *GormReportDAO) Resolve(ctx context.Context, biz string, bizId int64, status uint8, operator int64) error {
+	return dao.master.WithContext(ctx).Model(&Report{}).
+		Where("biz = ? AND biz_id = ? AND status = ?", biz, bizId, ReportStatusPending).
+		Updates(map[string]any{
+			"status":   status,
+			"operator": operator,
+			"utime":    time.Now().UnixMilli(),
+		}).Error
+}
+
+// Report 举报，同一个用户对同一个资源只能举报一次，Operator 为处理举报的管理员
+type Report struct {
+	Id  int64 `gorm:"primaryKey,autoIncrement"`
+	Uid int64 `gorm:"uniqueIndex:uid_biz_type_id"`
+
+	// <biz, bizId>
+	Biz   string `gorm:"type:varchar(128);uniqueIndex:uid_biz_type_id;index:biz_type_id_status"`
+	BizId int64  `gorm:"uniqueIndex:uid_biz_type_id;index:biz_type_id_status"`
+
+	Reason   uint8
+	Detail   string `gorm:"type:varchar(512)"`
+	Status   uint8  `gorm:"index:biz_type_id_status"`
+	Operator int64
+	Ctime    int64
+	Utime    int64
+}
+
+// ReportTarget 按照资源聚合的查询结果
+type ReportTarget struct {
+	Biz   string
+	BizId int64
+	Cnt   int64
+	Utime int64
+}
@@ -0,0 +1,99 @@
+package dao
+
+import (
+	"context"
+	"math/rand"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type SessionDAO interface {
+	Insert(ctx context.Context, s UserSession) (int64, error)
+	GetActiveList(ctx context.Context, uid int64) ([]UserSession, error)
+	GetById(ctx context.Context, uid int64, id int64) (UserSession, error)
+	Revoke(ctx context.Context, ssid string) error
+	UpdateLastSeen(ctx context.Context, ssid string, lastSeen int64) error
+}
+
+type GormSessionDAO struct {
+	master *gorm.DB
+	slaves []*gorm.DB
+}
+
+func NewSessionDAO(m *gorm.DB, s []*gorm.DB) SessionDAO {
+	return &GormSessionDAO{
+		master: m,
+		slaves: s,
+	}
+}
+
+func (dao *GormSessionDAO) RandSalve() *gorm.DB {
+	rand.Seed(time.Now().UnixNano())
+	randomSlave := dao.slaves[rand.Intn(len(dao.slaves))]
+	return randomSlave
+}
+
+// Insert 记录一次登录
+func (dao *GormSessionDAO) Insert(ctx context.Context, s UserSession) (int64, error) {
+	now := time.Now().UnixMilli()
+	s.Ctime = now
+	s.Utime = now
+	s.LastSeen = now
+	err := dao.master.WithContext(ctx).Create(&s).Error
+	return s.Id, err
+}
+
+// GetActiveList 获取用户未退出、未过期的会话，最近活跃的排在前面
+func (dao *GormSessionDAO) GetActiveList(ctx context.Context, uid int64) ([]UserSession, error) {
+	var res []UserSession
+	err := dao.RandSalve().WithContext(ctx).
+		Where("uid = ? AND status = ? AND expire_at > ?", uid, SessionActive, time.Now().UnixMilli()).
+		Order("last_seen DESC").Find(&res).Error
+	return res, err
+}
+
+// GetById 获取用户的某个会话，会话不属于该用户时返回 ErrRecordNotFound
+func (dao *GormSessionDAO) GetById(ctx context.Context, uid int64, id int64) (UserSession, error) {
+	var s UserSession
+	err := dao.master.WithContext(ctx).Where("id = ? AND uid = ?", id, uid).First(&s).Error
+	return s, err
+}
+
+// Revoke 标记会话已退出
+func (dao *GormSessionDAO) Revoke(ctx context.Context, ssid string) error {
+	return dao.master.WithContext(ctx).Model(&UserSession{}).
+		Where("ssid = ?", ssid).
+		Updates(map[string]any{
+			"status": SessionRevoked,
+			"utime":  time.Now().UnixMilli(),
+		}).Error
+}
+
+// UpdateLastSeen 更新会话的最近活跃时间
+func (dao *GormSessionDAO) UpdateLastSeen(ctx context.Context, ssid string, lastSeen int64) error {
+	return dao.master.WithContext(ctx).Model(&UserSession{}).
+		Where("ssid = ?", ssid).
+		Update("last_seen", lastSeen).Error
+}
+
+// 会话状态
+const (
+	SessionActive uint8 = iota
+	SessionRevoked
+)
+
+// UserSession 用户在某个设备上的一次登录
+type UserSession struct {
+	Id        int64  `gorm:"primaryKey,autoIncrement"`
+	Uid       int64  `gorm:"index:uid_status"`
+	Status    uint8  `gorm:"index:uid_status"`
+	Ssid      string `gorm:"type:varchar(64);unique"`
+	Device    string `gorm:"type:varchar(64)"`
+	UserAgent string `gorm:"type:varchar(512)"`
+	Ip        string `gorm:"type:varchar(64)"`
+	LastSeen  int64
+	ExpireAt  int64
+	Ctime     int64
+	Utime     int64
+}
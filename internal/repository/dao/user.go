@@ -13,6 +13,7 @@ import (
 
 var (
 	ErrDuplicateEmailorPhone = errors.New("邮箱或手机号码冲突")
+	ErrDuplicateOAuth2       = errors.New("第三方账号已绑定")
 	ErrRecordNotFound        = gorm.ErrRecordNotFound
 )
 
@@ -22,6 +23,16 @@ type UserDAO interface {
 	SearchByEmail(ctx context.Context, email string) (User, error)
 	SearchByPhone(ctx context.Context, phone string) (User, error)
 	Update(ctx context.Context, u User) error
+	SearchByOAuth2(ctx context.Context, provider, openId string) (User, error)
+	InsertWithOAuth2(ctx context.Context, u User, b UserOAuthBinding) (int64, error)
+	UpdatePhone(ctx context.Context, uid int64, phone string) error
+	UpdateEmail(ctx context.Context, uid int64, email string) error
+	GetOAuth2Bindings(ctx context.Context, uid int64) ([]UserOAuthBinding, error)
+	InsertOAuth2Binding(ctx context.Context, b UserOAuthBinding) error
+	DeleteOAuth2Binding(ctx context.Context, uid int64, provider string) (int64, error)
+	UpdatePassword(ctx context.Context, uid int64, password string) error
+	UpdateRole(ctx context.Context, uid int64, role uint8) (int64, error)
+	UpdateStatus(ctx context.Context, uid int64, status uint8) (int64, error)
 }
 
 // UserDAO 数据库存储实例
@@ -115,6 +126,131 @@ func (dao *GormUserDAO) Update(ctx context.Context, u User) error {
 	return dao.master.WithContext(ctx).Save(&user).Error
 }
 
+// SearchByOAuth2 通过第三方账号查找用户
+func (dao *GormUserDAO) SearchByOAuth2(ctx context.Context, provider, openId string) (User, error) {
+	var b UserOAuthBinding
+	err := dao.master.WithContext(ctx).Where("provider = ? AND open_id = ?", provider, openId).First(&b).Error
+	if err != nil {
+		return User{}, err
+	}
+	var user User
+	err = dao.master.WithContext(ctx).Where(b.Uid).First(&user).Error
+	return user, err
+}
+
+// InsertWithOAuth2 在同一个事务中创建用户，并绑定第三方账号
+func (dao *GormUserDAO) InsertWithOAuth2(ctx context.Context, u User, b UserOAuthBinding) (int64, error) {
+	now := time.Now().UnixMilli()
+	u.CTime = now
+	u.UTime = now
+	b.Ctime = now
+	b.Utime = now
+
+	err := dao.master.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&u).Error; err != nil {
+			return err
+		}
+		b.Uid = u.Id
+		return tx.Create(&b).Error
+	})
+	if mysqlErr, ok := err.(*mysql.MySQLError); ok {
+		const duplicateErr uint16 = 1062
+		if mysqlErr.Number == duplicateErr {
+			// 并发回调时，第三方账号已经被绑定
+			return -1, ErrDuplicateOAuth2
+		}
+	}
+	return u.Id, err
+}
+
+// UpdatePhone 绑定手机号码，phone 为空表示解绑
+func (dao *GormUserDAO) UpdatePhone(ctx context.Context, uid int64, phone string) error {
+	err := dao.master.WithContext(ctx).Model(&User{}).Where("id = ?", uid).
+		Updates(map[string]any{
+			"phone":  sql.NullString{String: phone, Valid: phone != ""},
+			"u_time": time.Now().UnixMilli(),
+		}).Error
+	return dao.duplicateErr(err)
+}
+
+// UpdateEmail 绑定邮箱
+func (dao *GormUserDAO) UpdateEmail(ctx context.Context, uid int64, email string) error {
+	err := dao.master.WithContext(ctx).Model(&User{}).Where("id = ?", uid).
+		Updates(map[string]any{
+			"email":  sql.NullString{String: email, Valid: email != ""},
+			"u_time": time.Now().UnixMilli(),
+		}).Error
+	return dao.duplicateErr(err)
+}
+
+// UpdatePassword 更新密码，password 为加密后的密码
+func (dao *GormUserDAO) UpdatePassword(ctx context.Context, uid int64, password string) error {
+	return dao.master.WithContext(ctx).Model(&User{}).Where("id = ?", uid).
+		Updates(map[string]any{
+			"password": password,
+			"u_time":   time.Now().UnixMilli(),
+		}).Error
+}
+
+// UpdateRole 更新角色，返回受影响的行数
+func (dao *GormUserDAO) UpdateRole(ctx context.Context, uid int64, role uint8) (int64, error) {
+	res := dao.master.WithContext(ctx).Model(&User{}).Where("id = ?", uid).
+		Updates(map[string]any{
+			"role":   role,
+			"u_time": time.Now().UnixMilli(),
+		})
+	return res.RowsAffected, res.Error
+}
+
+// UpdateStatus 更新账号状态（正常、封禁），返回受影响的行数
+func (dao *GormUserDAO) UpdateStatus(ctx context.Context, uid int64, status uint8) (int64, error) {
+	res := dao.master.WithContext(ctx).Model(&User{}).Where("id = ?", uid).
+		Updates(map[string]any{
+			"status": status,
+			"u_time": time.Now().UnixMilli(),
+		})
+	return res.RowsAffected, res.Error
+}
+
+// duplicateErr 把唯一索引冲突转换为 ErrDuplicateEmailorPhone
+func (dao *GormUserDAO) duplicateErr(err error) error {
+	if mysqlErr, ok := err.(*mysql.MySQLError); ok {
+		const duplicateErr uint16 = 1062
+		if mysqlErr.Number == duplicateErr {
+			return ErrDuplicateEmailorPhone
+		}
+	}
+	return err
+}
+
+// GetOAuth2Bindings 获取用户绑定的第三方账号
+func (dao *GormUserDAO) GetOAuth2Bindings(ctx context.Context, uid int64) ([]UserOAuthBinding, error) {
+	var res []UserOAuthBinding
+	err := dao.master.WithContext(ctx).Where("uid = ?", uid).Find(&res).Error
+	return res, err
+}
+
+// InsertOAuth2Binding 为已有用户绑定第三方账号
+func (dao *GormUserDAO) InsertOAuth2Binding(ctx context.Context, b UserOAuthBinding) error {
+	now := time.Now().UnixMilli()
+	b.Ctime = now
+	b.Utime = now
+	err := dao.master.WithContext(ctx).Create(&b).Error
+	if mysqlErr, ok := err.(*mysql.MySQLError); ok {
+		const duplicateErr uint16 = 1062
+		if mysqlErr.Number == duplicateErr {
+			return ErrDuplicateOAuth2
+		}
+	}
+	return err
+}
+
+// DeleteOAuth2Binding 解绑第三方账号，返回受影响的行数
+func (dao *GormUserDAO) DeleteOAuth2Binding(ctx context.Context, uid int64, provider string) (int64, error) {
+	res := dao.master.WithContext(ctx).Where("uid = ? AND provider = ?", uid, provider).Delete(&UserOAuthBinding{})
+	return res.RowsAffected, res.Error
+}
+
 // User 数据库表结构
 type User struct {
 	Id           int64          `gorm:"primaryKey"`
@@ -124,6 +260,23 @@ type User struct {
 	NickName     string
 	Birthday     int64
 	Introduction string
+	Role         uint8 // 角色：0 普通用户，1 版主，2 管理员
+	Status       uint8 // 状态：0 正常，1 封禁
 	CTime        int64 // 创建时间
 	UTime        int64 // 更新时间
-}
\ No newline at end of file
+}
+
+// UserOAuthBinding 第三方账号和用户的绑定关系
+type UserOAuthBinding struct {
+	Id       int64  `gorm:"primaryKey,autoIncrement"`
+	Uid      int64  `gorm:"index"`
+	Provider string `gorm:"type:varchar(32);uniqueIndex:provider_open_id"`
+	OpenId   string `gorm:"type:varchar(128);uniqueIndex:provider_open_id"`
+	UnionId  string `gorm:"type:varchar(128)"`
+	Ctime    int64
+	Utime    int64
+}
+
+func (UserOAuthBinding) TableName() string {
+	return "user_oauth_binding"
+}
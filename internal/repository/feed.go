@@ -16,12 +16,12 @@ var FolloweesNotFound = cache.FolloweesNotFound
 type FeedRepository interface {
 	// 推事件
 	CreatePushEvents(ctx context.Context, events []domain.FeedEvent) error
-	FindPushEvents(ctx context.Context, uid, timestamp, limit int64) ([]domain.FeedEvent, error)
+	FindPushEvents(ctx context.Context, uid, timestamp, id, limit int64) ([]domain.FeedEvent, error)
 	FindPushEventsWithTyp(ctx context.Context, typ string, uid, timestamp, limit int64) ([]domain.FeedEvent, error)
 
 	// 拉事件
 	CreatePullEvent(ctx context.Context, event domain.FeedEvent) error
-	FindPullEvents(ctx context.Context, uids []int64, timestamp, limit int64) ([]domain.FeedEvent, error)
+	FindPullEvents(ctx context.Context, uids []int64, timestamp, id, limit int64) ([]domain.FeedEvent, error)
 	FindPullEventsWithTyp(ctx context.Context, typ string, uids []int64, timestamp, limit int64) ([]domain.FeedEvent, error)
 }
 
@@ -49,8 +49,8 @@ func (f *feedEventRepo) CreatePushEvents(ctx context.Context, events []domain.Fe
 	return f.pushDao.CreatePushEvents(ctx, pushEvents)
 }
 
-func (f *feedEventRepo) FindPushEvents(ctx context.Context, uid, timestamp, limit int64) ([]domain.FeedEvent, error) {
-	events, err := f.pushDao.GetPushEvents(ctx, uid, timestamp, limit)
+func (f *feedEventRepo) FindPushEvents(ctx context.Context, uid, timestamp, id, limit int64) ([]domain.FeedEvent, error) {
+	events, err := f.pushDao.GetPushEvents(ctx, uid, timestamp, id, limit)
 	if err != nil {
 		return nil, err
 	}
@@ -79,8 +79,8 @@ func (f *feedEventRepo) CreatePullEvent(ctx context.Context, event domain.FeedEv
 	return f.pullDao.CreatePullEvent(ctx, convertToPullEventDao(event))
 }
 
-func (f *feedEventRepo) FindPullEvents(ctx context.Context, uids []int64, timestamp, limit int64) ([]domain.FeedEvent, error) {
-	events, err := f.pullDao.FindPullEvents(ctx, uids, timestamp, limit)
+func (f *feedEventRepo) FindPullEvents(ctx context.Context, uids []int64, timestamp, id, limit int64) ([]domain.FeedEvent, error) {
+	events, err := f.pullDao.FindPullEvents(ctx, uids, timestamp, id, limit)
 	if err != nil {
 		return nil, err
 	}
@@ -145,11 +145,12 @@ func convertToPushEventDomain(event dao.FeedPushEvent) domain.FeedEvent {
 	var ext map[string]string
 	_ = json.Unmarshal([]byte(event.Content), &ext)
 	return domain.FeedEvent{
-		Id:    event.Id,
-		Uid:   event.Uid,
-		Type:  event.Type,
-		Ctime: time.Unix(event.Ctime, 0),
-		Ext:   ext,
+		Id:     event.Id,
+		Uid:    event.Uid,
+		Type:   event.Type,
+		Ctime:  time.Unix(event.Ctime, 0),
+		Ext:    ext,
+		Source: domain.FeedSourcePush,
 	}
 }
 
@@ -157,10 +158,11 @@ func convertToPullEventDomain(event dao.FeedPullEvent) domain.FeedEvent {
 	var ext map[string]string
 	_ = json.Unmarshal([]byte(event.Content), &ext)
 	return domain.FeedEvent{
-		Id:    event.Id,
-		Uid:   event.Uid,
-		Type:  event.Type,
-		Ctime: time.Unix(event.Ctime, 0),
-		Ext:   ext,
+		Id:     event.Id,
+		Uid:    event.Uid,
+		Type:   event.Type,
+		Ctime:  time.Unix(event.Ctime, 0),
+		Ext:    ext,
+		Source: domain.FeedSourcePull,
 	}
 }
\ No newline at end of file
@@ -2,14 +2,18 @@ package repository
 
 import (
 	"context"
+	"log"
 
 	"github.com/Linxhhh/webook/internal/domain"
 	"github.com/Linxhhh/webook/internal/repository/cache"
 	"github.com/Linxhhh/webook/internal/repository/dao"
 )
 
+var ErrInteractionNotFound = dao.ErrRecordNotFound
+
 type InteractionRepository interface {
 	IncrReadCnt(ctx context.Context, biz string, bizId int64) error
+	BatchIncrReadCnt(ctx context.Context, bizs []string, bizIds []int64) error
 	Like(ctx context.Context, biz string, bizId int64, uid int64) error
 	CancelLike(ctx context.Context, biz string, bizId int64, uid int64) error
 	Collect(ctx context.Context, biz string, bizId int64, uid int64) error
@@ -42,6 +46,21 @@ func (repo *CacheInteractionRepository) IncrReadCnt(ctx context.Context, biz str
 	return repo.cache.IncrReadCnt(ctx, biz, bizId)
 }
 
+/*
+BatchIncrReadCnt 批量增加阅读量：
+数据库已经提交之后，缓存更新失败只记录日志，不返回错误，否则消费者重试整批消息时，数据库的阅读量会重复增加
+*/
+func (repo *CacheInteractionRepository) BatchIncrReadCnt(ctx context.Context, bizs []string, bizIds []int64) error {
+	err := repo.dao.BatchIncrReadCnt(ctx, bizs, bizIds)
+	if err != nil {
+		return err
+	}
+	if err = repo.cache.BatchIncrReadCnt(ctx, bizs, bizIds); err != nil {
+		log.Println("批量增加阅读量缓存失败：err : ", err.Error())
+	}
+	return nil
+}
+
 // -------------------------------------------------------------------------------------------------------------------------
 
 func (repo *CacheInteractionRepository) Like(ctx context.Context, biz string, bizId int64, uid int64) error {
@@ -104,6 +123,7 @@ func (repo *CacheInteractionRepository) Get(ctx context.Context, biz string, biz
 		ReadCnt:    interaction.ReadCnt,
 		LikeCnt:    interaction.LikeCnt,
 		CollectCnt: interaction.CollectCnt,
+		CommentCnt: interaction.CommentCnt,
 	}
 
 	// 回写缓存
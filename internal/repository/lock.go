@@ -0,0 +1,31 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/Linxhhh/webook/internal/repository/dao"
+)
+
+type LockRepository interface {
+	TryLock(ctx context.Context, name, owner string, ttl time.Duration) (bool, error)
+	Unlock(ctx context.Context, name, owner string) error
+}
+
+type GormLockRepository struct {
+	dao dao.LockDAO
+}
+
+func NewLockRepository(dao dao.LockDAO) LockRepository {
+	return &GormLockRepository{
+		dao: dao,
+	}
+}
+
+func (repo *GormLockRepository) TryLock(ctx context.Context, name, owner string, ttl time.Duration) (bool, error) {
+	return repo.dao.TryLock(ctx, name, owner, ttl)
+}
+
+func (repo *GormLockRepository) Unlock(ctx context.Context, name, owner string) error {
+	return repo.dao.Unlock(ctx, name, owner)
+}
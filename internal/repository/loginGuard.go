@@ -0,0 +1,80 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/Linxhhh/webook/internal/domain"
+	"github.com/Linxhhh/webook/internal/repository/cache"
+	"github.com/Linxhhh/webook/internal/repository/dao"
+)
+
+type LoginGuardRepository interface {
+	IncrFailure(ctx context.Context, email, ip string, window time.Duration) (int64, int64, error)
+	ResetFailure(ctx context.Context, email string) error
+	SetDelay(ctx context.Context, email string, delay time.Duration) error
+	GetDelay(ctx context.Context, email string) (time.Duration, error)
+	Lock(ctx context.Context, l domain.LoginLockout) error
+	GetLock(ctx context.Context, email, ip string) (time.Duration, time.Duration, error)
+	UnlockEmail(ctx context.Context, email string) error
+}
+
+type CacheLoginGuardRepository struct {
+	dao   dao.LoginLockoutDAO
+	cache cache.LoginGuardCache
+}
+
+func NewLoginGuardRepository(dao dao.LoginLockoutDAO, cache cache.LoginGuardCache) LoginGuardRepository {
+	return &CacheLoginGuardRepository{
+		dao:   dao,
+		cache: cache,
+	}
+}
+
+func (repo *CacheLoginGuardRepository) IncrFailure(ctx context.Context, email, ip string, window time.Duration) (int64, int64, error) {
+	return repo.cache.IncrFailure(ctx, email, ip, window)
+}
+
+func (repo *CacheLoginGuardRepository) ResetFailure(ctx context.Context, email string) error {
+	return repo.cache.ResetFailure(ctx, email)
+}
+
+func (repo *CacheLoginGuardRepository) SetDelay(ctx context.Context, email string, delay time.Duration) error {
+	return repo.cache.SetDelay(ctx, email, delay)
+}
+
+func (repo *CacheLoginGuardRepository) GetDelay(ctx context.Context, email string) (time.Duration, error) {
+	return repo.cache.GetDelay(ctx, email)
+}
+
+// Lock 先在 Redis 中锁定，再写入审计记录
+func (repo *CacheLoginGuardRepository) Lock(ctx context.Context, l domain.LoginLockout) error {
+	expiration := time.Until(l.LockUntil)
+	var err error
+	if l.TargetType == domain.LockTargetIp {
+		err = repo.cache.LockIp(ctx, l.Target, expiration)
+	} else {
+		err = repo.cache.LockEmail(ctx, l.Target, expiration)
+	}
+	if err != nil {
+		return err
+	}
+	return repo.dao.Insert(ctx, dao.LoginLockout{
+		TargetType: l.TargetType,
+		Target:     l.Target,
+		Ip:         l.Ip,
+		Failures:   l.Failures,
+		LockUntil:  l.LockUntil.UnixMilli(),
+	})
+}
+
+func (repo *CacheLoginGuardRepository) GetLock(ctx context.Context, email, ip string) (time.Duration, time.Duration, error) {
+	return repo.cache.GetLock(ctx, email, ip)
+}
+
+func (repo *CacheLoginGuardRepository) UnlockEmail(ctx context.Context, email string) error {
+	if err := repo.cache.UnlockEmail(ctx, email); err != nil {
+		return err
+	}
+	return repo.dao.MarkUnlocked(ctx, domain.LockTargetEmail, email)
+}
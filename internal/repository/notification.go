@@ -0,0 +1,116 @@
+package repository
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/Linxhhh/webook/internal/domain"
+	"github.com/Linxhhh/webook/internal/repository/cache"
+	"github.com/Linxhhh/webook/internal/repository/dao"
+)
+
+type NotificationRepository interface {
+	Create(ctx context.Context, n domain.Notification) (int64, error)
+	GetList(ctx context.Context, uid int64, lastId int64, limit int) ([]domain.Notification, error)
+	CountUnread(ctx context.Context, uid int64) (int64, error)
+	MarkRead(ctx context.Context, uid int64, id int64) error
+	MarkAllRead(ctx context.Context, uid int64) error
+}
+
+type CacheNotificationRepository struct {
+	dao   dao.NotificationDAO
+	cache cache.NotificationCache
+}
+
+func NewNotificationRepository(dao dao.NotificationDAO, cache cache.NotificationCache) NotificationRepository {
+	return &CacheNotificationRepository{
+		dao:   dao,
+		cache: cache,
+	}
+}
+
+func (repo *CacheNotificationRepository) Create(ctx context.Context, n domain.Notification) (int64, error) {
+	id, err := repo.dao.Insert(ctx, dao.Notification{
+		Uid:     n.Uid,
+		Status:  dao.NotificationUnread,
+		Type:    string(n.Type),
+		ActorId: n.ActorId,
+		Biz:     n.Biz,
+		BizId:   n.BizId,
+		Content: n.Content,
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	// 未读数量 +1，通知已经写入数据库，缓存失败只记录日志，否则消费者重试时会重复插入通知
+	if err = repo.cache.IncrUnreadCnt(ctx, n.Uid, 1); err != nil {
+		log.Println("增加未读通知数量缓存失败：err : ", err.Error())
+	}
+	return id, nil
+}
+
+func (repo *CacheNotificationRepository) GetList(ctx context.Context, uid int64, lastId int64, limit int) ([]domain.Notification, error) {
+	list, err := repo.dao.GetList(ctx, uid, lastId, limit)
+	if err != nil {
+		return nil, err
+	}
+
+	// 类型转换
+	res := make([]domain.Notification, 0, len(list))
+	for _, n := range list {
+		res = append(res, domain.Notification{
+			Id:      n.Id,
+			Uid:     n.Uid,
+			Type:    domain.NotificationType(n.Type),
+			ActorId: n.ActorId,
+			Biz:     n.Biz,
+			BizId:   n.BizId,
+			Content: n.Content,
+			IsRead:  n.Status == dao.NotificationRead,
+			Ctime:   time.UnixMilli(n.Ctime),
+		})
+	}
+	return res, nil
+}
+
+func (repo *CacheNotificationRepository) CountUnread(ctx context.Context, uid int64) (int64, error) {
+
+	// 查询缓存
+	cnt, err := repo.cache.GetUnreadCnt(ctx, uid)
+	if err == nil {
+		return cnt, err
+	}
+
+	// 查询数据库
+	cnt, err = repo.dao.CountUnread(ctx, uid)
+	if err != nil {
+		return 0, err
+	}
+
+	// 回写缓存
+	go func() {
+		repo.cache.SetUnreadCnt(ctx, uid, cnt)
+	}()
+
+	return cnt, err
+}
+
+func (repo *CacheNotificationRepository) MarkRead(ctx context.Context, uid int64, id int64) error {
+	affected, err := repo.dao.MarkRead(ctx, uid, id)
+	if err != nil || affected == 0 {
+		return err
+	}
+
+	// 未读数量 -1
+	return repo.cache.IncrUnreadCnt(ctx, uid, -1)
+}
+
+func (repo *CacheNotificationRepository) MarkAllRead(ctx context.Context, uid int64) error {
+	err := repo.dao.MarkAllRead(ctx, uid)
+	if err != nil {
+		return err
+	}
+	return repo.cache.SetUnreadCnt(ctx, uid, 0)
+}
@@ -0,0 +1,32 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/Linxhhh/webook/internal/repository/cache"
+)
+
+var ErrInvalidOAuth2State = cache.ErrInvalidOAuth2State
+
+type OAuth2StateRepository interface {
+	Store(ctx context.Context, provider, state string, uid int64) error
+	Verify(ctx context.Context, provider, state string) (int64, error)
+}
+
+type CacheOAuth2StateRepository struct {
+	cache cache.OAuth2StateCache
+}
+
+func NewOAuth2StateRepository(cache cache.OAuth2StateCache) OAuth2StateRepository {
+	return &CacheOAuth2StateRepository{
+		cache: cache,
+	}
+}
+
+func (repo *CacheOAuth2StateRepository) Store(ctx context.Context, provider, state string, uid int64) error {
+	return repo.cache.SetState(ctx, provider, state, uid)
+}
+
+func (repo *CacheOAuth2StateRepository) Verify(ctx context.Context, provider, state string) (int64, error) {
+	return repo.cache.VerifyState(ctx, provider, state)
+}
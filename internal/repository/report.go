@@ -0,0 +1,86 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/Linxhhh/webook/internal/domain"
+	"github.com/Linxhhh/webook/internal/repository/dao"
+)
+
+var ErrDuplicateReport = dao.ErrDuplicateReport
+
+type ReportRepository interface {
+	Create(ctx context.Context, r domain.Report) (int64, error)
+	CountPending(ctx context.Context, biz string, bizId int64) (int64, error)
+	GetPendingTargets(ctx context.Context, offset, limit int) ([]domain.ReportTarget, error)
+	GetPendingByTarget(ctx context.Context, biz string, bizId int64, limit int) ([]domain.Report, error)
+	Resolve(ctx context.Context, biz string, bizId int64, status domain.ReportStatus, operator int64) error
+}
+
+type GormReportRepository struct {
+	dao dao.ReportDAO
+}
+
+func NewReportRepository(dao dao.ReportDAO) ReportRepository {
+	return &GormReportRepository{
+		dao: dao,
+	}
+}
+
+// Create 创建举报，返回该资源待处理的举报数量
+func (repo *GormReportRepository) Create(ctx context.Context, r domain.Report) (int64, error) {
+	return repo.dao.Insert(ctx, dao.Report{
+		Uid:    r.Uid,
+		Biz:    r.Biz,
+		BizId:  r.BizId,
+		Reason: uint8(r.Reason),
+		Detail: r.Detail,
+	})
+}
+
+func (repo *GormReportRepository) CountPending(ctx context.Context, biz string, bizId int64) (int64, error) {
+	return repo.dao.CountPending(ctx, biz, bizId)
+}
+
+func (repo *GormReportRepository) GetPendingTargets(ctx context.Context, offset, limit int) ([]domain.ReportTarget, error) {
+	targets, err := repo.dao.GetPendingTargets(ctx, offset, limit)
+	if err != nil {
+		return nil, err
+	}
+	res := make([]domain.ReportTarget, 0, len(targets))
+	for _, t := range targets {
+		res = append(res, domain.ReportTarget{
+			Biz:   t.Biz,
+			BizId: t.BizId,
+			Count: t.Cnt,
+			Utime: time.UnixMilli(t.Utime),
+		})
+	}
+	return res, nil
+}
+
+func (repo *GormReportRepository) GetPendingByTarget(ctx context.Context, biz string, bizId int64, limit int) ([]domain.Report, error) {
+	reports, err := repo.dao.GetPendingByTarget(ctx, biz, bizId, limit)
+	if err != nil {
+		return nil, err
+	}
+	res := make([]domain.Report, 0, len(reports))
+	for _, r := range reports {
+		res = append(res, domain.Report{
+			Id:     r.Id,
+			Uid:    r.Uid,
+			Biz:    r.Biz,
+			BizId:  r.BizId,
+			Reason: domain.ReportReason(r.Reason),
+			Detail: r.Detail,
+			Status: domain.ReportStatus(r.Status),
+			Ctime:  time.UnixMilli(r.Ctime),
+		})
+	}
+	return res, nil
+}
+
+func (repo *GormReportRepository) Resolve(ctx context.Context, biz string, bizId int64, status domain.ReportStatus, operator int64) error {
+	return repo.dao.Resolve(ctx, biz, bizId, uint8(status), operator)
+}
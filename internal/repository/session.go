@@ -0,0 +1,100 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/Linxhhh/webook/internal/domain"
+	"github.com/Linxhhh/webook/internal/repository/cache"
+	"github.com/Linxhhh/webook/internal/repository/dao"
+)
+
+var ErrSessionNotFound = dao.ErrRecordNotFound
+
+type SessionRepository interface {
+	Create(ctx context.Context, s domain.Session) (int64, error)
+	GetActiveList(ctx context.Context, uid int64) ([]domain.Session, error)
+	GetById(ctx context.Context, uid int64, id int64) (domain.Session, error)
+	Revoke(ctx context.Context, ssid string, expiration time.Duration) error
+	IsRevoked(ctx context.Context, ssid string) (bool, error)
+	Touch(ctx context.Context, ssid string, interval time.Duration) error
+}
+
+type CacheSessionRepository struct {
+	dao   dao.SessionDAO
+	cache cache.SessionCache
+}
+
+func NewSessionRepository(dao dao.SessionDAO, cache cache.SessionCache) SessionRepository {
+	return &CacheSessionRepository{
+		dao:   dao,
+		cache: cache,
+	}
+}
+
+func (repo *CacheSessionRepository) Create(ctx context.Context, s domain.Session) (int64, error) {
+	return repo.dao.Insert(ctx, dao.UserSession{
+		Uid:       s.Uid,
+		Status:    dao.SessionActive,
+		Ssid:      s.Ssid,
+		Device:    s.Device,
+		UserAgent: s.UserAgent,
+		Ip:        s.Ip,
+		ExpireAt:  s.ExpireAt.UnixMilli(),
+	})
+}
+
+func (repo *CacheSessionRepository) GetActiveList(ctx context.Context, uid int64) ([]domain.Session, error) {
+	list, err := repo.dao.GetActiveList(ctx, uid)
+	if err != nil {
+		return nil, err
+	}
+	res := make([]domain.Session, 0, len(list))
+	for _, s := range list {
+		res = append(res, convertToSessionDomain(s))
+	}
+	return res, nil
+}
+
+func (repo *CacheSessionRepository) GetById(ctx context.Context, uid int64, id int64) (domain.Session, error) {
+	s, err := repo.dao.GetById(ctx, uid, id)
+	if err != nil {
+		return domain.Session{}, err
+	}
+	return convertToSessionDomain(s), nil
+}
+
+// Revoke 先标记数据库中的会话，再把会话 ID 加入 Redis 黑名单
+func (repo *CacheSessionRepository) Revoke(ctx context.Context, ssid string, expiration time.Duration) error {
+	if err := repo.dao.Revoke(ctx, ssid); err != nil {
+		return err
+	}
+	return repo.cache.Revoke(ctx, ssid, expiration)
+}
+
+func (repo *CacheSessionRepository) IsRevoked(ctx context.Context, ssid string) (bool, error) {
+	return repo.cache.IsRevoked(ctx, ssid)
+}
+
+// Touch 更新会话的最近活跃时间，interval 内只回写一次数据库
+func (repo *CacheSessionRepository) Touch(ctx context.Context, ssid string, interval time.Duration) error {
+	ok, err := repo.cache.MarkSeen(ctx, ssid, interval)
+	if err != nil || !ok {
+		return err
+	}
+	return repo.dao.UpdateLastSeen(ctx, ssid, time.Now().UnixMilli())
+}
+
+func convertToSessionDomain(s dao.UserSession) domain.Session {
+	return domain.Session{
+		Id:        s.Id,
+		Uid:       s.Uid,
+		Ssid:      s.Ssid,
+		Device:    s.Device,
+		UserAgent: s.UserAgent,
+		Ip:        s.Ip,
+		LastSeen:  time.UnixMilli(s.LastSeen),
+		ExpireAt:  time.UnixMilli(s.ExpireAt),
+		Ctime:     time.UnixMilli(s.Ctime),
+	}
+}
@@ -13,6 +13,7 @@ import (
 var (
 	ErrDuplicateEmailorPhone = dao.ErrDuplicateEmailorPhone
 	ErrUserNotFound          = dao.ErrRecordNotFound
+	ErrDuplicateOAuth2       = dao.ErrDuplicateOAuth2
 )
 
 type UserRepository interface {
@@ -22,6 +23,17 @@ type UserRepository interface {
 	SearchByEmail(ctx context.Context, email string) (domain.User, error)
 	SearchByPhone(ctx context.Context, phone string) (int64, error)
 	Update(ctx context.Context, u domain.User) error
+	SearchByOAuth2(ctx context.Context, provider, openId string) (int64, error)
+	CreateByOAuth2(ctx context.Context, info domain.OAuth2Info) (int64, error)
+	UpdatePhone(ctx context.Context, uid int64, phone string) error
+	UpdateEmail(ctx context.Context, uid int64, email string) error
+	GetOAuth2Bindings(ctx context.Context, uid int64) ([]domain.OAuth2Info, error)
+	BindOAuth2(ctx context.Context, uid int64, info domain.OAuth2Info) error
+	UnbindOAuth2(ctx context.Context, uid int64, provider string) error
+	SearchPassword(ctx context.Context, uid int64) (string, error)
+	UpdatePassword(ctx context.Context, uid int64, password string) error
+	UpdateRole(ctx context.Context, uid int64, role domain.Role) error
+	UpdateStatus(ctx context.Context, uid int64, status domain.UserStatus) error
 }
 
 type CacheUserRepository struct {
@@ -76,6 +88,8 @@ func (repo *CacheUserRepository) SearchById(ctx context.Context, id int64) (doma
 		NickName:     user.NickName,
 		Birthday:     time.UnixMilli(user.Birthday),
 		Introduction: user.Introduction,
+		Role:         domain.Role(user.Role),
+		Status:       domain.UserStatus(user.Status),
 	}
 
 	// 回写缓存
@@ -95,6 +109,8 @@ func (repo *CacheUserRepository) SearchByEmail(ctx context.Context, email string
 		Id:       user.Id,
 		Email:    user.Email.String,
 		Password: user.Password,
+		Role:     domain.Role(user.Role),
+		Status:   domain.UserStatus(user.Status),
 	}, err
 }
 
@@ -121,3 +137,106 @@ func (repo *CacheUserRepository) Update(ctx context.Context, u domain.User) erro
 	}
 	return err
 }
+
+func (repo *CacheUserRepository) SearchByOAuth2(ctx context.Context, provider, openId string) (int64, error) {
+	user, err := repo.dao.SearchByOAuth2(ctx, provider, openId)
+	if err == dao.ErrRecordNotFound {
+		return -1, ErrUserNotFound
+	}
+	return user.Id, err
+}
+
+func (repo *CacheUserRepository) CreateByOAuth2(ctx context.Context, info domain.OAuth2Info) (int64, error) {
+	return repo.dao.InsertWithOAuth2(ctx, dao.User{
+		NickName: info.NickName,
+	}, dao.UserOAuthBinding{
+		Provider: info.Provider,
+		OpenId:   info.OpenId,
+		UnionId:  info.UnionId,
+	})
+}
+
+func (repo *CacheUserRepository) UpdatePhone(ctx context.Context, uid int64, phone string) error {
+	err := repo.dao.UpdatePhone(ctx, uid, phone)
+	if err == nil {
+		// 清除缓存
+		repo.cache.Del(ctx, uid)
+	}
+	return err
+}
+
+func (repo *CacheUserRepository) UpdateEmail(ctx context.Context, uid int64, email string) error {
+	err := repo.dao.UpdateEmail(ctx, uid, email)
+	if err == nil {
+		// 清除缓存
+		repo.cache.Del(ctx, uid)
+	}
+	return err
+}
+
+func (repo *CacheUserRepository) GetOAuth2Bindings(ctx context.Context, uid int64) ([]domain.OAuth2Info, error) {
+	bindings, err := repo.dao.GetOAuth2Bindings(ctx, uid)
+	if err != nil {
+		return nil, err
+	}
+	res := make([]domain.OAuth2Info, 0, len(bindings))
+	for _, b := range bindings {
+		res = append(res, domain.OAuth2Info{
+			Provider: b.Provider,
+			OpenId:   b.OpenId,
+			UnionId:  b.UnionId,
+		})
+	}
+	return res, nil
+}
+
+func (repo *CacheUserRepository) BindOAuth2(ctx context.Context, uid int64, info domain.OAuth2Info) error {
+	return repo.dao.InsertOAuth2Binding(ctx, dao.UserOAuthBinding{
+		Uid:      uid,
+		Provider: info.Provider,
+		OpenId:   info.OpenId,
+		UnionId:  info.UnionId,
+	})
+}
+
+func (repo *CacheUserRepository) UnbindOAuth2(ctx context.Context, uid int64, provider string) error {
+	cnt, err := repo.dao.DeleteOAuth2Binding(ctx, uid, provider)
+	if err == nil && cnt == 0 {
+		return ErrUserNotFound
+	}
+	return err
+}
+
+// SearchPassword 获取加密后的密码，不经过缓存
+func (repo *CacheUserRepository) SearchPassword(ctx context.Context, uid int64) (string, error) {
+	user, err := repo.dao.SearchById(ctx, uid)
+	if err == dao.ErrRecordNotFound {
+		return "", ErrUserNotFound
+	}
+	return user.Password, err
+}
+
+func (repo *CacheUserRepository) UpdatePassword(ctx context.Context, uid int64, password string) error {
+	return repo.dao.UpdatePassword(ctx, uid, password)
+}
+
+func (repo *CacheUserRepository) UpdateRole(ctx context.Context, uid int64, role domain.Role) error {
+	cnt, err := repo.dao.UpdateRole(ctx, uid, uint8(role))
+	return repo.afterUpdate(ctx, uid, cnt, err)
+}
+
+func (repo *CacheUserRepository) UpdateStatus(ctx context.Context, uid int64, status domain.UserStatus) error {
+	cnt, err := repo.dao.UpdateStatus(ctx, uid, uint8(status))
+	return repo.afterUpdate(ctx, uid, cnt, err)
+}
+
+// afterUpdate 用户不存在时返回 ErrUserNotFound，更新成功后清除缓存
+func (repo *CacheUserRepository) afterUpdate(ctx context.Context, uid int64, cnt int64, err error) error {
+	if err != nil {
+		return err
+	}
+	if cnt == 0 {
+		return ErrUserNotFound
+	}
+	return repo.cache.Del(ctx, uid)
+}
@@ -0,0 +1,57 @@
+package service
+
+import (
+	"context"
+
+	"github.com/Linxhhh/webook/internal/repository"
+)
+
+var (
+	ErrMergeSelf = repository.ErrMergeSelf
+)
+
+/*
+AccountService 账号合并服务：
+同一个人使用邮箱、手机号码分别注册了账号时，把其中一个账号合并到当前登录的账号
+*/
+type AccountService struct {
+	repo     repository.AccountRepository
+	userRepo repository.UserRepository
+	sessSvc  *SessionService
+}
+
+func NewAccountService(repo repository.AccountRepository, userRepo repository.UserRepository, sessSvc *SessionService) *AccountService {
+	return &AccountService{
+		repo:     repo,
+		userRepo: userRepo,
+		sessSvc:  sessSvc,
+	}
+}
+
+// FindByPhone 通过手机号码查找需要合并的账号
+func (svc *AccountService) FindByPhone(ctx context.Context, phone string) (int64, error) {
+	return svc.userRepo.SearchByPhone(ctx, phone)
+}
+
+// FindByEmail 通过邮箱查找需要合并的账号
+func (svc *AccountService) FindByEmail(ctx context.Context, email string) (int64, error) {
+	u, err := svc.userRepo.SearchByEmail(ctx, email)
+	return u.Id, err
+}
+
+/*
+Merge 把账号 src 合并到账号 dst：
+调用方需要先校验用户同时拥有两个账号，合并之后 src 的所有会话失效
+*/
+func (svc *AccountService) Merge(ctx context.Context, dst, src int64) error {
+	if dst == src {
+		return ErrMergeSelf
+	}
+
+	if err := svc.repo.Merge(ctx, dst, src); err != nil {
+		return err
+	}
+
+	// 会话记录不迁移，仍然属于 src，让这些设备下线
+	return svc.sessSvc.RevokeAll(ctx, src, "")
+}
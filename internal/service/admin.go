@@ -0,0 +1,195 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/Linxhhh/webook/internal/domain"
+	"github.com/Linxhhh/webook/internal/repository"
+)
+
+var (
+	ErrPermissionDenied = errors.New("权限不足")
+	ErrReportNotFound   = errors.New("没有待处理的举报")
+)
+
+/*
+AdminService 管理后台：
+封禁用户、调整角色、强制下架帖子和处理举报，操作者不能处理自己以及角色不低于自己的用户
+*/
+type AdminService struct {
+	userRepo    repository.UserRepository
+	artRepo     repository.ArticleRepository
+	commentRepo repository.CommentRepository
+	reportRepo  repository.ReportRepository
+	sessSvc     *SessionService
+}
+
+func NewAdminService(userRepo repository.UserRepository, artRepo repository.ArticleRepository, commentRepo repository.CommentRepository,
+	reportRepo repository.ReportRepository, sessSvc *SessionService) *AdminService {
+	return &AdminService{
+		userRepo:    userRepo,
+		artRepo:     artRepo,
+		commentRepo: commentRepo,
+		reportRepo:  reportRepo,
+		sessSvc:     sessSvc,
+	}
+}
+
+// Ban 封禁用户，并让该用户所有设备下线
+func (svc *AdminService) Ban(ctx context.Context, operator domain.Role, operatorId, uid int64) error {
+	if err := svc.checkTarget(ctx, operator, operatorId, uid); err != nil {
+		return err
+	}
+	if err := svc.userRepo.UpdateStatus(ctx, uid, domain.UserStatusBanned); err != nil {
+		return err
+	}
+	return svc.sessSvc.RevokeAll(ctx, uid, "")
+}
+
+/*
+Bootstrap 启动时把配置中的用户设置为管理员，用于创建第一个管理员：
+已经是管理员的用户跳过，设置之后该用户所有设备下线，重新登录后令牌中携带管理员角色
+*/
+func (svc *AdminService) Bootstrap(ctx context.Context, uids []int64) error {
+	for _, uid := range uids {
+		user, err := svc.userRepo.SearchById(ctx, uid)
+		if err == repository.ErrUserNotFound {
+			return fmt.Errorf("初始化管理员失败，用户 %d 不存在", uid)
+		}
+		if err != nil {
+			return err
+		}
+		if user.Role == domain.RoleAdmin {
+			continue
+		}
+		if err = svc.userRepo.UpdateRole(ctx, uid, domain.RoleAdmin); err != nil {
+			return err
+		}
+		if err = svc.sessSvc.RevokeAll(ctx, uid, ""); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Unban 解除封禁
+func (svc *AdminService) Unban(ctx context.Context, operator domain.Role, operatorId, uid int64) error {
+	if err := svc.checkTarget(ctx, operator, operatorId, uid); err != nil {
+		return err
+	}
+	return svc.userRepo.UpdateStatus(ctx, uid, domain.UserStatusNormal)
+}
+
+/*
+SetRole 调整用户角色：
+不能授予高于自己的角色，调整后该用户所有设备下线，重新登录后令牌中携带新的角色
+*/
+func (svc *AdminService) SetRole(ctx context.Context, operator domain.Role, operatorId, uid int64, role domain.Role) error {
+	if role > operator {
+		return ErrPermissionDenied
+	}
+	if err := svc.checkTarget(ctx, operator, operatorId, uid); err != nil {
+		return err
+	}
+	if err := svc.userRepo.UpdateRole(ctx, uid, role); err != nil {
+		return err
+	}
+	return svc.sessSvc.RevokeAll(ctx, uid, "")
+}
+
+/*
+Unpublish 强制下架帖子，制作库和线上库的帖子都变为私有：
+SyncStatus 不会修改因为举报被隐藏的帖子，这里先把隐藏的帖子变为私有
+*/
+func (svc *AdminService) Unpublish(ctx context.Context, aid int64) error {
+	art, err := svc.artRepo.GetPubById(ctx, aid)
+	if err == repository.ErrArticleNotFound {
+		return ErrArticleNotFound
+	}
+	if err != nil {
+		return err
+	}
+	if art.Status == domain.ArticleStatusHidden {
+		if _, err = svc.artRepo.UpdatePubStatus(ctx, aid, domain.ArticleStatusHidden, domain.ArticleStatusPrivate); err != nil {
+			return err
+		}
+	}
+	return svc.artRepo.SyncStatus(ctx, art.AuthorId, aid, domain.ArticleStatusPrivate)
+}
+
+// ReportList 按照被举报的资源聚合待处理的举报，举报数量多的在前
+func (svc *AdminService) ReportList(ctx context.Context, page, pageSize int) ([]domain.ReportTarget, error) {
+	return svc.reportRepo.GetPendingTargets(ctx, (page-1)*pageSize, pageSize)
+}
+
+// ReportDetail 获取资源待处理的举报明细
+func (svc *AdminService) ReportDetail(ctx context.Context, biz string, bizId int64) ([]domain.Report, error) {
+	const limit = 100
+	return svc.reportRepo.GetPendingByTarget(ctx, biz, bizId, limit)
+}
+
+/*
+ResolveReport 处理资源所有待处理的举报：
+举报成立时下架帖子、删除评论或者封禁用户；
+驳回时，如果帖子因为举报被自动隐藏，则恢复为已发表
+*/
+func (svc *AdminService) ResolveReport(ctx context.Context, operator domain.Role, operatorId int64, biz string, bizId int64, accept bool) error {
+	cnt, err := svc.reportRepo.CountPending(ctx, biz, bizId)
+	if err != nil {
+		return err
+	}
+	if cnt == 0 {
+		return ErrReportNotFound
+	}
+
+	status := domain.ReportStatusDismissed
+	if accept {
+		status = domain.ReportStatusAccepted
+		err = svc.punish(ctx, operator, operatorId, biz, bizId)
+	} else if biz == domain.ReportBizArticle {
+		_, err = svc.artRepo.UpdatePubStatus(ctx, bizId, domain.ArticleStatusHidden, domain.ArticleStatusPublished)
+	}
+	if err != nil {
+		return err
+	}
+	return svc.reportRepo.Resolve(ctx, biz, bizId, status, operatorId)
+}
+
+// punish 举报成立之后处理被举报的资源
+func (svc *AdminService) punish(ctx context.Context, operator domain.Role, operatorId int64, biz string, bizId int64) error {
+	switch biz {
+	case domain.ReportBizArticle:
+		return svc.Unpublish(ctx, bizId)
+	case domain.ReportBizComment:
+		c, err := svc.commentRepo.GetById(ctx, bizId)
+		if errors.Is(err, repository.ErrCommentNotFound) {
+			// 评论已经被作者删除
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		return svc.commentRepo.Delete(ctx, c.Uid, c.Id)
+	case domain.ReportBizUser:
+		return svc.Ban(ctx, operator, operatorId, bizId)
+	default:
+		return nil
+	}
+}
+
+// checkTarget 操作者不能处理自己，也不能处理角色不低于自己的用户
+func (svc *AdminService) checkTarget(ctx context.Context, operator domain.Role, operatorId, uid int64) error {
+	if operatorId == uid {
+		return ErrPermissionDenied
+	}
+	u, err := svc.userRepo.SearchById(ctx, uid)
+	if err != nil {
+		return err
+	}
+	if u.Role >= operator {
+		return ErrPermissionDenied
+	}
+	return nil
+}
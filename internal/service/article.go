@@ -7,19 +7,39 @@ import (
 
 	"github.com/Linxhhh/webook/internal/domain"
 	"github.com/Linxhhh/webook/internal/repository"
+	"github.com/Linxhhh/webook/internal/service/moderation"
+	"github.com/Linxhhh/webook/pkg/diff"
+	"github.com/Linxhhh/webook/pkg/markdown"
 )
 
-var ErrIncorrectArticleorAuthor = repository.ErrIncorrectArticleorAuthor
+var (
+	ErrIncorrectArticleorAuthor = repository.ErrIncorrectArticleorAuthor
+	ErrArticleNotFound          = errors.New("帖子不存在")
+	ErrArticleNotPending        = repository.ErrArticleNotPending
+	ErrRevisionNotFound         = errors.New("版本不存在")
+	ErrRevisionMismatch         = errors.New("只能对比同一个帖子的版本")
+	ErrArticleNotScheduled      = repository.ErrArticleNotScheduled
+	ErrInvalidPublishTime       = errors.New("发表时间不合法")
+	ErrRestoreNotAllowed        = errors.New("审核中或等待定时发表的帖子不能恢复版本")
+)
+
+// maxScheduleAhead 定时发表最多可以提前设置的时间
+const maxScheduleAhead = 30 * 24 * time.Hour
 
 type ArticleService struct {
-	repo     repository.ArticleRepository
-	userRepo repository.UserRepository
+	repo      repository.ArticleRepository
+	revRepo   repository.ArticleRevisionRepository
+	userRepo  repository.UserRepository
+	moderator moderation.Moderator
 }
 
-func NewArticleService(repo repository.ArticleRepository, userRepo repository.UserRepository) *ArticleService {
+func NewArticleService(repo repository.ArticleRepository, revRepo repository.ArticleRevisionRepository,
+	userRepo repository.UserRepository, moderator moderation.Moderator) *ArticleService {
 	return &ArticleService{
-		repo:     repo,
-		userRepo: userRepo,
+		repo:      repo,
+		revRepo:   revRepo,
+		userRepo:  userRepo,
+		moderator: moderator,
 	}
 }
 
@@ -31,9 +51,129 @@ func (as *ArticleService) Save(ctx context.Context, art domain.Article) (int64,
 	return as.repo.Insert(ctx, art)
 }
 
+/*
+Publish 提交发表：
+只保存到制作库并进入审核状态，由审核消费者调用 Review 之后才同步到线上库；
+已发表的帖子重新提交时，线上库保持旧版本直到新版本审核通过
+*/
 func (as *ArticleService) Publish(ctx context.Context, art domain.Article) (int64, error) {
+	art.Status = domain.ArticleStatusPendingReview
+	if art.Id > 0 {
+		return art.Id, as.repo.Update(ctx, art)
+	}
+	return as.repo.Insert(ctx, art)
+}
+
+/*
+Schedule 提交定时发表：
+和 Publish 一样先进入审核，审核通过之后如果还没有到发表时间，则等待定时任务发表
+*/
+func (as *ArticleService) Schedule(ctx context.Context, art domain.Article, publishAt time.Time) (int64, error) {
+	if err := checkPublishTime(publishAt); err != nil {
+		return 0, err
+	}
+	art.Status = domain.ArticleStatusPendingReview
+	art.PublishAt = publishAt
+	if art.Id > 0 {
+		return art.Id, as.repo.Update(ctx, art)
+	}
+	return as.repo.Insert(ctx, art)
+}
+
+// CancelSchedule 取消定时发表，帖子变为未发表，线上库保持不变
+func (as *ArticleService) CancelSchedule(ctx context.Context, uid, aid int64) error {
+	return as.repo.CancelSchedule(ctx, uid, aid)
+}
+
+// Reschedule 修改定时发表的时间
+func (as *ArticleService) Reschedule(ctx context.Context, uid, aid int64, publishAt time.Time) error {
+	if err := checkPublishTime(publishAt); err != nil {
+		return err
+	}
+	return as.repo.Reschedule(ctx, uid, aid, publishAt)
+}
+
+// checkPublishTime 发表时间必须晚于当前时间，并且不能超过 maxScheduleAhead
+func checkPublishTime(t time.Time) error {
+	now := time.Now()
+	if !t.After(now) || t.After(now.Add(maxScheduleAhead)) {
+		return ErrInvalidPublishTime
+	}
+	return nil
+}
+
+/*
+Review 审核帖子：
+帖子不在审核中（已经审核过，或者作者又修改了）时返回 ErrArticleNotPending，保证重复消费是幂等的；
+返回的帖子状态为审核结果：已发表（同步到线上库）、等待定时发表或者审核不通过
+*/
+func (as *ArticleService) Review(ctx context.Context, aid int64) (domain.Article, error) {
+	art, err := as.repo.GetPending(ctx, aid)
+	if err != nil {
+		return domain.Article{}, err
+	}
+
+	result, err := as.moderator.Moderate(ctx, art.Title+"\n"+art.Content)
+	if err != nil {
+		return domain.Article{}, err
+	}
+	if !result.Pass {
+		art.Status = domain.ArticleStatusRejected
+		return art, as.repo.Reject(ctx, art, result.Reason)
+	}
+
+	// 还没有到定时发表的时间
 	art.Status = domain.ArticleStatusPublished
-	return as.repo.Sync(ctx, art)
+	if art.PublishAt.After(time.Now()) {
+		art.Status = domain.ArticleStatusScheduled
+		return art, as.repo.Approve(ctx, art)
+	}
+
+	// 同步到线上库之前渲染
+	if err = render(&art); err != nil {
+		return domain.Article{}, err
+	}
+	return art, as.repo.Approve(ctx, art)
+}
+
+// Reviewed 获取帖子在制作库中的当前版本，重复消费审核事件时用于判断是否需要补发 feed 流事件
+func (as *ArticleService) Reviewed(ctx context.Context, aid int64) (domain.Article, error) {
+	art, err := as.repo.GetById(ctx, aid)
+	if errors.Is(err, repository.ErrArticleNotFound) {
+		return domain.Article{}, ErrArticleNotFound
+	}
+	return art, err
+}
+
+// DueScheduled 获取已经到达发表时间的定时发表帖子，after 为上一批最后一个帖子，第一批传零值
+func (as *ArticleService) DueScheduled(ctx context.Context, after domain.Article, limit int) ([]domain.Article, error) {
+	return as.repo.GetDueScheduled(ctx, time.Now(), after, limit)
+}
+
+/*
+PublishScheduled 发表到期的定时发表帖子：
+作者在读取之后取消或修改了帖子时返回 ErrArticleNotScheduled
+*/
+func (as *ArticleService) PublishScheduled(ctx context.Context, art domain.Article) error {
+	if err := render(&art); err != nil {
+		return err
+	}
+	return as.repo.PublishScheduled(ctx, art)
+}
+
+/*
+render 把帖子内容渲染为过滤之后的 HTML，同时生成纯文本摘要和目录，
+和帖子一起存储到线上库，读取时不需要再渲染
+*/
+func render(art *domain.Article) error {
+	res, err := markdown.Render(art.Content)
+	if err != nil {
+		return err
+	}
+	art.Html = res.HTML
+	art.Abstract = res.Abstract
+	art.Toc = res.TOC
+	return nil
 }
 
 func (as *ArticleService) Withdraw(ctx context.Context, uid int64, aid int64) error {
@@ -58,12 +198,99 @@ func (as *ArticleService) Detail(ctx context.Context, uid, aid int64) (domain.Ar
 	return art, err
 }
 
+// Revisions 获取帖子的版本列表，只有作者可以查看
+func (as *ArticleService) Revisions(ctx context.Context, uid, aid int64, page, pageSize int) ([]domain.ArticleRevision, error) {
+	if _, err := as.Detail(ctx, uid, aid); err != nil {
+		return nil, err
+	}
+	return as.revRepo.GetList(ctx, aid, (page-1)*pageSize, pageSize)
+}
+
+// Revision 获取指定的版本，只有作者可以查看
+func (as *ArticleService) Revision(ctx context.Context, uid, rid int64) (domain.ArticleRevision, error) {
+	rev, err := as.revRepo.GetById(ctx, rid)
+	if errors.Is(err, repository.ErrRevisionNotFound) {
+		return domain.ArticleRevision{}, ErrRevisionNotFound
+	}
+	if err != nil {
+		return domain.ArticleRevision{}, err
+	}
+	if rev.AuthorId != uid {
+		return domain.ArticleRevision{}, ErrIncorrectArticleorAuthor
+	}
+	return rev, nil
+}
+
+// DiffRevisions 按行对比同一个帖子的两个版本，Lines 为从 from 变为 to 的编辑序列
+func (as *ArticleService) DiffRevisions(ctx context.Context, uid, fromId, toId int64) (domain.ArticleRevisionDiff, error) {
+	from, err := as.Revision(ctx, uid, fromId)
+	if err != nil {
+		return domain.ArticleRevisionDiff{}, err
+	}
+	to, err := as.Revision(ctx, uid, toId)
+	if err != nil {
+		return domain.ArticleRevisionDiff{}, err
+	}
+	if from.ArticleId != to.ArticleId {
+		return domain.ArticleRevisionDiff{}, ErrRevisionMismatch
+	}
+
+	lines := diff.Lines(from.Content, to.Content)
+	from.Content, to.Content = "", ""
+	return domain.ArticleRevisionDiff{
+		From:      from,
+		To:        to,
+		TitleDiff: from.Title != to.Title,
+		Lines:     lines,
+	}, nil
+}
+
+/*
+Restore 恢复历史版本：
+使用历史版本的标题和内容保存为帖子的新草稿（同时记录一个新的版本），线上库不受影响，需要重新提交发表；
+保存草稿会清除审核状态和发表时间，所以审核中或等待定时发表的帖子不能恢复，需要先等待审核结果或取消定时发表
+*/
+func (as *ArticleService) Restore(ctx context.Context, uid, rid int64) (int64, error) {
+	rev, err := as.Revision(ctx, uid, rid)
+	if err != nil {
+		return 0, err
+	}
+	art, err := as.Detail(ctx, uid, rev.ArticleId)
+	if err != nil {
+		return 0, err
+	}
+	if art.Status == domain.ArticleStatusPendingReview || art.Status == domain.ArticleStatusScheduled {
+		return 0, ErrRestoreNotAllowed
+	}
+	return as.Save(ctx, domain.Article{
+		Id:       rev.ArticleId,
+		Title:    rev.Title,
+		Content:  rev.Content,
+		AuthorId: uid,
+	})
+}
+
 func (as *ArticleService) PubDetail(ctx context.Context, aid int64) (domain.Article, error) {
 	art, err := as.repo.GetPubById(ctx, aid)
+	if errors.Is(err, repository.ErrArticleNotFound) {
+		return domain.Article{}, ErrArticleNotFound
+	}
 	if err != nil {
 		return domain.Article{}, err
 	}
 
+	// 已撤销或被下架的帖子不可见
+	if art.Status != domain.ArticleStatusPublished {
+		return domain.Article{}, ErrArticleNotFound
+	}
+
+	// 渲染功能上线之前发表的帖子，线上库中没有 HTML
+	if art.Html == "" && art.Content != "" {
+		if err = render(&art); err != nil {
+			return domain.Article{}, err
+		}
+	}
+
 	// 获取 AuthorName
 	user, err := as.userRepo.SearchById(ctx, art.AuthorId)
 	if err != nil {
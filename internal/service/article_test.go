@@ -0,0 +1,98 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/Linxhhh/webook/internal/domain"
+	"github.com/Linxhhh/webook/internal/repository"
+)
+
+// memArticleRepo 只实现恢复版本用到的方法
+type memArticleRepo struct {
+	repository.ArticleRepository
+	arts map[int64]domain.Article
+}
+
+func (r *memArticleRepo) GetById(ctx context.Context, aid int64) (domain.Article, error) {
+	art, ok := r.arts[aid]
+	if !ok {
+		return domain.Article{}, repository.ErrArticleNotFound
+	}
+	return art, nil
+}
+
+func (r *memArticleRepo) Update(ctx context.Context, art domain.Article) error {
+	r.arts[art.Id] = art
+	return nil
+}
+
+// memRevisionRepo 只实现恢复版本用到的方法
+type memRevisionRepo struct {
+	repository.ArticleRevisionRepository
+	revs map[int64]domain.ArticleRevision
+}
+
+func (r *memRevisionRepo) GetById(ctx context.Context, id int64) (domain.ArticleRevision, error) {
+	rev, ok := r.revs[id]
+	if !ok {
+		return domain.ArticleRevision{}, repository.ErrRevisionNotFound
+	}
+	return rev, nil
+}
+
+func TestArticleRestore(t *testing.T) {
+	testCases := []struct {
+		name   string
+		status domain.ArticleStatus
+		want   error
+	}{
+		{name: "草稿", status: domain.ArticleStatusUnpublished},
+		{name: "已发表", status: domain.ArticleStatusPublished},
+		{name: "审核被拒绝", status: domain.ArticleStatusRejected},
+		{name: "审核中", status: domain.ArticleStatusPendingReview, want: ErrRestoreNotAllowed},
+		{name: "等待定时发表", status: domain.ArticleStatusScheduled, want: ErrRestoreNotAllowed},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			current := domain.Article{Id: 1, Title: "新标题", Content: "新内容", AuthorId: 7, Status: tc.status}
+			artRepo := &memArticleRepo{arts: map[int64]domain.Article{1: current}}
+			revRepo := &memRevisionRepo{revs: map[int64]domain.ArticleRevision{
+				10: {Id: 10, ArticleId: 1, AuthorId: 7, Title: "旧标题", Content: "旧内容"},
+			}}
+			svc := NewArticleService(artRepo, revRepo, nil, nil)
+
+			aid, err := svc.Restore(context.Background(), 7, 10)
+			if !errors.Is(err, tc.want) {
+				t.Fatalf("期望 %v，实际 %v", tc.want, err)
+			}
+			got := artRepo.arts[1]
+			if tc.want != nil {
+				// 拒绝恢复时，审核或定时发表不受影响
+				if got.Status != tc.status || got.Title != current.Title || got.Content != current.Content {
+					t.Fatalf("帖子不应该被修改：%+v", got)
+				}
+				return
+			}
+			if aid != 1 || got.Title != "旧标题" || got.Content != "旧内容" || got.Status != domain.ArticleStatusUnpublished {
+				t.Fatalf("恢复结果错误：%d %+v", aid, got)
+			}
+		})
+	}
+}
+
+func TestArticleRestoreOtherAuthor(t *testing.T) {
+	artRepo := &memArticleRepo{arts: map[int64]domain.Article{1: {Id: 1, AuthorId: 7}}}
+	revRepo := &memRevisionRepo{revs: map[int64]domain.ArticleRevision{
+		10: {Id: 10, ArticleId: 1, AuthorId: 7},
+	}}
+	svc := NewArticleService(artRepo, revRepo, nil, nil)
+
+	if _, err := svc.Restore(context.Background(), 8, 10); !errors.Is(err, ErrIncorrectArticleorAuthor) {
+		t.Fatalf("期望 ErrIncorrectArticleorAuthor，实际 %v", err)
+	}
+	if _, err := svc.Restore(context.Background(), 7, 11); !errors.Is(err, ErrRevisionNotFound) {
+		t.Fatalf("期望 ErrRevisionNotFound，实际 %v", err)
+	}
+}
@@ -2,10 +2,12 @@ package service
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"math/rand"
 
 	"github.com/Linxhhh/webook/internal/repository"
+	"github.com/Linxhhh/webook/internal/service/email"
 	"github.com/Linxhhh/webook/internal/service/sms"
 )
 
@@ -13,6 +15,7 @@ var (
 	ErrSendCodeTooMany   = repository.ErrSendCodeTooMany
 	ErrVerifyCodeFailed  = repository.ErrVerifyCodeFailed
 	ErrVerifyCodeTooMany = repository.ErrVerifyCodeTooMany
+	ErrUnknownCodeChannel = errors.New("未知的验证码发送渠道")
 )
 
 /* 
@@ -20,41 +23,64 @@ var (
 一般来说，可能替换的是具体的短信服务提供商，即 sms.Service
 */
 
+// CodeChannel 验证码的发送渠道
+type CodeChannel string
+
+const (
+	CodeChannelSMS   CodeChannel = "sms"
+	CodeChannelEmail CodeChannel = "email"
+)
+
 type CodeService struct {
 	repo  repository.CodeRepository
 	sms   sms.Service
+	email email.Service
 	tplId string
+
+	// 邮件渠道按照业务类型选择模板，默认使用 email.VerifyCodeTemplate
+	emailTpls map[string]email.Template
 }
 
-func NewCodeService(repo repository.CodeRepository, sms sms.Service) *CodeService {
+func NewCodeService(repo repository.CodeRepository, sms sms.Service, emailSvc email.Service) *CodeService {
 	return &CodeService{
 		repo:  repo,
 		sms:   sms,
+		email: emailSvc,
 		tplId: "1234567",
+		emailTpls: map[string]email.Template{
+			"reset_password": email.ResetPasswordTemplate,
+		},
 	}
 }
 
 /*
 Send 验证码发送服务：
-传入业务类型 biz，用户手机号码 phone
+传入业务类型 biz，发送渠道 channel，以及对应的手机号码或邮箱 target
 */
-func (svc *CodeService) Send(ctx context.Context, biz string, phone string) error {
+func (svc *CodeService) Send(ctx context.Context, biz string, channel CodeChannel, target string) error {
 
 	// 生成验证码
 	code := svc.generateCode()
 
 	// 存储验证码
-	err := svc.repo.Store(ctx, biz, phone, code)
+	err := svc.repo.Store(ctx, biz, target, code)
 	if err != nil {
 		return err
 	}
 
 	// 发送验证码
-	err = svc.sms.Send(ctx, svc.tplId, []string{code}, phone)
-	if err != nil {
-		return err
+	switch channel {
+	case CodeChannelSMS:
+		return svc.sms.Send(ctx, svc.tplId, []string{code}, target)
+	case CodeChannelEmail:
+		tpl, ok := svc.emailTpls[biz]
+		if !ok {
+			tpl = email.VerifyCodeTemplate
+		}
+		return email.SendTemplate(ctx, svc.email, tpl, email.CodeData{Code: code, Minutes: 3}, target)
+	default:
+		return ErrUnknownCodeChannel
 	}
-	return nil
 }
 
 func (svc *CodeService) generateCode() string {
@@ -0,0 +1,109 @@
+package service
+
+import (
+	"context"
+	"errors"
+
+	"github.com/Linxhhh/webook/internal/domain"
+	"github.com/Linxhhh/webook/internal/repository"
+)
+
+var (
+	ErrIncorrectCommentorUser = repository.ErrIncorrectCommentorUser
+	ErrCommentTargetNotFound  = errors.New("评论对象不存在")
+)
+
+type CommentService struct {
+	repo     repository.CommentRepository
+	userRepo repository.UserRepository
+	artRepo  repository.ArticleRepository
+}
+
+func NewCommentService(repo repository.CommentRepository, userRepo repository.UserRepository, artRepo repository.ArticleRepository) *CommentService {
+	return &CommentService{
+		repo:     repo,
+		userRepo: userRepo,
+		artRepo:  artRepo,
+	}
+}
+
+/*
+Create 发表评论：
+目前只支持评论帖子，先校验被评论的帖子是否已发表，如果是回复，再校验被回复的评论，并设置 RootId 和 ParentUid
+*/
+func (svc *CommentService) Create(ctx context.Context, c domain.Comment) (domain.Comment, error) {
+
+	// 校验被评论的帖子
+	if c.Biz != "article" {
+		return c, ErrCommentTargetNotFound
+	}
+	art, err := svc.artRepo.GetPubById(ctx, c.BizId)
+	if errors.Is(err, repository.ErrArticleNotFound) {
+		return c, ErrCommentTargetNotFound
+	}
+	if err != nil {
+		return c, err
+	}
+	if art.Status != domain.ArticleStatusPublished {
+		return c, ErrCommentTargetNotFound
+	}
+
+	// 校验被回复的评论
+	c.RootId = 0
+	if c.ParentId > 0 {
+		parent, err := svc.repo.GetById(ctx, c.ParentId)
+		if errors.Is(err, repository.ErrCommentNotFound) {
+			return c, ErrCommentTargetNotFound
+		}
+		if err != nil {
+			return c, err
+		}
+		if parent.Biz != c.Biz || parent.BizId != c.BizId {
+			return c, ErrCommentTargetNotFound
+		}
+		c.RootId = parent.RootId
+		if c.RootId == 0 {
+			c.RootId = parent.Id
+		}
+		c.ParentUid = parent.Uid
+	}
+
+	id, err := svc.repo.Create(ctx, c)
+	c.Id = id
+	return c, err
+}
+
+// Delete 删除自己的评论
+func (svc *CommentService) Delete(ctx context.Context, uid int64, id int64) error {
+	return svc.repo.Delete(ctx, uid, id)
+}
+
+// List 获取顶级评论列表
+func (svc *CommentService) List(ctx context.Context, biz string, bizId int64, lastId int64, limit int) ([]domain.Comment, error) {
+	list, err := svc.repo.GetTopList(ctx, biz, bizId, lastId, limit)
+	if err != nil {
+		return nil, err
+	}
+	return list, svc.fillUserName(ctx, list)
+}
+
+// Replies 获取顶级评论下的回复列表
+func (svc *CommentService) Replies(ctx context.Context, rootId int64, lastId int64, limit int) ([]domain.Comment, error) {
+	list, err := svc.repo.GetReplies(ctx, rootId, lastId, limit)
+	if err != nil {
+		return nil, err
+	}
+	return list, svc.fillUserName(ctx, list)
+}
+
+// fillUserName 获取评论者的昵称
+func (svc *CommentService) fillUserName(ctx context.Context, list []domain.Comment) error {
+	for i := range list {
+		user, err := svc.userRepo.SearchById(ctx, list[i].Uid)
+		if err != nil && !errors.Is(err, repository.ErrUserNotFound) {
+			return err
+		}
+		list[i].UserName = user.NickName
+	}
+	return nil
+}
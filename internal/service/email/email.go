@@ -0,0 +1,8 @@
+package email
+
+import "context"
+
+// Service 邮件服务接口，body 为 HTML 格式
+type Service interface {
+	Send(ctx context.Context, subject string, body string, to ...string) error
+}
@@ -0,0 +1,18 @@
+package email
+
+import (
+	"context"
+	"log"
+)
+
+type LocalService struct {
+}
+
+func NewLocalService() *LocalService {
+	return &LocalService{}
+}
+
+func (s *LocalService) Send(ctx context.Context, subject string, body string, to ...string) error {
+	log.Println("邮件发送给", to, subject, body)
+	return nil
+}
@@ -0,0 +1,117 @@
+package email
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"encoding/base64"
+	"fmt"
+	"mime"
+	"net"
+	"net/smtp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+type SMTPService struct {
+	host     string
+	port     int
+	username string
+	password string
+	from     string
+}
+
+func NewSMTPService(host string, port int, username, password, from string) *SMTPService {
+	return &SMTPService{
+		host:     host,
+		port:     port,
+		username: username,
+		password: password,
+		from:     from,
+	}
+}
+
+/*
+Send 邮件发送服务：
+465 端口使用隐式 TLS，其他端口在服务器支持时使用 STARTTLS
+*/
+func (svc *SMTPService) Send(ctx context.Context, subject string, body string, to ...string) error {
+	addr := net.JoinHostPort(svc.host, strconv.Itoa(svc.port))
+	dialer := &net.Dialer{Timeout: 5 * time.Second}
+
+	var (
+		conn net.Conn
+		err  error
+	)
+	if svc.port == 465 {
+		conn, err = tls.DialWithDialer(dialer, "tcp", addr, &tls.Config{ServerName: svc.host})
+	} else {
+		conn, err = dialer.DialContext(ctx, "tcp", addr)
+	}
+	if err != nil {
+		return err
+	}
+	if deadline, ok := ctx.Deadline(); ok {
+		conn.SetDeadline(deadline)
+	}
+
+	client, err := smtp.NewClient(conn, svc.host)
+	if err != nil {
+		conn.Close()
+		return err
+	}
+	defer client.Close()
+
+	if ok, _ := client.Extension("STARTTLS"); ok && svc.port != 465 {
+		if err = client.StartTLS(&tls.Config{ServerName: svc.host}); err != nil {
+			return err
+		}
+	}
+	if svc.username != "" {
+		if err = client.Auth(smtp.PlainAuth("", svc.username, svc.password, svc.host)); err != nil {
+			return err
+		}
+	}
+
+	if err = client.Mail(svc.from); err != nil {
+		return err
+	}
+	for _, rcpt := range to {
+		if err = client.Rcpt(rcpt); err != nil {
+			return err
+		}
+	}
+	w, err := client.Data()
+	if err != nil {
+		return err
+	}
+	if _, err = w.Write(svc.message(subject, body, to)); err != nil {
+		return err
+	}
+	if err = w.Close(); err != nil {
+		return err
+	}
+	return client.Quit()
+}
+
+// message 构造 MIME 邮件，标题和正文使用 UTF-8 编码
+func (svc *SMTPService) message(subject string, body string, to []string) []byte {
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "From: %s\r\n", svc.from)
+	fmt.Fprintf(&buf, "To: %s\r\n", strings.Join(to, ", "))
+	fmt.Fprintf(&buf, "Subject: %s\r\n", mime.BEncoding.Encode("UTF-8", subject))
+	fmt.Fprintf(&buf, "Date: %s\r\n", time.Now().Format(time.RFC1123Z))
+	buf.WriteString("MIME-Version: 1.0\r\n")
+	buf.WriteString("Content-Type: text/html; charset=UTF-8\r\n")
+	buf.WriteString("Content-Transfer-Encoding: base64\r\n\r\n")
+
+	// 每行不超过 76 个字符
+	encoded := base64.StdEncoding.EncodeToString([]byte(body))
+	for len(encoded) > 76 {
+		buf.WriteString(encoded[:76] + "\r\n")
+		encoded = encoded[76:]
+	}
+	buf.WriteString(encoded + "\r\n")
+	return buf.Bytes()
+}
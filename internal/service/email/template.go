@@ -0,0 +1,60 @@
+package email
+
+import (
+	"bytes"
+	"context"
+	"embed"
+	"html/template"
+)
+
+//go:embed templates/*.html
+var templateFS embed.FS
+
+var tpls = template.Must(template.ParseFS(templateFS, "templates/*.html"))
+
+// Template 邮件模板，正文模板位于 templates 目录
+type Template struct {
+	Subject string
+	Name    string
+}
+
+var (
+	VerifyCodeTemplate    = Template{Subject: "webook 验证码", Name: "verifyCode.html"}
+	ResetPasswordTemplate = Template{Subject: "webook 找回密码", Name: "resetPassword.html"}
+	WeeklyDigestTemplate  = Template{Subject: "webook 每周精选", Name: "weeklyDigest.html"}
+)
+
+// CodeData 验证码模板参数
+type CodeData struct {
+	Code    string
+	Minutes int
+}
+
+// DigestData 每周精选模板参数
+type DigestData struct {
+	NickName string
+	Articles []DigestArticle
+}
+
+type DigestArticle struct {
+	Title string
+	URL   string
+}
+
+// Render 渲染邮件正文
+func (t Template) Render(data any) (string, error) {
+	var buf bytes.Buffer
+	if err := tpls.ExecuteTemplate(&buf, t.Name, data); err != nil {
+		return "", err
+	}
+	return buf.String(), nil
+}
+
+// SendTemplate 渲染模板并发送邮件
+func SendTemplate(ctx context.Context, svc Service, t Template, data any, to ...string) error {
+	body, err := t.Render(data)
+	if err != nil {
+		return err
+	}
+	return svc.Send(ctx, t.Subject, body, to...)
+}
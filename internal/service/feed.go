@@ -2,6 +2,8 @@ package service
 
 import (
 	"context"
+	"errors"
+	"math"
 	"sort"
 	"strconv"
 	"sync"
@@ -13,18 +15,41 @@ import (
 )
 
 type FeedEventService struct {
-	repo     repository.FeedRepository
-	follRepo repository.FollowRepository
+	repo      repository.FeedRepository
+	follRepo  repository.FollowRepository
+	artRepo   repository.ArticleRepository
+	userRepo  repository.UserRepository
+	interRepo repository.InteractionRepository
 }
 
-func NewFeedEventService(repo repository.FeedRepository, follRepo repository.FollowRepository) *FeedEventService {
+func NewFeedEventService(repo repository.FeedRepository, follRepo repository.FollowRepository, artRepo repository.ArticleRepository,
+	userRepo repository.UserRepository, interRepo repository.InteractionRepository) *FeedEventService {
 	return &FeedEventService{
-		repo:     repo,
-		follRepo: follRepo,
+		repo:      repo,
+		follRepo:  follRepo,
+		artRepo:   artRepo,
+		userRepo:  userRepo,
+		interRepo: interRepo,
 	}
 }
 
+/*
+CreateFeedEvent 创建 Feed 事件：
+发帖事件推送给作者的粉丝；点赞、收藏事件只推送给帖子作者；关注事件只推送给被关注的用户
+*/
 func (f *FeedEventService) CreateFeedEvent(ctx context.Context, feed domain.FeedEvent) error {
+	switch feed.Type {
+	case domain.LikeFeedEvent, domain.CollectFeedEvent:
+		return f.createAuthorEvent(ctx, feed)
+	case domain.FollowFeedEvent:
+		return f.createFolloweeEvent(ctx, feed)
+	default:
+		return f.createFanoutEvent(ctx, feed)
+	}
+}
+
+// createFanoutEvent 根据粉丝数量，推送给粉丝或者等待粉丝拉取
+func (f *FeedEventService) createFanoutEvent(ctx context.Context, feed domain.FeedEvent) error {
 
 	followee, err := feed.Ext.Get("uid")
 	if err != nil {
@@ -44,9 +69,8 @@ func (f *FeedEventService) CreateFeedEvent(ctx context.Context, feed domain.Feed
 	if resp.Followers > 100 {
 		// 拉模型（等粉丝拉取）
 		return f.repo.CreatePullEvent(ctx, domain.FeedEvent{
-			Uid:  uid,
-			Type: domain.ArticleFeedEvent,
-			// Type:  feed.Type,
+			Uid:   uid,
+			Type:  feed.Type,
 			Ctime: time.Now(),
 			Ext:   feed.Ext,
 		})
@@ -56,12 +80,14 @@ func (f *FeedEventService) CreateFeedEvent(ctx context.Context, feed domain.Feed
 		if err != nil {
 			return err
 		}
+		if len(list) == 0 {
+			return nil
+		}
 		var events []domain.FeedEvent
 		for _, elem := range list {
 			events = append(events, domain.FeedEvent{
-				Uid:  elem.Follower,
-				Type: domain.ArticleFeedEvent,
-				// Type:  feed.Type,
+				Uid:   elem.Follower,
+				Type:  feed.Type,
 				Ctime: time.Now(),
 				Ext:   feed.Ext,
 			})
@@ -70,8 +96,75 @@ func (f *FeedEventService) CreateFeedEvent(ctx context.Context, feed domain.Feed
 	}
 }
 
-// GetFeedEventList 查询发件箱和收信箱
-func (f *FeedEventService) GetFeedEventList(ctx context.Context, uid int64, timestamp, limit int64) ([]domain.FeedEvent, error) {
+// createAuthorEvent 推送给帖子作者的收件箱，作者自己的互动不通知
+func (f *FeedEventService) createAuthorEvent(ctx context.Context, feed domain.FeedEvent) error {
+
+	val, err := feed.Ext.Get("aid")
+	if err != nil {
+		return err
+	}
+	aid, err := strconv.ParseInt(val, 10, 64)
+	if err != nil {
+		return err
+	}
+	val, err = feed.Ext.Get("uid")
+	if err != nil {
+		return err
+	}
+	uid, err := strconv.ParseInt(val, 10, 64)
+	if err != nil {
+		return err
+	}
+
+	// 获取帖子作者，帖子已被删除或撤销时不再推送，避免消息重试后进入死信队列
+	art, err := f.artRepo.GetPubById(ctx, aid)
+	if errors.Is(err, repository.ErrArticleNotFound) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if art.Status != domain.ArticleStatusPublished {
+		return nil
+	}
+	if art.AuthorId == uid {
+		return nil
+	}
+
+	return f.repo.CreatePushEvents(ctx, []domain.FeedEvent{{
+		Uid:   art.AuthorId,
+		Type:  feed.Type,
+		Ctime: time.Now(),
+		Ext:   feed.Ext,
+	}})
+}
+
+// createFolloweeEvent 推送给被关注用户的收件箱
+func (f *FeedEventService) createFolloweeEvent(ctx context.Context, feed domain.FeedEvent) error {
+
+	val, err := feed.Ext.Get("followee")
+	if err != nil {
+		return err
+	}
+	followee, err := strconv.ParseInt(val, 10, 64)
+	if err != nil {
+		return err
+	}
+
+	return f.repo.CreatePushEvents(ctx, []domain.FeedEvent{{
+		Uid:   followee,
+		Type:  feed.Type,
+		Ctime: time.Now(),
+		Ext:   feed.Ext,
+	}})
+}
+
+/*
+GetFeedEventList 按照游标查询发件箱和收信箱：
+排序规则为（时间戳，来源，事件 ID）倒序，时间戳和游标相同时，
+与游标同一来源的事件按照 ID 比较，来源排在游标之后的事件全部返回，排在之前的全部跳过
+*/
+func (f *FeedEventService) GetFeedEventList(ctx context.Context, uid int64, cursor domain.FeedCursor, limit int64) ([]domain.FeedEvent, error) {
 
 	var eg errgroup.Group
 	var lock sync.Mutex
@@ -89,7 +182,7 @@ func (f *FeedEventService) GetFeedEventList(ctx context.Context, uid int64, time
 		}
 
 		// 查询发件箱
-		evts, err := f.repo.FindPullEvents(ctx, followeeIDs, timestamp, limit)
+		evts, err := f.repo.FindPullEvents(ctx, followeeIDs, cursor.Timestamp, tieId(cursor, domain.FeedSourcePull), limit)
 		if err != nil {
 			return err
 		}
@@ -101,7 +194,7 @@ func (f *FeedEventService) GetFeedEventList(ctx context.Context, uid int64, time
 
 	eg.Go(func() error {
 		// 查询收件箱
-		evts, err := f.repo.FindPushEvents(ctx, uid, timestamp, limit)
+		evts, err := f.repo.FindPushEvents(ctx, uid, cursor.Timestamp, tieId(cursor, domain.FeedSourcePush), limit)
 		if err != nil {
 			return err
 		}
@@ -116,9 +209,117 @@ func (f *FeedEventService) GetFeedEventList(ctx context.Context, uid int64, time
 		return nil, err
 	}
 
-	// 按照时间戳排序
+	// 按照时间戳排序，时间戳相同则按照来源、事件 ID 排序
 	sort.Slice(events, func(i, j int) bool {
-		return events[i].Ctime.UnixMilli() > events[j].Ctime.UnixMilli()
+		if !events[i].Ctime.Equal(events[j].Ctime) {
+			return events[i].Ctime.After(events[j].Ctime)
+		}
+		if events[i].Source != events[j].Source {
+			return events[i].Source > events[j].Source
+		}
+		return events[i].Id > events[j].Id
 	})
 	return events[:min(len(events), int(limit))], nil
 }
+
+// tieId 时间戳和游标相同时，来源为 source 的事件需要满足 id < tieId
+func tieId(cursor domain.FeedCursor, source domain.FeedSource) int64 {
+	switch {
+	case source == cursor.Source:
+		return cursor.Id
+	case source < cursor.Source:
+		return math.MaxInt64
+	default:
+		return 0
+	}
+}
+
+/*
+GetFeed 查询 Feed 流：
+先合并发件箱和收件箱，再补充帖子标题、作者昵称和互动数据，
+返回的游标取自最后一个事件，即使该事件对应的帖子已经不可见
+*/
+func (f *FeedEventService) GetFeed(ctx context.Context, uid int64, cursor domain.FeedCursor, limit int64) ([]domain.FeedElem, domain.FeedCursor, error) {
+
+	events, err := f.GetFeedEventList(ctx, uid, cursor, limit)
+	if err != nil {
+		return nil, cursor, err
+	}
+
+	elems := make([]domain.FeedElem, 0, len(events))
+	for _, evt := range events {
+		elem, err := f.hydrate(ctx, evt)
+		if errors.Is(err, repository.ErrArticleNotFound) {
+			// 帖子已被删除或撤销
+			continue
+		}
+		if err != nil {
+			return nil, cursor, err
+		}
+		elems = append(elems, elem)
+	}
+
+	// 下一页的游标
+	if len(events) > 0 {
+		last := events[len(events)-1]
+		cursor = domain.FeedCursor{Timestamp: last.Ctime.Unix(), Source: last.Source, Id: last.Id}
+	}
+	return elems, cursor, nil
+}
+
+// hydrate 根据拓展字段中的 uid 和 aid，补充触发事件的用户、帖子标题、作者昵称和互动数据
+func (f *FeedEventService) hydrate(ctx context.Context, evt domain.FeedEvent) (domain.FeedElem, error) {
+
+	elem := domain.FeedElem{Event: evt}
+
+	// 点赞、收藏、关注事件，获取触发事件的用户
+	if evt.Type != domain.ArticleFeedEvent {
+		if val, err := evt.Ext.Get("uid"); err == nil {
+			uid, err := strconv.ParseInt(val, 10, 64)
+			if err != nil {
+				return elem, err
+			}
+			actor, err := f.userRepo.SearchById(ctx, uid)
+			if err != nil && !errors.Is(err, repository.ErrUserNotFound) {
+				return elem, err
+			}
+			elem.Actor = domain.User{Id: uid, NickName: actor.NickName}
+		}
+	}
+
+	val, err := evt.Ext.Get("aid")
+	if err != nil {
+		// 与帖子无关的事件
+		return elem, nil
+	}
+	aid, err := strconv.ParseInt(val, 10, 64)
+	if err != nil {
+		return elem, err
+	}
+
+	// 获取帖子
+	art, err := f.artRepo.GetPubById(ctx, aid)
+	if err != nil {
+		return elem, err
+	}
+	if art.Status != domain.ArticleStatusPublished {
+		return elem, repository.ErrArticleNotFound
+	}
+
+	// 获取 AuthorName
+	user, err := f.userRepo.SearchById(ctx, art.AuthorId)
+	if err != nil && !errors.Is(err, repository.ErrUserNotFound) {
+		return elem, err
+	}
+	art.AuthorName = user.NickName
+	art.Content = ""
+	elem.Article = art
+
+	// 获取（阅读、点赞、收藏）数据
+	inter, err := f.interRepo.Get(ctx, "article", aid)
+	if err != nil && !errors.Is(err, repository.ErrInteractionNotFound) {
+		return elem, err
+	}
+	elem.Interaction = inter
+	return elem, nil
+}
@@ -0,0 +1,138 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/Linxhhh/webook/internal/domain"
+	"github.com/Linxhhh/webook/internal/repository"
+)
+
+var (
+	ErrAccountLocked    = errors.New("登录失败次数过多，账号已被锁定")
+	ErrIpLocked         = errors.New("登录失败次数过多，IP 已被锁定")
+	ErrLoginTooFrequent = errors.New("登录过于频繁")
+)
+
+/*
+LoginGuardService 登录防护：
+窗口内同一邮箱每失败一次，下一次登录前需要等待的时间翻倍；
+邮箱失败次数达到上限后锁定账号，可以通过短信验证码解锁；IP 失败次数达到上限后锁定 IP，到期自动解锁
+*/
+type LoginGuardService struct {
+	repo repository.LoginGuardRepository
+
+	window        time.Duration // 统计失败次数的窗口
+	maxFailures   int64         // 锁定邮箱的失败次数
+	ipMaxFailures int64         // 锁定 IP 的失败次数
+	lockDuration  time.Duration // 锁定时长
+	baseDelay     time.Duration // 第一次失败之后的等待时间
+	maxDelay      time.Duration // 等待时间的上限
+}
+
+func NewLoginGuardService(repo repository.LoginGuardRepository) *LoginGuardService {
+	return &LoginGuardService{
+		repo:          repo,
+		window:        15 * time.Minute,
+		maxFailures:   5,
+		ipMaxFailures: 50,
+		lockDuration:  30 * time.Minute,
+		baseDelay:     time.Second,
+		maxDelay:      30 * time.Second,
+	}
+}
+
+/*
+Check 登录前检查：
+返回 ErrAccountLocked、ErrIpLocked 或 ErrLoginTooFrequent 时，同时返回需要等待的时间
+*/
+func (svc *LoginGuardService) Check(ctx context.Context, email, ip string) (time.Duration, error) {
+	email = normalizeEmail(email)
+	emailTTL, ipTTL, err := svc.repo.GetLock(ctx, email, ip)
+	if err != nil {
+		return 0, err
+	}
+	if emailTTL > 0 {
+		return emailTTL, ErrAccountLocked
+	}
+	if ipTTL > 0 {
+		return ipTTL, ErrIpLocked
+	}
+
+	delay, err := svc.repo.GetDelay(ctx, email)
+	if err != nil {
+		return 0, err
+	}
+	if delay > 0 {
+		return delay, ErrLoginTooFrequent
+	}
+	return 0, nil
+}
+
+// Fail 记录一次登录失败，达到上限时锁定邮箱或 IP，否则设置下一次登录前的等待时间
+func (svc *LoginGuardService) Fail(ctx context.Context, email, ip string) error {
+	email = normalizeEmail(email)
+	emailCnt, ipCnt, err := svc.repo.IncrFailure(ctx, email, ip, svc.window)
+	if err != nil {
+		return err
+	}
+
+	lockUntil := time.Now().Add(svc.lockDuration)
+	if ipCnt >= svc.ipMaxFailures {
+		log.Printf("登录失败次数过多，锁定 IP %s", ip)
+		err = svc.repo.Lock(ctx, domain.LoginLockout{
+			TargetType: domain.LockTargetIp,
+			Target:     ip,
+			Ip:         ip,
+			Failures:   ipCnt,
+			LockUntil:  lockUntil,
+		})
+		if err != nil {
+			return err
+		}
+	}
+	if emailCnt >= svc.maxFailures {
+		log.Printf("登录失败次数过多，锁定账号 %s", email)
+		return svc.repo.Lock(ctx, domain.LoginLockout{
+			TargetType: domain.LockTargetEmail,
+			Target:     email,
+			Ip:         ip,
+			Failures:   emailCnt,
+			LockUntil:  lockUntil,
+		})
+	}
+	return svc.repo.SetDelay(ctx, email, svc.delay(emailCnt))
+}
+
+// delay 第 n 次失败之后的等待时间：baseDelay * 2^(n-1)，不超过 maxDelay
+func (svc *LoginGuardService) delay(n int64) time.Duration {
+	d := svc.baseDelay
+	for i := int64(1); i < n && d < svc.maxDelay; i++ {
+		d *= 2
+	}
+	return min(d, svc.maxDelay)
+}
+
+// Succeed 登录成功后清空邮箱的失败次数
+func (svc *LoginGuardService) Succeed(ctx context.Context, email string) error {
+	return svc.repo.ResetFailure(ctx, normalizeEmail(email))
+}
+
+// IsLocked 判断账号是否被锁定
+func (svc *LoginGuardService) IsLocked(ctx context.Context, email string) (bool, error) {
+	emailTTL, _, err := svc.repo.GetLock(ctx, normalizeEmail(email), "")
+	return emailTTL > 0, err
+}
+
+// Unlock 解除账号的锁定，需要先校验短信验证码
+func (svc *LoginGuardService) Unlock(ctx context.Context, email string) error {
+	return svc.repo.UnlockEmail(ctx, normalizeEmail(email))
+}
+
+// normalizeEmail 邮箱不区分大小写，避免通过改变大小写绕过失败计数
+func normalizeEmail(email string) string {
+	return strings.ToLower(strings.TrimSpace(email))
+}
@@ -0,0 +1,53 @@
+package moderation
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+/*
+KeywordModerator 本地关键词过滤：
+关键词忽略大小写按子串匹配，正则表达式按原样匹配，命中任意一条即不通过
+*/
+type KeywordModerator struct {
+	keywords []string
+	patterns []*regexp.Regexp
+}
+
+// NewKeywordModerator 编译所有正则表达式，有不合法的表达式时返回 error
+func NewKeywordModerator(keywords []string, patterns []string) (*KeywordModerator, error) {
+	m := &KeywordModerator{
+		keywords: make([]string, 0, len(keywords)),
+		patterns: make([]*regexp.Regexp, 0, len(patterns)),
+	}
+	for _, kw := range keywords {
+		if kw = strings.TrimSpace(kw); kw != "" {
+			m.keywords = append(m.keywords, strings.ToLower(kw))
+		}
+	}
+	for _, p := range patterns {
+		re, err := regexp.Compile(p)
+		if err != nil {
+			return nil, fmt.Errorf("审核规则 %q 不合法：%w", p, err)
+		}
+		m.patterns = append(m.patterns, re)
+	}
+	return m, nil
+}
+
+func (m *KeywordModerator) Moderate(ctx context.Context, text string) (Result, error) {
+	lower := strings.ToLower(text)
+	for _, kw := range m.keywords {
+		if strings.Contains(lower, kw) {
+			return Result{Reason: fmt.Sprintf("内容包含敏感词「%s」", kw)}, nil
+		}
+	}
+	for _, re := range m.patterns {
+		if re.MatchString(text) {
+			return Result{Reason: "内容包含违规信息"}, nil
+		}
+	}
+	return Result{Pass: true}, nil
+}
@@ -0,0 +1,18 @@
+package moderation
+
+import "context"
+
+// Result 审核结果，不通过时 Reason 为展示给作者的原因
+type Result struct {
+	Pass   bool
+	Reason string
+}
+
+/*
+Moderator 内容审核接口：
+本地的关键词过滤、外部的分类服务都实现该接口，
+返回 error 表示审核服务本身出错（需要重试），而不是内容不通过
+*/
+type Moderator interface {
+	Moderate(ctx context.Context, text string) (Result, error)
+}
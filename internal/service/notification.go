@@ -0,0 +1,80 @@
+package service
+
+import (
+	"context"
+	"errors"
+
+	"github.com/Linxhhh/webook/internal/domain"
+	"github.com/Linxhhh/webook/internal/repository"
+)
+
+type NotificationService struct {
+	repo     repository.NotificationRepository
+	userRepo repository.UserRepository
+	artRepo  repository.ArticleRepository
+}
+
+func NewNotificationService(repo repository.NotificationRepository, userRepo repository.UserRepository, artRepo repository.ArticleRepository) *NotificationService {
+	return &NotificationService{
+		repo:     repo,
+		userRepo: userRepo,
+		artRepo:  artRepo,
+	}
+}
+
+// Notify 创建一条通知，自己触发的通知会被忽略
+func (svc *NotificationService) Notify(ctx context.Context, n domain.Notification) error {
+	if n.Uid == n.ActorId {
+		return nil
+	}
+	_, err := svc.repo.Create(ctx, n)
+	return err
+}
+
+// NotifyArticleAuthor 通知帖子作者，content 为空时，通知内容为帖子标题
+func (svc *NotificationService) NotifyArticleAuthor(ctx context.Context, typ domain.NotificationType, actorId int64, aid int64, content string) error {
+	art, err := svc.artRepo.GetPubById(ctx, aid)
+	if err != nil {
+		return err
+	}
+	if content == "" {
+		content = art.Title
+	}
+	return svc.Notify(ctx, domain.Notification{
+		Uid:     art.AuthorId,
+		Type:    typ,
+		ActorId: actorId,
+		Biz:     "article",
+		BizId:   aid,
+		Content: content,
+	})
+}
+
+// List 获取通知列表，并补充触发通知的用户昵称
+func (svc *NotificationService) List(ctx context.Context, uid int64, lastId int64, limit int) ([]domain.Notification, error) {
+	list, err := svc.repo.GetList(ctx, uid, lastId, limit)
+	if err != nil {
+		return nil, err
+	}
+	for i := range list {
+		// 获取 ActorName
+		user, err := svc.userRepo.SearchById(ctx, list[i].ActorId)
+		if err != nil && !errors.Is(err, repository.ErrUserNotFound) {
+			return nil, err
+		}
+		list[i].ActorName = user.NickName
+	}
+	return list, nil
+}
+
+func (svc *NotificationService) UnreadCount(ctx context.Context, uid int64) (int64, error) {
+	return svc.repo.CountUnread(ctx, uid)
+}
+
+func (svc *NotificationService) MarkRead(ctx context.Context, uid int64, id int64) error {
+	return svc.repo.MarkRead(ctx, uid, id)
+}
+
+func (svc *NotificationService) MarkAllRead(ctx context.Context, uid int64) error {
+	return svc.repo.MarkAllRead(ctx, uid)
+}
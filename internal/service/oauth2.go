@@ -0,0 +1,125 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+
+	"github.com/Linxhhh/webook/internal/repository"
+	"github.com/Linxhhh/webook/internal/service/oauth2"
+)
+
+var (
+	ErrOAuth2ProviderNotFound = errors.New("不支持的第三方平台")
+	ErrInvalidOAuth2State     = repository.ErrInvalidOAuth2State
+	ErrOAuth2Failed           = oauth2.ErrOAuth2Failed
+)
+
+/*
+OAuth2Service 第三方登录服务：
+具体的平台通过 oauth2.Service 接入，这里负责 state 校验和查找/创建用户
+*/
+type OAuth2Service struct {
+	providers map[string]oauth2.Service
+	stateRepo repository.OAuth2StateRepository
+	userSvc   *UserService
+}
+
+func NewOAuth2Service(providers []oauth2.Service, stateRepo repository.OAuth2StateRepository, userSvc *UserService) *OAuth2Service {
+	m := make(map[string]oauth2.Service, len(providers))
+	for _, p := range providers {
+		m[p.Name()] = p
+	}
+	return &OAuth2Service{
+		providers: m,
+		stateRepo: stateRepo,
+		userSvc:   userSvc,
+	}
+}
+
+/*
+AuthURL 生成授权地址，并保存随机的 state，返回授权地址和 state：
+uid 为发起授权的用户，登录时为 0，绑定时为当前登录的用户；
+调用方还需要把 state 绑定到发起授权的浏览器（例如 HttpOnly Cookie），回调时一起校验
+*/
+func (svc *OAuth2Service) AuthURL(ctx context.Context, provider string, uid int64) (string, string, error) {
+	p, ok := svc.providers[provider]
+	if !ok {
+		return "", "", ErrOAuth2ProviderNotFound
+	}
+
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", "", err
+	}
+	state := hex.EncodeToString(b)
+	if err := svc.stateRepo.Store(ctx, provider, state, uid); err != nil {
+		return "", "", err
+	}
+	url, err := p.AuthURL(ctx, state)
+	return url, state, err
+}
+
+/*
+Login 授权回调：
+先校验 state，再使用授权码换取访问令牌和用户信息，最后查找或创建用户，返回用户 ID
+*/
+func (svc *OAuth2Service) Login(ctx context.Context, provider, code, state string) (int64, error) {
+	p, ok := svc.providers[provider]
+	if !ok {
+		return -1, ErrOAuth2ProviderNotFound
+	}
+
+	// 校验 state，绑定时生成的 state 不能用于登录
+	owner, err := svc.stateRepo.Verify(ctx, provider, state)
+	if err != nil {
+		return -1, err
+	}
+	if owner != 0 {
+		return -1, ErrInvalidOAuth2State
+	}
+
+	// 获取第三方用户信息
+	token, err := p.Exchange(ctx, code)
+	if err != nil {
+		return -1, err
+	}
+	info, err := p.UserInfo(ctx, token)
+	if err != nil {
+		return -1, err
+	}
+
+	// 查找或创建用户
+	return svc.userSvc.FindOrCreateByOAuth2(ctx, info)
+}
+
+// Bind 授权回调：为已登录用户绑定第三方账号
+func (svc *OAuth2Service) Bind(ctx context.Context, uid int64, provider, code, state string) error {
+	p, ok := svc.providers[provider]
+	if !ok {
+		return ErrOAuth2ProviderNotFound
+	}
+
+	// 校验 state，只能使用当前用户发起授权时生成的 state
+	owner, err := svc.stateRepo.Verify(ctx, provider, state)
+	if err != nil {
+		return err
+	}
+	if owner != uid {
+		return ErrInvalidOAuth2State
+	}
+
+	// 获取第三方用户信息
+	token, err := p.Exchange(ctx, code)
+	if err != nil {
+		return err
+	}
+	info, err := p.UserInfo(ctx, token)
+	if err != nil {
+		return err
+	}
+
+	// 绑定
+	return svc.userSvc.BindOAuth2(ctx, uid, info)
+}
@@ -0,0 +1,105 @@
+package oauth2
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/Linxhhh/webook/internal/domain"
+)
+
+var GithubEndpoint = Endpoint{
+	AuthURL:  "https://github.com/login/oauth/authorize",
+	TokenURL: "https://github.com/login/oauth/access_token",
+	UserURL:  "https://api.github.com/user",
+}
+
+type GithubService struct {
+	clientId     string
+	clientSecret string
+	redirectURL  string
+	endpoint     Endpoint
+	client       *http.Client
+}
+
+func NewGithubService(clientId, clientSecret, redirectURL string, endpoint Endpoint, client *http.Client) *GithubService {
+	return &GithubService{
+		clientId:     clientId,
+		clientSecret: clientSecret,
+		redirectURL:  redirectURL,
+		endpoint:     endpoint,
+		client:       client,
+	}
+}
+
+func (svc *GithubService) Name() string {
+	return "github"
+}
+
+func (svc *GithubService) AuthURL(ctx context.Context, state string) (string, error) {
+	params := url.Values{}
+	params.Set("client_id", svc.clientId)
+	params.Set("redirect_uri", svc.redirectURL)
+	params.Set("scope", "read:user")
+	params.Set("state", state)
+	return svc.endpoint.AuthURL + "?" + params.Encode(), nil
+}
+
+func (svc *GithubService) Exchange(ctx context.Context, code string) (Token, error) {
+	form := url.Values{}
+	form.Set("client_id", svc.clientId)
+	form.Set("client_secret", svc.clientSecret)
+	form.Set("code", code)
+	form.Set("redirect_uri", svc.redirectURL)
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, svc.endpoint.TokenURL, strings.NewReader(form.Encode()))
+	if err != nil {
+		return Token{}, err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	var res struct {
+		AccessToken      string `json:"access_token"`
+		Error            string `json:"error"`
+		ErrorDescription string `json:"error_description"`
+	}
+	if err = doJSON(svc.client, req, &res); err != nil {
+		return Token{}, err
+	}
+	if res.Error != "" || res.AccessToken == "" {
+		return Token{}, fmt.Errorf("%w：%s %s", ErrOAuth2Failed, res.Error, res.ErrorDescription)
+	}
+	return Token{AccessToken: res.AccessToken}, nil
+}
+
+func (svc *GithubService) UserInfo(ctx context.Context, token Token) (domain.OAuth2Info, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, svc.endpoint.UserURL, nil)
+	if err != nil {
+		return domain.OAuth2Info{}, err
+	}
+	req.Header.Set("Authorization", "Bearer "+token.AccessToken)
+
+	var res struct {
+		Id    int64  `json:"id"`
+		Login string `json:"login"`
+		Name  string `json:"name"`
+	}
+	if err = doJSON(svc.client, req, &res); err != nil {
+		return domain.OAuth2Info{}, err
+	}
+	if res.Id == 0 {
+		return domain.OAuth2Info{}, fmt.Errorf("%w：用户信息为空", ErrOAuth2Failed)
+	}
+
+	nickName := res.Name
+	if nickName == "" {
+		nickName = res.Login
+	}
+	return domain.OAuth2Info{
+		Provider: svc.Name(),
+		OpenId:   strconv.FormatInt(res.Id, 10),
+		NickName: nickName,
+	}, nil
+}
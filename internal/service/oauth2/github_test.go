@@ -0,0 +1,129 @@
+package oauth2
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+)
+
+// newGithubStub 本地模拟 GitHub 的授权接口，授权码 good-code 换取访问令牌 token-1
+func newGithubStub(t *testing.T) *httptest.Server {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/token", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost || r.Header.Get("Accept") != "application/json" {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		if err := r.ParseForm(); err != nil {
+			t.Fatal(err)
+		}
+		if r.PostForm.Get("client_id") != "cid" || r.PostForm.Get("client_secret") != "secret" {
+			_, _ = w.Write([]byte(`{"error":"incorrect_client_credentials"}`))
+			return
+		}
+		switch r.PostForm.Get("code") {
+		case "good-code":
+			_, _ = w.Write([]byte(`{"access_token":"token-1"}`))
+		case "server-error":
+			w.WriteHeader(http.StatusInternalServerError)
+		default:
+			_, _ = w.Write([]byte(`{"error":"bad_verification_code","error_description":"expired"}`))
+		}
+	})
+	mux.HandleFunc("/user", func(w http.ResponseWriter, r *http.Request) {
+		switch r.Header.Get("Authorization") {
+		case "Bearer token-1":
+			_, _ = w.Write([]byte(`{"id":42,"login":"octocat","name":""}`))
+		case "Bearer empty":
+			_, _ = w.Write([]byte(`{}`))
+		default:
+			w.WriteHeader(http.StatusUnauthorized)
+		}
+	})
+	srv := httptest.NewServer(mux)
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func newTestGithub(srv *httptest.Server, secret string) *GithubService {
+	return NewGithubService("cid", secret, "http://localhost/callback", Endpoint{
+		AuthURL:  srv.URL + "/authorize",
+		TokenURL: srv.URL + "/token",
+		UserURL:  srv.URL + "/user",
+	}, srv.Client())
+}
+
+func TestGithubAuthURL(t *testing.T) {
+	srv := newGithubStub(t)
+	svc := newTestGithub(srv, "secret")
+
+	raw, err := svc.AuthURL(context.Background(), "state-1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	u, err := url.Parse(raw)
+	if err != nil {
+		t.Fatal(err)
+	}
+	q := u.Query()
+	if u.Path != "/authorize" || q.Get("client_id") != "cid" || q.Get("state") != "state-1" ||
+		q.Get("redirect_uri") != "http://localhost/callback" {
+		t.Fatalf("授权地址错误：%s", raw)
+	}
+}
+
+func TestGithubLogin(t *testing.T) {
+	srv := newGithubStub(t)
+	svc := newTestGithub(srv, "secret")
+	ctx := context.Background()
+
+	token, err := svc.Exchange(ctx, "good-code")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if token.AccessToken != "token-1" {
+		t.Fatalf("访问令牌错误：%q", token.AccessToken)
+	}
+
+	info, err := svc.UserInfo(ctx, token)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Provider != "github" || info.OpenId != "42" || info.NickName != "octocat" {
+		t.Fatalf("用户信息错误：%+v", info)
+	}
+}
+
+func TestGithubErrors(t *testing.T) {
+	srv := newGithubStub(t)
+	ctx := context.Background()
+
+	testCases := []struct {
+		name string
+		svc  *GithubService
+		code string
+	}{
+		{name: "授权码无效", svc: newTestGithub(srv, "secret"), code: "bad-code"},
+		{name: "密钥错误", svc: newTestGithub(srv, "wrong"), code: "good-code"},
+		{name: "HTTP 状态码错误", svc: newTestGithub(srv, "secret"), code: "server-error"},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := tc.svc.Exchange(ctx, tc.code)
+			if !errors.Is(err, ErrOAuth2Failed) {
+				t.Fatalf("期望 ErrOAuth2Failed，实际 %v", err)
+			}
+		})
+	}
+
+	svc := newTestGithub(srv, "secret")
+	if _, err := svc.UserInfo(ctx, Token{AccessToken: "expired"}); !errors.Is(err, ErrOAuth2Failed) {
+		t.Fatalf("期望 ErrOAuth2Failed，实际 %v", err)
+	}
+	if _, err := svc.UserInfo(ctx, Token{AccessToken: "empty"}); !errors.Is(err, ErrOAuth2Failed) {
+		t.Fatalf("期望 ErrOAuth2Failed，实际 %v", err)
+	}
+}
@@ -0,0 +1,52 @@
+package oauth2
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/Linxhhh/webook/internal/domain"
+)
+
+var ErrOAuth2Failed = errors.New("第三方授权失败")
+
+// Token 授权码换取的访问令牌，部分平台（例如微信）会同时返回用户标识
+type Token struct {
+	AccessToken string
+	OpenId      string
+	UnionId     string
+}
+
+/*
+Service 第三方登录接口：
+AuthURL 生成授权地址，Exchange 使用授权码换取访问令牌，UserInfo 获取用户信息
+*/
+type Service interface {
+	Name() string
+	AuthURL(ctx context.Context, state string) (string, error)
+	Exchange(ctx context.Context, code string) (Token, error)
+	UserInfo(ctx context.Context, token Token) (domain.OAuth2Info, error)
+}
+
+// Endpoint 第三方平台的接口地址，测试时可以替换为本地服务
+type Endpoint struct {
+	AuthURL  string
+	TokenURL string
+	UserURL  string
+}
+
+// doJSON 发送请求并解析 JSON 响应
+func doJSON(client *http.Client, req *http.Request, v any) error {
+	req.Header.Set("Accept", "application/json")
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("%w：HTTP 状态码 %d", ErrOAuth2Failed, resp.StatusCode)
+	}
+	return json.NewDecoder(resp.Body).Decode(v)
+}
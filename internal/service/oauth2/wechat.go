@@ -0,0 +1,100 @@
+package oauth2
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+
+	"github.com/Linxhhh/webook/internal/domain"
+)
+
+var WechatEndpoint = Endpoint{
+	AuthURL:  "https://open.weixin.qq.com/connect/qrconnect",
+	TokenURL: "https://api.weixin.qq.com/sns/oauth2/access_token",
+	UserURL:  "https://api.weixin.qq.com/sns/userinfo",
+}
+
+type WechatService struct {
+	appId       string
+	appSecret   string
+	redirectURL string
+	endpoint    Endpoint
+	client      *http.Client
+}
+
+func NewWechatService(appId, appSecret, redirectURL string, endpoint Endpoint, client *http.Client) *WechatService {
+	return &WechatService{
+		appId:       appId,
+		appSecret:   appSecret,
+		redirectURL: redirectURL,
+		endpoint:    endpoint,
+		client:      client,
+	}
+}
+
+func (svc *WechatService) Name() string {
+	return "wechat"
+}
+
+// AuthURL 微信要求参数按照固定顺序，并且以 #wechat_redirect 结尾
+func (svc *WechatService) AuthURL(ctx context.Context, state string) (string, error) {
+	const pattern = "%s?appid=%s&redirect_uri=%s&response_type=code&scope=snsapi_login&state=%s#wechat_redirect"
+	return fmt.Sprintf(pattern, svc.endpoint.AuthURL, svc.appId, url.QueryEscape(svc.redirectURL), url.QueryEscape(state)), nil
+}
+
+// Exchange 微信在换取访问令牌时同时返回 openid 和 unionid
+func (svc *WechatService) Exchange(ctx context.Context, code string) (Token, error) {
+	params := url.Values{}
+	params.Set("appid", svc.appId)
+	params.Set("secret", svc.appSecret)
+	params.Set("code", code)
+	params.Set("grant_type", "authorization_code")
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, svc.endpoint.TokenURL+"?"+params.Encode(), nil)
+	if err != nil {
+		return Token{}, err
+	}
+
+	var res struct {
+		AccessToken string `json:"access_token"`
+		OpenId      string `json:"openid"`
+		UnionId     string `json:"unionid"`
+		ErrCode     int    `json:"errcode"`
+		ErrMsg      string `json:"errmsg"`
+	}
+	if err = doJSON(svc.client, req, &res); err != nil {
+		return Token{}, err
+	}
+	if res.ErrCode != 0 || res.OpenId == "" {
+		return Token{}, fmt.Errorf("%w：%d %s", ErrOAuth2Failed, res.ErrCode, res.ErrMsg)
+	}
+	return Token{AccessToken: res.AccessToken, OpenId: res.OpenId, UnionId: res.UnionId}, nil
+}
+
+func (svc *WechatService) UserInfo(ctx context.Context, token Token) (domain.OAuth2Info, error) {
+	params := url.Values{}
+	params.Set("access_token", token.AccessToken)
+	params.Set("openid", token.OpenId)
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, svc.endpoint.UserURL+"?"+params.Encode(), nil)
+	if err != nil {
+		return domain.OAuth2Info{}, err
+	}
+
+	var res struct {
+		NickName string `json:"nickname"`
+		ErrCode  int    `json:"errcode"`
+		ErrMsg   string `json:"errmsg"`
+	}
+	if err = doJSON(svc.client, req, &res); err != nil {
+		return domain.OAuth2Info{}, err
+	}
+	if res.ErrCode != 0 {
+		return domain.OAuth2Info{}, fmt.Errorf("%w：%d %s", ErrOAuth2Failed, res.ErrCode, res.ErrMsg)
+	}
+	return domain.OAuth2Info{
+		Provider: svc.Name(),
+		OpenId:   token.OpenId,
+		UnionId:  token.UnionId,
+		NickName: res.NickName,
+	}, nil
+}
@@ -0,0 +1,172 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/Linxhhh/webook/internal/domain"
+	"github.com/Linxhhh/webook/internal/repository"
+	"github.com/Linxhhh/webook/internal/service/oauth2"
+)
+
+// memStateRepo 内存中的 state 存储
+type memStateRepo struct {
+	states map[string]int64
+}
+
+func (r *memStateRepo) Store(ctx context.Context, provider, state string, uid int64) error {
+	r.states[provider+":"+state] = uid
+	return nil
+}
+
+func (r *memStateRepo) Verify(ctx context.Context, provider, state string) (int64, error) {
+	uid, ok := r.states[provider+":"+state]
+	if !ok {
+		return 0, ErrInvalidOAuth2State
+	}
+	delete(r.states, provider+":"+state)
+	return uid, nil
+}
+
+// memOAuth2UserRepo 只实现第三方登录用到的方法
+type memOAuth2UserRepo struct {
+	repository.UserRepository
+	owners map[string]int64
+	nextId int64
+}
+
+func (r *memOAuth2UserRepo) SearchByOAuth2(ctx context.Context, provider, openId string) (int64, error) {
+	uid, ok := r.owners[provider+":"+openId]
+	if !ok {
+		return 0, repository.ErrUserNotFound
+	}
+	return uid, nil
+}
+
+func (r *memOAuth2UserRepo) CreateByOAuth2(ctx context.Context, info domain.OAuth2Info) (int64, error) {
+	r.nextId++
+	r.owners[info.Provider+":"+info.OpenId] = r.nextId
+	return r.nextId, nil
+}
+
+func (r *memOAuth2UserRepo) GetOAuth2Bindings(ctx context.Context, uid int64) ([]domain.OAuth2Info, error) {
+	return nil, nil
+}
+
+func (r *memOAuth2UserRepo) BindOAuth2(ctx context.Context, uid int64, info domain.OAuth2Info) error {
+	r.owners[info.Provider+":"+info.OpenId] = uid
+	return nil
+}
+
+// newOAuth2TestService 使用本地模拟的 GitHub 作为第三方平台
+func newOAuth2TestService(t *testing.T) (*OAuth2Service, *memOAuth2UserRepo) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/token", func(w http.ResponseWriter, r *http.Request) {
+		if r.FormValue("code") != "code" {
+			_, _ = w.Write([]byte(`{"error":"bad_verification_code"}`))
+			return
+		}
+		_, _ = w.Write([]byte(`{"access_token":"token"}`))
+	})
+	mux.HandleFunc("/user", func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`{"id":7,"login":"stub"}`))
+	})
+	srv := httptest.NewServer(mux)
+	t.Cleanup(srv.Close)
+
+	provider := oauth2.NewGithubService("cid", "secret", "http://localhost/callback", oauth2.Endpoint{
+		AuthURL:  srv.URL + "/authorize",
+		TokenURL: srv.URL + "/token",
+		UserURL:  srv.URL + "/user",
+	}, srv.Client())
+	userRepo := &memOAuth2UserRepo{owners: map[string]int64{}, nextId: 100}
+	svc := NewOAuth2Service([]oauth2.Service{provider}, &memStateRepo{states: map[string]int64{}}, NewUserService(userRepo))
+	return svc, userRepo
+}
+
+// authState 发起授权，返回授权地址中的 state
+func authState(t *testing.T, svc *OAuth2Service, uid int64) string {
+	raw, state, err := svc.AuthURL(context.Background(), "github", uid)
+	if err != nil {
+		t.Fatal(err)
+	}
+	u, err := url.Parse(raw)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if u.Query().Get("state") != state {
+		t.Fatalf("授权地址中的 state 错误：%s", raw)
+	}
+	return state
+}
+
+func TestOAuth2Login(t *testing.T) {
+	svc, _ := newOAuth2TestService(t)
+	ctx := context.Background()
+
+	// 首次登录创建用户，再次登录返回同一个用户
+	uid, err := svc.Login(ctx, "github", "code", authState(t, svc, 0))
+	if err != nil {
+		t.Fatal(err)
+	}
+	again, err := svc.Login(ctx, "github", "code", authState(t, svc, 0))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if uid != 101 || again != uid {
+		t.Fatalf("用户 ID 错误：%d %d", uid, again)
+	}
+
+	// state 只能使用一次
+	state := authState(t, svc, 0)
+	if _, err = svc.Login(ctx, "github", "code", state); err != nil {
+		t.Fatal(err)
+	}
+	if _, err = svc.Login(ctx, "github", "code", state); !errors.Is(err, ErrInvalidOAuth2State) {
+		t.Fatalf("期望 ErrInvalidOAuth2State，实际 %v", err)
+	}
+
+	// 不支持的平台、伪造的 state、无效的授权码
+	if _, err = svc.Login(ctx, "gitlab", "code", state); !errors.Is(err, ErrOAuth2ProviderNotFound) {
+		t.Fatalf("期望 ErrOAuth2ProviderNotFound，实际 %v", err)
+	}
+	if _, err = svc.Login(ctx, "github", "code", "forged"); !errors.Is(err, ErrInvalidOAuth2State) {
+		t.Fatalf("期望 ErrInvalidOAuth2State，实际 %v", err)
+	}
+	if _, err = svc.Login(ctx, "github", "bad", authState(t, svc, 0)); !errors.Is(err, ErrOAuth2Failed) {
+		t.Fatalf("期望 ErrOAuth2Failed，实际 %v", err)
+	}
+}
+
+func TestOAuth2StateOwner(t *testing.T) {
+	svc, userRepo := newOAuth2TestService(t)
+	ctx := context.Background()
+
+	// 登录的 state 不能用于绑定，其他用户的 state 也不能用于绑定
+	if err := svc.Bind(ctx, 1, "github", "code", authState(t, svc, 0)); !errors.Is(err, ErrInvalidOAuth2State) {
+		t.Fatalf("期望 ErrInvalidOAuth2State，实际 %v", err)
+	}
+	if err := svc.Bind(ctx, 1, "github", "code", authState(t, svc, 2)); !errors.Is(err, ErrInvalidOAuth2State) {
+		t.Fatalf("期望 ErrInvalidOAuth2State，实际 %v", err)
+	}
+
+	// 绑定的 state 不能用于登录
+	if _, err := svc.Login(ctx, "github", "code", authState(t, svc, 1)); !errors.Is(err, ErrInvalidOAuth2State) {
+		t.Fatalf("期望 ErrInvalidOAuth2State，实际 %v", err)
+	}
+	if len(userRepo.owners) != 0 {
+		t.Fatalf("校验失败时不能绑定或创建用户：%v", userRepo.owners)
+	}
+
+	// 当前用户发起的授权可以绑定
+	if err := svc.Bind(ctx, 1, "github", "code", authState(t, svc, 1)); err != nil {
+		t.Fatal(err)
+	}
+	if userRepo.owners["github:7"] != 1 {
+		t.Fatalf("绑定失败：%v", userRepo.owners)
+	}
+}
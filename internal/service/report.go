@@ -0,0 +1,94 @@
+package service
+
+import (
+	"context"
+	"errors"
+
+	"github.com/Linxhhh/webook/internal/domain"
+	"github.com/Linxhhh/webook/internal/repository"
+)
+
+var (
+	ErrDuplicateReport      = repository.ErrDuplicateReport
+	ErrReportTargetNotFound = errors.New("举报对象不存在")
+	ErrReportSelf           = errors.New("不能举报自己")
+)
+
+/*
+ReportService 举报：
+同一个用户对同一个资源只能举报一次，帖子待处理的举报达到阈值之后，
+线上库的帖子自动隐藏，等待管理员处理
+*/
+type ReportService struct {
+	repo        repository.ReportRepository
+	artRepo     repository.ArticleRepository
+	commentRepo repository.CommentRepository
+	userRepo    repository.UserRepository
+
+	hideThreshold int64 // 自动隐藏帖子的举报数量
+}
+
+func NewReportService(repo repository.ReportRepository, artRepo repository.ArticleRepository,
+	commentRepo repository.CommentRepository, userRepo repository.UserRepository, hideThreshold int64) *ReportService {
+	return &ReportService{
+		repo:          repo,
+		artRepo:       artRepo,
+		commentRepo:   commentRepo,
+		userRepo:      userRepo,
+		hideThreshold: hideThreshold,
+	}
+}
+
+// Report 举报资源，不能举报自己发表的内容
+func (svc *ReportService) Report(ctx context.Context, r domain.Report) error {
+	owner, err := svc.targetOwner(ctx, r.Biz, r.BizId)
+	if err != nil {
+		return err
+	}
+	if owner == r.Uid {
+		return ErrReportSelf
+	}
+
+	cnt, err := svc.repo.Create(ctx, r)
+	if err != nil {
+		return err
+	}
+
+	// 举报数量达到阈值，隐藏线上库的帖子
+	if r.Biz == domain.ReportBizArticle && cnt >= svc.hideThreshold {
+		_, err = svc.artRepo.UpdatePubStatus(ctx, r.BizId, domain.ArticleStatusPublished, domain.ArticleStatusHidden)
+	}
+	return err
+}
+
+// targetOwner 校验被举报的资源，返回资源的所有者
+func (svc *ReportService) targetOwner(ctx context.Context, biz string, bizId int64) (int64, error) {
+	switch biz {
+	case domain.ReportBizArticle:
+		art, err := svc.artRepo.GetPubById(ctx, bizId)
+		if errors.Is(err, repository.ErrArticleNotFound) {
+			return 0, ErrReportTargetNotFound
+		}
+		if err != nil {
+			return 0, err
+		}
+		if art.Status != domain.ArticleStatusPublished {
+			return 0, ErrReportTargetNotFound
+		}
+		return art.AuthorId, nil
+	case domain.ReportBizComment:
+		c, err := svc.commentRepo.GetById(ctx, bizId)
+		if errors.Is(err, repository.ErrCommentNotFound) {
+			return 0, ErrReportTargetNotFound
+		}
+		return c.Uid, err
+	case domain.ReportBizUser:
+		u, err := svc.userRepo.SearchById(ctx, bizId)
+		if errors.Is(err, repository.ErrUserNotFound) {
+			return 0, ErrReportTargetNotFound
+		}
+		return u.Id, err
+	default:
+		return 0, ErrReportTargetNotFound
+	}
+}
@@ -0,0 +1,122 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/Linxhhh/webook/internal/domain"
+	"github.com/Linxhhh/webook/internal/repository"
+)
+
+var (
+	ErrSessionRevoked  = errors.New("会话已失效")
+	ErrSessionNotFound = repository.ErrSessionNotFound
+)
+
+type SessionService struct {
+	repo         repository.SessionRepository
+	seenInterval time.Duration // 活跃时间的回写间隔
+}
+
+func NewSessionService(repo repository.SessionRepository) *SessionService {
+	return &SessionService{
+		repo:         repo,
+		seenInterval: time.Minute,
+	}
+}
+
+// Create 记录一次登录，根据 UserAgent 识别设备
+func (svc *SessionService) Create(ctx context.Context, s domain.Session) error {
+	s.Device = deviceName(s.UserAgent)
+	_, err := svc.repo.Create(ctx, s)
+	return err
+}
+
+// List 获取用户当前登录的设备，ssid 为发起请求的会话
+func (svc *SessionService) List(ctx context.Context, uid int64, ssid string) ([]domain.Session, error) {
+	list, err := svc.repo.GetActiveList(ctx, uid)
+	if err != nil {
+		return nil, err
+	}
+	for i := range list {
+		list[i].Current = list[i].Ssid == ssid
+	}
+	return list, nil
+}
+
+/*
+Logout 退出登录：
+把会话 ID 加入黑名单，expiration 为会话的最长有效期，之后该会话的长短令牌都无法使用
+*/
+func (svc *SessionService) Logout(ctx context.Context, ssid string, expiration time.Duration) error {
+	return svc.repo.Revoke(ctx, ssid, expiration)
+}
+
+// Kick 让用户的某个设备下线，会话不属于该用户时返回 ErrSessionNotFound
+func (svc *SessionService) Kick(ctx context.Context, uid int64, id int64) error {
+	s, err := svc.repo.GetById(ctx, uid, id)
+	if err != nil {
+		return err
+	}
+	expiration := time.Until(s.ExpireAt)
+	if expiration <= 0 {
+		// 会话已经过期，令牌本身已经失效
+		return nil
+	}
+	return svc.repo.Revoke(ctx, s.Ssid, expiration)
+}
+
+// RevokeAll 让用户的所有设备下线，except 不为空时保留该会话
+func (svc *SessionService) RevokeAll(ctx context.Context, uid int64, except string) error {
+	list, err := svc.repo.GetActiveList(ctx, uid)
+	if err != nil {
+		return err
+	}
+	for _, s := range list {
+		expiration := time.Until(s.ExpireAt)
+		if expiration <= 0 || s.Ssid == except {
+			continue
+		}
+		if err = svc.repo.Revoke(ctx, s.Ssid, expiration); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Check 校验会话是否有效，会话已经退出登录时返回 ErrSessionRevoked
+func (svc *SessionService) Check(ctx context.Context, ssid string) error {
+	revoked, err := svc.repo.IsRevoked(ctx, ssid)
+	if err != nil {
+		return err
+	}
+	if revoked {
+		return ErrSessionRevoked
+	}
+	return nil
+}
+
+// Touch 更新会话的最近活跃时间
+func (svc *SessionService) Touch(ctx context.Context, ssid string) error {
+	return svc.repo.Touch(ctx, ssid, svc.seenInterval)
+}
+
+// deviceName 根据 UserAgent 粗略识别设备类型
+func deviceName(ua string) string {
+	devices := []struct{ keyword, name string }{
+		{"iPhone", "iPhone"},
+		{"iPad", "iPad"},
+		{"Android", "Android"},
+		{"Windows", "Windows"},
+		{"Macintosh", "Mac"},
+		{"Linux", "Linux"},
+	}
+	for _, d := range devices {
+		if strings.Contains(ua, d.keyword) {
+			return d.name
+		}
+	}
+	return "未知设备"
+}
@@ -0,0 +1,134 @@
+package sms
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha1"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+)
+
+const AliyunEndpoint = "https://dysmsapi.aliyuncs.com/"
+
+type AliyunService struct {
+	accessKeyId     string
+	accessKeySecret string
+	regionId        string
+	signName        string
+	tpls            Templates
+	endpoint        string
+	client          *http.Client
+}
+
+func NewAliyunService(accessKeyId, accessKeySecret, regionId, signName string, tpls Templates, endpoint string, client *http.Client) *AliyunService {
+	return &AliyunService{
+		accessKeyId:     accessKeyId,
+		accessKeySecret: accessKeySecret,
+		regionId:        regionId,
+		signName:        signName,
+		tpls:            tpls,
+		endpoint:        endpoint,
+		client:          client,
+	}
+}
+
+/*
+Send 短信发送服务：
+调用阿里云 SendSms 接口（RPC 风格，HMAC-SHA1 签名），模板参数按照模板映射转换为 JSON 对象
+*/
+func (svc *AliyunService) Send(ctx context.Context, tplId string, args []string, numbers ...string) error {
+
+	// 转换模板
+	code, params, err := svc.tpls.resolve(tplId, args)
+	if err != nil {
+		return err
+	}
+	param, err := json.Marshal(params)
+	if err != nil {
+		return err
+	}
+
+	// 组装请求参数
+	query := url.Values{}
+	query.Set("AccessKeyId", svc.accessKeyId)
+	query.Set("Action", "SendSms")
+	query.Set("Format", "JSON")
+	query.Set("PhoneNumbers", strings.Join(numbers, ","))
+	query.Set("RegionId", svc.regionId)
+	query.Set("SignName", svc.signName)
+	query.Set("SignatureMethod", "HMAC-SHA1")
+	query.Set("SignatureNonce", nonce())
+	query.Set("SignatureVersion", "1.0")
+	query.Set("TemplateCode", code)
+	query.Set("TemplateParam", string(param))
+	query.Set("Timestamp", time.Now().UTC().Format("2006-01-02T15:04:05Z"))
+	query.Set("Version", "2017-05-25")
+	query.Set("Signature", svc.sign(http.MethodPost, query))
+
+	// 发送短信
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, svc.endpoint, strings.NewReader(query.Encode()))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	resp, err := svc.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	// 检查短信状态
+	var res struct {
+		Code      string `json:"Code"`
+		Message   string `json:"Message"`
+		RequestId string `json:"RequestId"`
+	}
+	if err = json.NewDecoder(resp.Body).Decode(&res); err != nil {
+		return fmt.Errorf("解析阿里云短信响应失败，HTTP 状态码 %d：%w", resp.StatusCode, err)
+	}
+	if resp.StatusCode != http.StatusOK || res.Code != "OK" {
+		return fmt.Errorf("发送短信失败 status: %d, code: %s, msg: %s, requestId: %s", resp.StatusCode, res.Code, res.Message, res.RequestId)
+	}
+	return nil
+}
+
+// sign 计算请求签名：参数按照名称排序后编码，再使用 AccessKeySecret& 作为密钥进行 HMAC-SHA1
+func (svc *AliyunService) sign(method string, query url.Values) string {
+	keys := make([]string, 0, len(query))
+	for k := range query {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	pairs := make([]string, 0, len(keys))
+	for _, k := range keys {
+		pairs = append(pairs, aliyunEncode(k)+"="+aliyunEncode(query.Get(k)))
+	}
+	str := method + "&" + aliyunEncode("/") + "&" + aliyunEncode(strings.Join(pairs, "&"))
+
+	mac := hmac.New(sha1.New, []byte(svc.accessKeySecret+"&"))
+	mac.Write([]byte(str))
+	return base64.StdEncoding.EncodeToString(mac.Sum(nil))
+}
+
+// aliyunEncode 阿里云要求的 URL 编码（RFC 3986）
+func aliyunEncode(s string) string {
+	s = url.QueryEscape(s)
+	s = strings.ReplaceAll(s, "+", "%20")
+	s = strings.ReplaceAll(s, "*", "%2A")
+	return strings.ReplaceAll(s, "%7E", "~")
+}
+
+func nonce() string {
+	b := make([]byte, 16)
+	_, _ = rand.Read(b)
+	return hex.EncodeToString(b)
+}
@@ -0,0 +1,142 @@
+package sms
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha1"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sort"
+	"strings"
+	"testing"
+)
+
+var aliyunTpls = Templates{
+	"1001": {Id: "SMS_123", Params: []string{"code", "minutes"}},
+}
+
+/*
+aliyunStub 本地模拟阿里云 SendSms 接口：
+按照文档独立计算签名并校验，成功时把收到的参数交给 check，再返回 status 和 body
+*/
+func aliyunStub(t *testing.T, status int, body string, check func(form url.Values)) *httptest.Server {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			t.Errorf("请求方法错误：%s", r.Method)
+		}
+		if err := r.ParseForm(); err != nil {
+			t.Fatal(err)
+		}
+		if got, want := r.PostForm.Get("Signature"), expectedAliyunSignature(r.PostForm, "secret"); got != want {
+			w.WriteHeader(http.StatusBadRequest)
+			_, _ = w.Write([]byte(`{"Code":"SignatureDoesNotMatch","Message":"Specified signature is not matched"}`))
+			return
+		}
+		if check != nil {
+			check(r.PostForm)
+		}
+		w.WriteHeader(status)
+		_, _ = w.Write([]byte(body))
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+// expectedAliyunSignature 签名字符串为 POST&%2F&<排序并编码后的参数>，密钥为 AccessKeySecret&
+func expectedAliyunSignature(form url.Values, secret string) string {
+	keys := make([]string, 0, len(form))
+	for k := range form {
+		if k != "Signature" {
+			keys = append(keys, k)
+		}
+	}
+	sort.Strings(keys)
+	enc := func(s string) string {
+		return strings.NewReplacer("+", "%20", "*", "%2A", "%7E", "~").Replace(url.QueryEscape(s))
+	}
+	pairs := make([]string, 0, len(keys))
+	for _, k := range keys {
+		pairs = append(pairs, enc(k)+"="+enc(form.Get(k)))
+	}
+	mac := hmac.New(sha1.New, []byte(secret+"&"))
+	mac.Write([]byte("POST&%2F&" + enc(strings.Join(pairs, "&"))))
+	return base64.StdEncoding.EncodeToString(mac.Sum(nil))
+}
+
+func TestAliyunSend(t *testing.T) {
+	srv := aliyunStub(t, http.StatusOK, `{"Code":"OK","Message":"OK","RequestId":"r1"}`, func(form url.Values) {
+		want := map[string]string{
+			"AccessKeyId":      "key",
+			"Action":           "SendSms",
+			"PhoneNumbers":     "13800000000,13900000000",
+			"RegionId":         "cn-hangzhou",
+			"SignName":         "webook",
+			"SignatureMethod":  "HMAC-SHA1",
+			"SignatureVersion": "1.0",
+			"TemplateCode":     "SMS_123",
+		}
+		for k, v := range want {
+			if form.Get(k) != v {
+				t.Errorf("参数 %s 错误：%q", k, form.Get(k))
+			}
+		}
+		var params map[string]string
+		if err := json.Unmarshal([]byte(form.Get("TemplateParam")), &params); err != nil {
+			t.Fatal(err)
+		}
+		if params["code"] != "123456" || params["minutes"] != "5" || len(params) != 2 {
+			t.Errorf("模板参数错误：%v", params)
+		}
+	})
+
+	svc := NewAliyunService("key", "secret", "cn-hangzhou", "webook", aliyunTpls, srv.URL+"/", srv.Client())
+	err := svc.Send(context.Background(), "1001", []string{"123456", "5"}, "13800000000", "13900000000")
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestAliyunSendErrors(t *testing.T) {
+	testCases := []struct {
+		name   string
+		secret string
+		status int
+		body   string
+		tplId  string
+		args   []string
+		want   error
+		msg    string
+	}{
+		{name: "服务商返回错误码", secret: "secret", status: http.StatusOK,
+			body:  `{"Code":"isv.BUSINESS_LIMIT_CONTROL","Message":"触发流控","RequestId":"r2"}`,
+			tplId: "1001", args: []string{"1", "2"}, msg: "isv.BUSINESS_LIMIT_CONTROL"},
+		{name: "签名错误", secret: "wrong", status: http.StatusOK, body: `{"Code":"OK"}`,
+			tplId: "1001", args: []string{"1", "2"}, msg: "SignatureDoesNotMatch"},
+		{name: "HTTP 状态码错误", secret: "secret", status: http.StatusServiceUnavailable, body: `{"Code":"OK"}`,
+			tplId: "1001", args: []string{"1", "2"}, msg: "503"},
+		{name: "响应不是 JSON", secret: "secret", status: http.StatusBadGateway, body: `<html>bad gateway</html>`,
+			tplId: "1001", args: []string{"1", "2"}, msg: "502"},
+		{name: "模板不存在", secret: "secret", tplId: "9999", args: []string{"1"}, want: ErrUnknownTemplate},
+		{name: "模板参数数量不匹配", secret: "secret", tplId: "1001", args: []string{"1"}, want: ErrTemplateArgs},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			srv := aliyunStub(t, tc.status, tc.body, nil)
+			svc := NewAliyunService("key", tc.secret, "cn-hangzhou", "webook", aliyunTpls, srv.URL+"/", srv.Client())
+			err := svc.Send(context.Background(), tc.tplId, tc.args, "13800000000")
+			if err == nil {
+				t.Fatal("期望发送失败")
+			}
+			if tc.want != nil && !errors.Is(err, tc.want) {
+				t.Fatalf("期望 %v，实际 %v", tc.want, err)
+			}
+			if tc.msg != "" && !strings.Contains(err.Error(), tc.msg) {
+				t.Fatalf("错误信息中缺少 %q：%v", tc.msg, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,121 @@
+package async
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/Linxhhh/webook/internal/domain"
+	"github.com/Linxhhh/webook/internal/repository"
+	"github.com/Linxhhh/webook/internal/service/sms"
+)
+
+/*
+Service 异步重试装饰器：
+同步发送失败时，把短信存入数据库并视为发送成功；
+后台任务每隔 interval 抢占待重试的短信重新发送，最多重试 retryMax 次
+*/
+type Service struct {
+	svc      sms.Service
+	repo     repository.AsyncSmsRepository
+	interval time.Duration
+	retryMax int
+
+	ctx    context.Context
+	cancel context.CancelFunc
+	done   chan struct{}
+}
+
+func NewService(svc sms.Service, repo repository.AsyncSmsRepository, interval time.Duration, retryMax int) *Service {
+	ctx, cancel := context.WithCancel(context.Background())
+	return &Service{
+		svc:      svc,
+		repo:     repo,
+		interval: interval,
+		retryMax: retryMax,
+		ctx:      ctx,
+		cancel:   cancel,
+		done:     make(chan struct{}),
+	}
+}
+
+func (s *Service) Send(ctx context.Context, tplId string, args []string, numbers ...string) error {
+	err := s.svc.Send(ctx, tplId, args, numbers...)
+	if err == nil {
+		return nil
+	}
+
+	// 调用方已经放弃的请求不再重试
+	if errors.Is(err, context.Canceled) {
+		return err
+	}
+
+	// 存入数据库，等待异步重试
+	addErr := s.repo.Add(context.WithoutCancel(ctx), domain.AsyncSms{
+		TplId:    tplId,
+		Args:     args,
+		Numbers:  numbers,
+		RetryMax: s.retryMax,
+	})
+	if addErr != nil {
+		return fmt.Errorf("%w，转为异步发送失败：%w", err, addErr)
+	}
+	log.Printf("短信发送失败，转为异步发送，err : %s", err)
+	return nil
+}
+
+// Start 启动异步重试任务
+func (s *Service) Start() error {
+	go s.loop()
+	return nil
+}
+
+// Close 停止异步重试任务，等待正在发送的短信完成
+func (s *Service) Close() error {
+	s.cancel()
+	<-s.done
+	return nil
+}
+
+func (s *Service) loop() {
+	defer close(s.done)
+	ticker := time.NewTicker(s.interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-s.ctx.Done():
+			return
+		case <-ticker.C:
+			s.retry()
+		}
+	}
+}
+
+// retry 发送所有到期的短信，直到没有可抢占的短信或者任务被关闭
+func (s *Service) retry() {
+	for s.ctx.Err() == nil {
+		msg, err := s.repo.Preempt(s.ctx, s.interval)
+		if err == repository.ErrNoAsyncSms {
+			return
+		}
+		if err != nil {
+			log.Printf("抢占异步短信失败，err : %s", err)
+			return
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		err = s.svc.Send(ctx, msg.TplId, msg.Args, msg.Numbers...)
+		if err == nil {
+			err = s.repo.MarkSuccess(ctx, msg.Id)
+		} else {
+			log.Printf("异步短信发送失败，id : %d，err : %s", msg.Id, err)
+			err = s.repo.MarkFailed(ctx, msg)
+		}
+		cancel()
+		if err != nil {
+			log.Printf("更新异步短信状态失败，id : %d，err : %s", msg.Id, err)
+		}
+	}
+}
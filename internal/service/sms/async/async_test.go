@@ -0,0 +1,221 @@
+package async
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/Linxhhh/webook/internal/domain"
+	"github.com/Linxhhh/webook/internal/repository"
+)
+
+// 内存中的异步短信状态，和 dao 中的状态含义一致
+const (
+	waiting = iota
+	sending
+	success
+	failed
+)
+
+type memSms struct {
+	sms    domain.AsyncSms
+	status int
+	round  int // 最近一次发送的轮次，同一轮只抢占一次，相当于 dao 中的 utime
+}
+
+/*
+memAsyncSmsRepo 内存中的异步短信存储：
+每一轮（一次 retry）中每条等待的短信最多被抢占一次，MarkFailed 的规则和 GormAsyncSmsDAO 一致
+*/
+type memAsyncSmsRepo struct {
+	mu    sync.Mutex
+	msgs  []*memSms
+	round int
+}
+
+func (r *memAsyncSmsRepo) Add(ctx context.Context, s domain.AsyncSms) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	s.Id = int64(len(r.msgs) + 1)
+	r.msgs = append(r.msgs, &memSms{sms: s, status: waiting, round: -1})
+	return nil
+}
+
+func (r *memAsyncSmsRepo) Preempt(ctx context.Context, interval time.Duration) (domain.AsyncSms, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, m := range r.msgs {
+		if m.status == waiting && m.round < r.round {
+			m.status = sending
+			m.round = r.round
+			return m.sms, nil
+		}
+	}
+	return domain.AsyncSms{}, repository.ErrNoAsyncSms
+}
+
+func (r *memAsyncSmsRepo) MarkSuccess(ctx context.Context, id int64) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.msgs[id-1].status = success
+	return nil
+}
+
+func (r *memAsyncSmsRepo) MarkFailed(ctx context.Context, s domain.AsyncSms) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	m := r.msgs[s.Id-1]
+	m.sms.RetryCnt = s.RetryCnt + 1
+	m.status = waiting
+	if m.sms.RetryCnt >= s.RetryMax {
+		m.status = failed
+	}
+	return nil
+}
+
+// nextRound 进入下一轮，相当于过了 interval
+func (r *memAsyncSmsRepo) nextRound() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.round++
+}
+
+func (r *memAsyncSmsRepo) get(id int64) memSms {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return *r.msgs[id-1]
+}
+
+// stubSms 前 fails 次发送失败
+type stubSms struct {
+	fails int32
+	calls int32
+}
+
+func (s *stubSms) Send(ctx context.Context, tplId string, args []string, numbers ...string) error {
+	if atomic.AddInt32(&s.calls, 1) <= atomic.LoadInt32(&s.fails) {
+		return errors.New("服务商异常")
+	}
+	return nil
+}
+
+func TestAsyncSend(t *testing.T) {
+	repo := &memAsyncSmsRepo{}
+	svc := NewService(&stubSms{}, repo, time.Minute, 3)
+
+	// 同步发送成功时不存入数据库
+	if err := svc.Send(context.Background(), "1001", []string{"1"}, "13800000000"); err != nil {
+		t.Fatal(err)
+	}
+	if len(repo.msgs) != 0 {
+		t.Fatalf("发送成功不应该存入数据库：%d", len(repo.msgs))
+	}
+
+	// 同步发送失败时存入数据库并视为成功
+	svc.svc = &stubSms{fails: 1}
+	if err := svc.Send(context.Background(), "1001", []string{"1"}, "13800000000", "13900000000"); err != nil {
+		t.Fatal(err)
+	}
+	got := repo.get(1).sms
+	if got.TplId != "1001" || got.Args[0] != "1" || len(got.Numbers) != 2 || got.RetryMax != 3 {
+		t.Fatalf("存入的短信错误：%+v", got)
+	}
+}
+
+func TestAsyncRetry(t *testing.T) {
+	testCases := []struct {
+		name      string
+		fails     int32 // 同步发送和重试一共失败的次数
+		wantCalls int32
+		wantCnt   int
+		want      int
+	}{
+		{name: "第一次重试成功", fails: 1, wantCalls: 2, wantCnt: 0, want: success},
+		{name: "最后一次重试成功", fails: 3, wantCalls: 4, wantCnt: 2, want: success},
+		{name: "达到最大重试次数", fails: 100, wantCalls: 4, wantCnt: 3, want: failed},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			repo := &memAsyncSmsRepo{}
+			stub := &stubSms{fails: tc.fails}
+			svc := NewService(stub, repo, time.Minute, 3)
+
+			if err := svc.Send(context.Background(), "1001", []string{"1"}, "13800000000"); err != nil {
+				t.Fatal(err)
+			}
+
+			// 重试的轮数多于 retryMax，达到最大重试次数之后不再发送
+			for i := 0; i < 10; i++ {
+				repo.nextRound()
+				svc.retry()
+			}
+			if c := atomic.LoadInt32(&stub.calls); c != tc.wantCalls {
+				t.Fatalf("发送次数错误：期望 %d，实际 %d", tc.wantCalls, c)
+			}
+			got := repo.get(1)
+			if got.status != tc.want || got.sms.RetryCnt != tc.wantCnt {
+				t.Fatalf("状态错误：%+v", got)
+			}
+		})
+	}
+}
+
+func TestAsyncCanceled(t *testing.T) {
+	repo := &memAsyncSmsRepo{}
+	svc := NewService(stubFunc(func(ctx context.Context) error { return ctx.Err() }), repo, time.Minute, 3)
+
+	// 调用方已经放弃的请求不再重试
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if err := svc.Send(ctx, "1001", nil, "13800000000"); !errors.Is(err, context.Canceled) {
+		t.Fatalf("期望 Canceled，实际 %v", err)
+	}
+	if len(repo.msgs) != 0 {
+		t.Fatalf("取消的请求不应该存入数据库：%d", len(repo.msgs))
+	}
+
+	// 超时的请求转为异步发送
+	ctx, cancel = context.WithTimeout(context.Background(), time.Nanosecond)
+	defer cancel()
+	<-ctx.Done()
+	if err := svc.Send(ctx, "1001", nil, "13800000000"); err != nil {
+		t.Fatal(err)
+	}
+	if len(repo.msgs) != 1 {
+		t.Fatalf("超时的请求应该存入数据库：%d", len(repo.msgs))
+	}
+}
+
+type stubFunc func(ctx context.Context) error
+
+func (f stubFunc) Send(ctx context.Context, tplId string, args []string, numbers ...string) error {
+	return f(ctx)
+}
+
+func TestAsyncStartClose(t *testing.T) {
+	repo := &memAsyncSmsRepo{}
+	stub := &stubSms{fails: 1}
+	svc := NewService(stub, repo, 5*time.Millisecond, 3)
+	if err := svc.Send(context.Background(), "1001", nil, "13800000000"); err != nil {
+		t.Fatal(err)
+	}
+
+	// 后台任务按照 interval 重试
+	if err := svc.Start(); err != nil {
+		t.Fatal(err)
+	}
+	deadline := time.Now().Add(time.Second)
+	for repo.get(1).status != success && time.Now().Before(deadline) {
+		repo.nextRound()
+		time.Sleep(5 * time.Millisecond)
+	}
+	if err := svc.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if repo.get(1).status != success {
+		t.Fatal("后台任务没有重试")
+	}
+}
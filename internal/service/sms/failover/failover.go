@@ -0,0 +1,109 @@
+package failover
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync/atomic"
+	"time"
+
+	"github.com/Linxhhh/webook/internal/service/sms"
+	"github.com/Linxhhh/webook/internal/service/sms/ratelimit"
+)
+
+var ErrAllFailed = errors.New("所有短信服务商都发送失败")
+
+/*
+Service 故障转移装饰器：
+每次从下一个服务商开始轮询，失败后尝试后面的服务商；
+连续失败 threshold 次的服务商在 cooldown 内被跳过，冷却期过后重新参与轮询，成功一次即恢复健康
+*/
+type Service struct {
+	svcs      []sms.Service
+	health    []*health
+	idx       uint64
+	threshold int32
+	cooldown  time.Duration
+}
+
+// health 服务商的健康状况
+type health struct {
+	fails int32 // 连续失败次数
+	until int64 // 冷却截止时间（纳秒）
+}
+
+func NewService(svcs []sms.Service, threshold int32, cooldown time.Duration) *Service {
+	hs := make([]*health, len(svcs))
+	for i := range hs {
+		hs[i] = &health{}
+	}
+	return &Service{
+		svcs:      svcs,
+		health:    hs,
+		threshold: threshold,
+		cooldown:  cooldown,
+	}
+}
+
+func (s *Service) Send(ctx context.Context, tplId string, args []string, numbers ...string) error {
+	n := uint64(len(s.svcs))
+	start := atomic.AddUint64(&s.idx, 1)
+	now := time.Now().UnixNano()
+
+	var (
+		lastErr error = ErrAllFailed
+		skipped []int
+	)
+
+	// 先尝试健康的服务商
+	for i := uint64(0); i < n; i++ {
+		j := int((start + i) % n)
+		if !s.available(j, now) {
+			skipped = append(skipped, j)
+			continue
+		}
+		err := s.send(ctx, j, tplId, args, numbers...)
+		if err == nil {
+			return nil
+		}
+		if ctx.Err() != nil {
+			return err
+		}
+		lastErr = err
+	}
+
+	// 健康的服务商都失败时，再尝试冷却中的服务商
+	for _, j := range skipped {
+		err := s.send(ctx, j, tplId, args, numbers...)
+		if err == nil {
+			return nil
+		}
+		if ctx.Err() != nil {
+			return err
+		}
+		lastErr = err
+	}
+	return fmt.Errorf("%w：%w", ErrAllFailed, lastErr)
+}
+
+// send 调用服务商并记录健康状况，触发限流不计入失败
+func (s *Service) send(ctx context.Context, i int, tplId string, args []string, numbers ...string) error {
+	err := s.svcs[i].Send(ctx, tplId, args, numbers...)
+	h := s.health[i]
+	switch {
+	case err == nil:
+		atomic.StoreInt32(&h.fails, 0)
+	case errors.Is(err, ratelimit.ErrLimited), ctx.Err() != nil:
+		// 限流和调用方取消不代表服务商异常
+	default:
+		if atomic.AddInt32(&h.fails, 1) >= s.threshold {
+			atomic.StoreInt64(&h.until, time.Now().Add(s.cooldown).UnixNano())
+		}
+	}
+	return err
+}
+
+func (s *Service) available(i int, now int64) bool {
+	h := s.health[i]
+	return atomic.LoadInt32(&h.fails) < s.threshold || now >= atomic.LoadInt64(&h.until)
+}
@@ -0,0 +1,164 @@
+package failover
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/Linxhhh/webook/internal/service/sms"
+	"github.com/Linxhhh/webook/internal/service/sms/ratelimit"
+)
+
+// stubSms 返回 err 的短信服务，记录调用次数
+type stubSms struct {
+	err   atomic.Value
+	delay time.Duration
+	calls int32
+}
+
+func newStub(err error) *stubSms {
+	s := &stubSms{}
+	s.setErr(err)
+	return s
+}
+
+func (s *stubSms) setErr(err error) {
+	s.err.Store(&err)
+}
+
+func (s *stubSms) Send(ctx context.Context, tplId string, args []string, numbers ...string) error {
+	atomic.AddInt32(&s.calls, 1)
+	if s.delay > 0 {
+		select {
+		case <-time.After(s.delay):
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+	return *s.err.Load().(*error)
+}
+
+func (s *stubSms) count() int32 {
+	return atomic.LoadInt32(&s.calls)
+}
+
+var errProvider = errors.New("服务商异常")
+
+func send(t *testing.T, svc sms.Service) error {
+	t.Helper()
+	return svc.Send(context.Background(), "1001", []string{"123456"}, "13800000000")
+}
+
+func TestFailoverSwitch(t *testing.T) {
+	bad, good := newStub(errProvider), newStub(nil)
+	svc := NewService([]sms.Service{bad, good}, 3, time.Hour)
+
+	// 失败的服务商被跳过，由后面的服务商发送
+	for i := 0; i < 10; i++ {
+		if err := send(t, svc); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// 连续失败 3 次之后，在冷却期内不再调用
+	if bad.count() != 3 {
+		t.Fatalf("失败的服务商应该只被调用 3 次，实际 %d", bad.count())
+	}
+	if good.count() != 10 {
+		t.Fatalf("健康的服务商应该被调用 10 次，实际 %d", good.count())
+	}
+}
+
+func TestFailoverCooldown(t *testing.T) {
+	bad, good := newStub(errProvider), newStub(nil)
+	svc := NewService([]sms.Service{bad, good}, 2, 50*time.Millisecond)
+
+	for i := 0; i < 6; i++ {
+		if err := send(t, svc); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if bad.count() != 2 {
+		t.Fatalf("冷却期内不应该调用，实际 %d", bad.count())
+	}
+
+	// 冷却期过后重新参与轮询，成功一次即恢复健康
+	time.Sleep(60 * time.Millisecond)
+	bad.setErr(nil)
+	for i := 0; i < 4; i++ {
+		if err := send(t, svc); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if bad.count() != 4 {
+		t.Fatalf("冷却期过后应该轮询到恢复的服务商，实际 %d", bad.count())
+	}
+	if fails := atomic.LoadInt32(&svc.health[0].fails); fails != 0 {
+		t.Fatalf("成功之后失败次数应该清零，实际 %d", fails)
+	}
+
+	// 再次失败时重新计数，不会立即进入冷却
+	bad.setErr(errProvider)
+	_ = send(t, svc)
+	_ = send(t, svc)
+	if !svc.available(0, time.Now().UnixNano()) {
+		t.Fatal("失败次数没有达到阈值，不应该进入冷却")
+	}
+}
+
+func TestFailoverAllFailed(t *testing.T) {
+	a, b := newStub(errProvider), newStub(errProvider)
+	svc := NewService([]sms.Service{a, b}, 1, time.Hour)
+
+	if err := send(t, svc); !errors.Is(err, ErrAllFailed) || !errors.Is(err, errProvider) {
+		t.Fatalf("期望 ErrAllFailed，实际 %v", err)
+	}
+
+	// 都在冷却中时，仍然尝试冷却中的服务商
+	b.setErr(nil)
+	if err := send(t, svc); err != nil {
+		t.Fatal(err)
+	}
+	if b.count() != 2 {
+		t.Fatalf("冷却中的服务商应该被尝试，实际 %d", b.count())
+	}
+}
+
+func TestFailoverIgnoreLimited(t *testing.T) {
+	limited, good := newStub(ratelimit.ErrLimited), newStub(nil)
+	svc := NewService([]sms.Service{limited, good}, 1, time.Hour)
+
+	for i := 0; i < 4; i++ {
+		if err := send(t, svc); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// 触发限流不计入失败，不会进入冷却，轮询到时仍然优先尝试
+	if limited.count() != 2 || atomic.LoadInt32(&svc.health[0].fails) != 0 {
+		t.Fatalf("限流不应该计入失败：%d %d", limited.count(), svc.health[0].fails)
+	}
+}
+
+func TestFailoverCanceled(t *testing.T) {
+	a, b := newStub(nil), newStub(nil)
+	a.delay, b.delay = time.Second, time.Second
+	svc := NewService([]sms.Service{a, b}, 1, time.Hour)
+
+	// 调用方取消时直接返回，不尝试其他服务商，也不计入失败
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if err := svc.Send(ctx, "1001", nil, "13800000000"); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("期望 DeadlineExceeded，实际 %v", err)
+	}
+	if a.count()+b.count() != 1 {
+		t.Fatalf("取消之后不应该继续尝试：%d %d", a.count(), b.count())
+	}
+	for i := range svc.health {
+		if !svc.available(i, time.Now().UnixNano()) {
+			t.Fatalf("取消不应该计入失败：%d", i)
+		}
+	}
+}
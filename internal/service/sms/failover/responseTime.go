@@ -0,0 +1,61 @@
+package failover
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"time"
+
+	"github.com/Linxhhh/webook/internal/service/sms"
+)
+
+/*
+ResponseTimeService 响应时间装饰器：
+只使用当前服务商发送，记录其平均响应时间（指数加权），
+平均响应时间超过 threshold 或者请求超时时，切换到下一个服务商
+*/
+type ResponseTimeService struct {
+	svcs      []sms.Service
+	idx       int32
+	avg       []int64 // 平均响应时间（纳秒），0 表示还没有数据
+	threshold time.Duration
+}
+
+func NewResponseTimeService(svcs []sms.Service, threshold time.Duration) *ResponseTimeService {
+	return &ResponseTimeService{
+		svcs:      svcs,
+		avg:       make([]int64, len(svcs)),
+		threshold: threshold,
+	}
+}
+
+func (s *ResponseTimeService) Send(ctx context.Context, tplId string, args []string, numbers ...string) error {
+	idx := atomic.LoadInt32(&s.idx)
+	start := time.Now()
+	err := s.svcs[idx].Send(ctx, tplId, args, numbers...)
+	avg := s.record(idx, time.Since(start))
+
+	// 响应变慢或者超时，切换服务商
+	if avg > s.threshold || errors.Is(err, context.DeadlineExceeded) {
+		next := (idx + 1) % int32(len(s.svcs))
+		if atomic.CompareAndSwapInt32(&s.idx, idx, next) {
+			// 清空下一个服务商的历史数据，重新统计
+			atomic.StoreInt64(&s.avg[next], 0)
+		}
+	}
+	return err
+}
+
+// record 更新平均响应时间，新的样本占 1/5 的权重
+func (s *ResponseTimeService) record(i int32, cost time.Duration) time.Duration {
+	for {
+		old := atomic.LoadInt64(&s.avg[i])
+		avg := int64(cost)
+		if old > 0 {
+			avg = old*4/5 + avg/5
+		}
+		if atomic.CompareAndSwapInt64(&s.avg[i], old, avg) {
+			return time.Duration(avg)
+		}
+	}
+}
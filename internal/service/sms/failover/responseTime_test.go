@@ -0,0 +1,77 @@
+package failover
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/Linxhhh/webook/internal/service/sms"
+)
+
+func TestResponseTimeSwitch(t *testing.T) {
+	slow, fast := newStub(nil), newStub(nil)
+	slow.delay = 30 * time.Millisecond
+	svc := NewResponseTimeService([]sms.Service{slow, fast}, 10*time.Millisecond)
+
+	// 第一次发送就超过阈值，切换到下一个服务商
+	if err := send(t, svc); err != nil {
+		t.Fatal(err)
+	}
+	if atomic.LoadInt32(&svc.idx) != 1 {
+		t.Fatalf("应该切换到下一个服务商，实际 %d", svc.idx)
+	}
+
+	// 响应正常时一直使用当前服务商
+	for i := 0; i < 5; i++ {
+		if err := send(t, svc); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if slow.count() != 1 || fast.count() != 5 {
+		t.Fatalf("调用次数错误：%d %d", slow.count(), fast.count())
+	}
+}
+
+func TestResponseTimeAverage(t *testing.T) {
+	s := newStub(nil)
+	svc := NewResponseTimeService([]sms.Service{s, newStub(nil)}, 100*time.Millisecond)
+
+	// 新的样本占 1/5 的权重，偶尔一次慢请求不会触发切换
+	svc.record(0, 50*time.Millisecond)
+	if avg := svc.record(0, 300*time.Millisecond); avg != 100*time.Millisecond {
+		t.Fatalf("平均响应时间错误：%s", avg)
+	}
+	atomic.StoreInt64(&svc.avg[0], int64(50*time.Millisecond))
+	s.delay = 80 * time.Millisecond
+	if err := send(t, svc); err != nil {
+		t.Fatal(err)
+	}
+	if atomic.LoadInt32(&svc.idx) != 0 {
+		t.Fatal("平均响应时间没有超过阈值，不应该切换")
+	}
+}
+
+func TestResponseTimeDeadline(t *testing.T) {
+	a, b := newStub(nil), newStub(nil)
+	a.delay, b.delay = time.Second, time.Second
+	svc := NewResponseTimeService([]sms.Service{a, b}, time.Hour)
+
+	// 请求超时切换服务商，切换到最后一个之后回到第一个
+	for _, want := range []int32{1, 0} {
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+		err := svc.Send(ctx, "1001", nil, "13800000000")
+		cancel()
+		if !errors.Is(err, context.DeadlineExceeded) {
+			t.Fatalf("期望 DeadlineExceeded，实际 %v", err)
+		}
+		if idx := atomic.LoadInt32(&svc.idx); idx != want {
+			t.Fatalf("期望切换到 %d，实际 %d", want, idx)
+		}
+		// 切换之后清空新服务商的历史数据
+		if avg := atomic.LoadInt64(&svc.avg[want]); avg != 0 {
+			t.Fatalf("新服务商的历史数据没有清空：%d", avg)
+		}
+	}
+}
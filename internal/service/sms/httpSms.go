@@ -0,0 +1,72 @@
+package sms
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+)
+
+/*
+HTTPService 通用的 HTTP 回调服务商：
+按照模板映射组装 JSON 请求体发送到配置的地址，响应 2xx 状态码视为发送成功，
+请求体格式为 {"templateId": "...", "params": {"name": "value"}, "numbers": ["..."]}
+*/
+type HTTPService struct {
+	url     string
+	method  string
+	headers map[string]string
+	tpls    Templates
+	client  *http.Client
+}
+
+func NewHTTPService(url, method string, headers map[string]string, tpls Templates, client *http.Client) *HTTPService {
+	if method == "" {
+		method = http.MethodPost
+	}
+	return &HTTPService{
+		url:     url,
+		method:  method,
+		headers: headers,
+		tpls:    tpls,
+		client:  client,
+	}
+}
+
+func (svc *HTTPService) Send(ctx context.Context, tplId string, args []string, numbers ...string) error {
+
+	// 转换模板
+	id, params, err := svc.tpls.resolve(tplId, args)
+	if err != nil {
+		return err
+	}
+	body, err := json.Marshal(map[string]any{
+		"templateId": id,
+		"params":     params,
+		"numbers":    numbers,
+	})
+	if err != nil {
+		return err
+	}
+
+	// 发送短信
+	req, err := http.NewRequestWithContext(ctx, svc.method, svc.url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	for k, v := range svc.headers {
+		req.Header.Set(k, v)
+	}
+	resp, err := svc.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("发送短信失败，HTTP 状态码 %d", resp.StatusCode)
+	}
+	return nil
+}
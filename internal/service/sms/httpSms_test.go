@@ -0,0 +1,72 @@
+package sms
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+var httpTpls = Templates{
+	"1001": {Id: "verify_code", Params: []string{"code"}},
+}
+
+func TestHTTPSend(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPut {
+			t.Errorf("请求方法错误：%s", r.Method)
+		}
+		if r.Header.Get("Authorization") != "Bearer xxx" || r.Header.Get("Content-Type") != "application/json" {
+			t.Errorf("请求头错误：%v", r.Header)
+		}
+		var body struct {
+			TemplateId string            `json:"templateId"`
+			Params     map[string]string `json:"params"`
+			Numbers    []string          `json:"numbers"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			t.Fatal(err)
+		}
+		if body.TemplateId != "verify_code" || body.Params["code"] != "123456" || len(body.Params) != 1 ||
+			strings.Join(body.Numbers, ",") != "13800000000,13900000000" {
+			t.Errorf("请求体错误：%+v", body)
+		}
+		w.WriteHeader(http.StatusAccepted)
+	}))
+	defer srv.Close()
+
+	svc := NewHTTPService(srv.URL, http.MethodPut, map[string]string{"Authorization": "Bearer xxx"}, httpTpls, srv.Client())
+	err := svc.Send(context.Background(), "1001", []string{"123456"}, "13800000000", "13900000000")
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestHTTPSendErrors(t *testing.T) {
+	var status int
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(status)
+		_, _ = w.Write([]byte(`{"error":"rejected"}`))
+	}))
+	defer srv.Close()
+	svc := NewHTTPService(srv.URL, "", nil, httpTpls, srv.Client())
+
+	for _, code := range []int{http.StatusBadRequest, http.StatusInternalServerError, http.StatusMultipleChoices} {
+		status = code
+		err := svc.Send(context.Background(), "1001", []string{"123456"}, "13800000000")
+		if err == nil || !strings.Contains(err.Error(), strconv.Itoa(code)) {
+			t.Fatalf("HTTP 状态码 %d 期望发送失败，实际 %v", code, err)
+		}
+	}
+
+	if err := svc.Send(context.Background(), "9999", nil, "13800000000"); !errors.Is(err, ErrUnknownTemplate) {
+		t.Fatalf("期望 ErrUnknownTemplate，实际 %v", err)
+	}
+	if err := svc.Send(context.Background(), "1001", nil, "13800000000"); !errors.Is(err, ErrTemplateArgs) {
+		t.Fatalf("期望 ErrTemplateArgs，实际 %v", err)
+	}
+}
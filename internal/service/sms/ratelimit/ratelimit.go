@@ -0,0 +1,39 @@
+package ratelimit
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/Linxhhh/webook/internal/service/sms"
+	"github.com/Linxhhh/webook/pkg/limiter"
+)
+
+var ErrLimited = errors.New("短信服务触发限流")
+
+// Service 限流装饰器，每个服务商使用独立的令牌桶
+type Service struct {
+	svc     sms.Service
+	limiter limiter.Limiter
+	key     string
+}
+
+func NewService(svc sms.Service, limiter limiter.Limiter, provider string) *Service {
+	return &Service{
+		svc:     svc,
+		limiter: limiter,
+		key:     fmt.Sprintf("sms:limiter:%s", provider),
+	}
+}
+
+func (s *Service) Send(ctx context.Context, tplId string, args []string, numbers ...string) error {
+	limited, err := s.limiter.Limit(ctx, s.key)
+	if err != nil {
+		// 限流器异常时保守处理，不再向服务商发送
+		return fmt.Errorf("短信服务限流器异常：%w", err)
+	}
+	if limited {
+		return ErrLimited
+	}
+	return s.svc.Send(ctx, tplId, args, numbers...)
+}
@@ -0,0 +1,57 @@
+package ratelimit
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+type stubLimiter struct {
+	limited bool
+	err     error
+	key     string
+}
+
+func (l *stubLimiter) Limit(ctx context.Context, key string) (bool, error) {
+	l.key = key
+	return l.limited, l.err
+}
+
+type stubSms struct {
+	calls int
+}
+
+func (s *stubSms) Send(ctx context.Context, tplId string, args []string, numbers ...string) error {
+	s.calls++
+	return nil
+}
+
+func TestRateLimit(t *testing.T) {
+	errRedis := errors.New("redis 不可用")
+	testCases := []struct {
+		name      string
+		limiter   *stubLimiter
+		want      error
+		wantCalls int
+	}{
+		{name: "通过限流", limiter: &stubLimiter{}, wantCalls: 1},
+		{name: "触发限流", limiter: &stubLimiter{limited: true}, want: ErrLimited},
+		{name: "限流器异常", limiter: &stubLimiter{err: errRedis}, want: errRedis},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			svc := &stubSms{}
+			err := NewService(svc, tc.limiter, "aliyun").Send(context.Background(), "1001", nil, "13800000000")
+			if !errors.Is(err, tc.want) {
+				t.Fatalf("期望 %v，实际 %v", tc.want, err)
+			}
+			if svc.calls != tc.wantCalls {
+				t.Fatalf("调用服务商 %d 次，期望 %d 次", svc.calls, tc.wantCalls)
+			}
+			// 每个服务商使用独立的令牌桶
+			if tc.limiter.key != "sms:limiter:aliyun" {
+				t.Fatalf("限流 key 错误：%s", tc.limiter.key)
+			}
+		})
+	}
+}
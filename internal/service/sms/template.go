@@ -0,0 +1,36 @@
+package sms
+
+import (
+	"errors"
+	"fmt"
+)
+
+var (
+	ErrUnknownTemplate = errors.New("服务商没有配置该短信模板")
+	ErrTemplateArgs    = errors.New("短信模板参数数量不匹配")
+)
+
+// Template 服务商的短信模板，Params 按照顺序给出每个参数的名称
+type Template struct {
+	Id     string
+	Params []string
+}
+
+// Templates 内部模板 ID 到服务商模板的映射
+type Templates map[string]Template
+
+// resolve 获取服务商的模板 ID，并按照参数名称组装模板参数
+func (t Templates) resolve(tplId string, args []string) (string, map[string]string, error) {
+	tpl, ok := t[tplId]
+	if !ok {
+		return "", nil, fmt.Errorf("%w：%s", ErrUnknownTemplate, tplId)
+	}
+	if len(tpl.Params) != len(args) {
+		return "", nil, fmt.Errorf("%w：%s 需要 %d 个参数", ErrTemplateArgs, tplId, len(tpl.Params))
+	}
+	params := make(map[string]string, len(args))
+	for i, name := range tpl.Params {
+		params[name] = args[i]
+	}
+	return tpl.Id, params, nil
+}
@@ -12,6 +12,13 @@ import (
 var (
 	ErrDuplicateEmailorPhone = repository.ErrDuplicateEmailorPhone
 	ErrInvalidEmailOrPassword = errors.New("邮箱或密码错误")
+	ErrAccountBound           = errors.New("已被其他账号绑定")
+	ErrLastLoginMethod        = errors.New("至少需要保留一种登录方式")
+	ErrBindingNotFound        = errors.New("未绑定")
+	ErrInvalidPassword        = errors.New("原密码错误")
+	ErrPasswordNotSet         = errors.New("未设置密码")
+	ErrUserBanned             = errors.New("账号已被封禁")
+	ErrUserNotFound           = repository.ErrUserNotFound
 )
 
 /* 
@@ -66,10 +73,29 @@ func (svc *UserService) Login(ctx context.Context, email string, password string
 	if err != nil {
 		return user, ErrInvalidEmailOrPassword
 	}
-	
+
+	// 检查账号是否被封禁
+	if user.Status == domain.UserStatusBanned {
+		return user, ErrUserBanned
+	}
 	return user, err
 }
 
+/*
+LoginInfo 获取签发令牌需要的用户信息：
+用于验证码、第三方登录和刷新令牌，账号被封禁时返回 ErrUserBanned
+*/
+func (us *UserService) LoginInfo(ctx context.Context, uid int64) (domain.User, error) {
+	user, err := us.repo.SearchById(ctx, uid)
+	if err != nil {
+		return domain.User{}, err
+	}
+	if user.Status == domain.UserStatusBanned {
+		return domain.User{}, ErrUserBanned
+	}
+	return user, nil
+}
+
 /*
 Edit 信息编辑服务：
 直接调用存储层，进行数据更新
@@ -102,4 +128,206 @@ func (us *UserService) FindOrCreate(ctx context.Context, phone string) (int64, e
 		return -1, err
 	}
 	return uid, nil
-}
\ No newline at end of file
+}
+
+/*
+FindOrCreateByOAuth2 通过第三方账号查找或创建用户：
+并发回调导致重复绑定时，重新查找一次
+*/
+func (us *UserService) FindOrCreateByOAuth2(ctx context.Context, info domain.OAuth2Info) (int64, error) {
+
+	// 查询用户
+	uid, err := us.repo.SearchByOAuth2(ctx, info.Provider, info.OpenId)
+	if err == repository.ErrUserNotFound {
+		// 创建用户
+		uid, err = us.repo.CreateByOAuth2(ctx, info)
+		if err == repository.ErrDuplicateOAuth2 {
+			uid, err = us.repo.SearchByOAuth2(ctx, info.Provider, info.OpenId)
+		}
+	}
+	if err != nil {
+		return -1, err
+	}
+	return uid, nil
+}
+
+/*
+BindPhone 绑定手机号码：
+手机号码已经属于其他账号时返回 ErrAccountBound，此时可以发起账号合并
+*/
+func (us *UserService) BindPhone(ctx context.Context, uid int64, phone string) error {
+	owner, err := us.repo.SearchByPhone(ctx, phone)
+	switch {
+	case err == nil && owner == uid:
+		return nil
+	case err == nil:
+		return ErrAccountBound
+	case err != repository.ErrUserNotFound:
+		return err
+	}
+
+	err = us.repo.UpdatePhone(ctx, uid, phone)
+	if err == repository.ErrDuplicateEmailorPhone {
+		return ErrAccountBound
+	}
+	return err
+}
+
+// UnbindPhone 解绑手机号码，手机号码是唯一的登录方式时不允许解绑
+func (us *UserService) UnbindPhone(ctx context.Context, uid int64) error {
+	user, err := us.repo.SearchById(ctx, uid)
+	if err != nil {
+		return err
+	}
+	if user.Phone == "" {
+		return ErrBindingNotFound
+	}
+	cnt, err := us.loginMethods(ctx, user)
+	if err != nil {
+		return err
+	}
+	if cnt <= 1 {
+		return ErrLastLoginMethod
+	}
+	return us.repo.UpdatePhone(ctx, uid, "")
+}
+
+/*
+BindEmail 绑定邮箱：
+邮箱已经属于其他账号时返回 ErrAccountBound，此时可以发起账号合并
+*/
+func (us *UserService) BindEmail(ctx context.Context, uid int64, email string) error {
+	owner, err := us.repo.SearchByEmail(ctx, email)
+	switch {
+	case err == nil && owner.Id == uid:
+		return nil
+	case err == nil:
+		return ErrAccountBound
+	case err != repository.ErrUserNotFound:
+		return err
+	}
+
+	err = us.repo.UpdateEmail(ctx, uid, email)
+	if err == repository.ErrDuplicateEmailorPhone {
+		return ErrAccountBound
+	}
+	return err
+}
+
+// OAuth2Bindings 获取用户绑定的第三方账号
+func (us *UserService) OAuth2Bindings(ctx context.Context, uid int64) ([]domain.OAuth2Info, error) {
+	return us.repo.GetOAuth2Bindings(ctx, uid)
+}
+
+/*
+BindOAuth2 为已登录用户绑定第三方账号：
+第三方账号已经属于其他账号时返回 ErrAccountBound，同一个平台只能绑定一个账号
+*/
+func (us *UserService) BindOAuth2(ctx context.Context, uid int64, info domain.OAuth2Info) error {
+	owner, err := us.repo.SearchByOAuth2(ctx, info.Provider, info.OpenId)
+	switch {
+	case err == nil && owner == uid:
+		return nil
+	case err == nil:
+		return ErrAccountBound
+	case err != repository.ErrUserNotFound:
+		return err
+	}
+
+	bindings, err := us.repo.GetOAuth2Bindings(ctx, uid)
+	if err != nil {
+		return err
+	}
+	for _, b := range bindings {
+		if b.Provider == info.Provider {
+			return ErrAccountBound
+		}
+	}
+
+	err = us.repo.BindOAuth2(ctx, uid, info)
+	if err == repository.ErrDuplicateOAuth2 {
+		return ErrAccountBound
+	}
+	return err
+}
+
+// UnbindOAuth2 解绑第三方账号，第三方账号是唯一的登录方式时不允许解绑
+func (us *UserService) UnbindOAuth2(ctx context.Context, uid int64, provider string) error {
+	user, err := us.repo.SearchById(ctx, uid)
+	if err != nil {
+		return err
+	}
+	cnt, err := us.loginMethods(ctx, user)
+	if err != nil {
+		return err
+	}
+	if cnt <= 1 {
+		return ErrLastLoginMethod
+	}
+	err = us.repo.UnbindOAuth2(ctx, uid, provider)
+	if err == repository.ErrUserNotFound {
+		return ErrBindingNotFound
+	}
+	return err
+}
+
+// loginMethods 统计用户可用的登录方式：手机号码、设置了密码的邮箱、第三方账号
+func (us *UserService) loginMethods(ctx context.Context, user domain.User) (int, error) {
+	cnt := 0
+	if user.Phone != "" {
+		cnt++
+	}
+	if user.Email != "" {
+		u, err := us.repo.SearchByEmail(ctx, user.Email)
+		if err != nil {
+			return 0, err
+		}
+		if u.Password != "" {
+			cnt++
+		}
+	}
+	bindings, err := us.repo.GetOAuth2Bindings(ctx, user.Id)
+	if err != nil {
+		return 0, err
+	}
+	return cnt + len(bindings), nil
+}
+
+/*
+ChangePassword 修改密码：
+校验原密码之后，重新加密新密码；没有设置过密码的用户需要通过找回密码来设置
+*/
+func (us *UserService) ChangePassword(ctx context.Context, uid int64, oldPassword, newPassword string) error {
+
+	// 校验原密码
+	hashPwd, err := us.repo.SearchPassword(ctx, uid)
+	if err != nil {
+		return err
+	}
+	if hashPwd == "" {
+		return ErrPasswordNotSet
+	}
+	if bcrypt.CompareHashAndPassword([]byte(hashPwd), []byte(oldPassword)) != nil {
+		return ErrInvalidPassword
+	}
+
+	return us.ResetPassword(ctx, uid, newPassword)
+}
+
+// ResetPassword 重置密码，调用方需要先校验用户身份
+func (us *UserService) ResetPassword(ctx context.Context, uid int64, password string) error {
+	hashPwd, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	if err != nil {
+		return err
+	}
+	return us.repo.UpdatePassword(ctx, uid, string(hashPwd))
+}
+
+// SearchId 通过手机号码或邮箱查找用户 ID，优先使用手机号码
+func (us *UserService) SearchId(ctx context.Context, phone, email string) (int64, error) {
+	if phone != "" {
+		return us.repo.SearchByPhone(ctx, phone)
+	}
+	u, err := us.repo.SearchByEmail(ctx, email)
+	return u.Id, err
+}
@@ -5,14 +5,15 @@ import (
 	"log"
 	"time"
 
+	"github.com/Linxhhh/webook/config"
 	"github.com/go-redis/redis"
 )
 
-func InitCache() redis.Cmdable {
+func InitCache(cfg config.RedisConfig) redis.Cmdable {
 	client := redis.NewClient(&redis.Options{
-		Addr:     "localhost:6379",
-		Password: "",
-		DB:       0,
+		Addr:     cfg.Addr,
+		Password: cfg.Password,
+		DB:       cfg.DB,
 	})
 
 	// 设置超时时间
@@ -1,23 +1,30 @@
 package ioc
 
 import (
+	"github.com/Linxhhh/webook/config"
 	"github.com/Linxhhh/webook/internal/repository/dao"
 	"gorm.io/driver/mysql"
 	"gorm.io/gorm"
 )
 
-func InitDB() (master *gorm.DB, slaves []*gorm.DB) {
-	master, err := gorm.Open(mysql.Open("root:123456@tcp(localhost:13306)/webook"))
+func InitDB(cfg config.DBConfig) (master *gorm.DB, slaves []*gorm.DB) {
+	master, err := gorm.Open(mysql.Open(cfg.Master))
 	if err != nil {
 		panic(err)
 	}
 
-	s1, err := gorm.Open(mysql.Open("root:123456@tcp(localhost:23306)/webook"))
-	if err != nil {
-		panic(err)
+	for _, dsn := range cfg.Slaves {
+		s, err := gorm.Open(mysql.Open(dsn))
+		if err != nil {
+			panic(err)
+		}
+		slaves = append(slaves, s)
 	}
 
-	slaves = append(slaves, s1)
+	// 没有配置从库时，读请求也走主库
+	if len(slaves) == 0 {
+		slaves = append(slaves, master)
+	}
 
 	err = master.AutoMigrate(
 		&dao.User{},
@@ -30,6 +37,15 @@ func InitDB() (master *gorm.DB, slaves []*gorm.DB) {
 		&dao.FollowRelation{},
 		&dao.FeedPullEvent{},
 		&dao.FeedPushEvent{},
+		&dao.Notification{},
+		&dao.Comment{},
+		&dao.UserSession{},
+		&dao.UserOAuthBinding{},
+		&dao.AsyncSms{},
+		&dao.LoginLockout{},
+		&dao.Report{},
+		&dao.ArticleRevision{},
+		&dao.DistributedLock{},
 	)
 	if err != nil {
 		panic(err)
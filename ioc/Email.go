@@ -0,0 +1,13 @@
+package ioc
+
+import (
+	"github.com/Linxhhh/webook/config"
+	"github.com/Linxhhh/webook/internal/service/email"
+)
+
+func InitEmailService(cfg config.EmailConfig) email.Service {
+	if cfg.Host == "" {
+		return email.NewLocalService()
+	}
+	return email.NewSMTPService(cfg.Host, cfg.Port, cfg.Username, cfg.Password, cfg.From)
+}
@@ -0,0 +1,10 @@
+package ioc
+
+import (
+	"github.com/Linxhhh/webook/internal/job"
+	"github.com/Linxhhh/webook/internal/service/sms/async"
+)
+
+func InitJobs(asyncSms *async.Service, scheduler *job.ArticleScheduler) []job.Job {
+	return []job.Job{asyncSms, scheduler}
+}
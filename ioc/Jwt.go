@@ -0,0 +1,10 @@
+package ioc
+
+import (
+	"github.com/Linxhhh/webook/config"
+	"github.com/Linxhhh/webook/pkg/jwts"
+)
+
+func InitJWT(cfg config.JWTConfig) *jwts.JWT {
+	return jwts.NewJWT(cfg.Key, cfg.Expire, cfg.RefreshExpire)
+}
@@ -2,13 +2,15 @@ package ioc
 
 import (
 	"github.com/IBM/sarama"
+	"github.com/Linxhhh/webook/config"
 	"github.com/Linxhhh/webook/internal/events"
+	"github.com/Linxhhh/webook/internal/repository"
 )
 
-func InitSaramaClient() sarama.Client {
-	cfg := sarama.NewConfig()
-	cfg.Producer.Return.Successes = true
-	client, err := sarama.NewClient([]string{"localhost:9094"}, cfg)
+func InitSaramaClient(cfg config.KafkaConfig) sarama.Client {
+	saramaCfg := sarama.NewConfig()
+	saramaCfg.Producer.Return.Successes = true
+	client, err := sarama.NewClient(cfg.Brokers, saramaCfg)
 	if err != nil {
 		panic(err)
 	}
@@ -23,6 +25,14 @@ func InitSyncProducer(c sarama.Client) sarama.SyncProducer {
 	return p
 }
 
-func InitConsumers(artEvt *events.ArticleEventConsumer) []events.Consumer {
-	return []events.Consumer{artEvt}
+// InitReadEventConsumer 按照配置的批量大小和时间间隔，批量消费阅读事件
+func InitReadEventConsumer(repo repository.InteractionRepository, client sarama.Client, producer sarama.SyncProducer,
+	cfg config.KafkaConfig) *events.ReadEventConsumer {
+	return events.NewReadEventConsumer(repo, client, producer, cfg.ReadBatch.Size, cfg.ReadBatch.Interval)
+}
+
+func InitConsumers(artEvt *events.ArticleEventConsumer, reviewEvt *events.ArticleReviewConsumer, readEvt *events.ReadEventConsumer,
+	interEvt *events.InteractionEventConsumer, followEvt *events.FollowEventConsumer,
+	notiEvt *events.NotificationEventConsumer) []events.Consumer {
+	return []events.Consumer{artEvt, reviewEvt, readEvt, interEvt, followEvt, notiEvt}
 }
@@ -5,21 +5,32 @@ import (
 	"strings"
 	"time"
 
+	"github.com/Linxhhh/webook/config"
 	"github.com/Linxhhh/webook/internal/app/middleware"
+	"github.com/Linxhhh/webook/internal/service"
+	"github.com/Linxhhh/webook/pkg/jwts"
+	"github.com/Linxhhh/webook/pkg/limiter"
 	"github.com/gin-contrib/cors"
 	"github.com/gin-gonic/gin"
+	"github.com/go-redis/redis"
 )
 
-func InitMiddleware() []gin.HandlerFunc {
+func InitMiddleware(j *jwts.JWT, sessSvc *service.SessionService, cmd redis.Cmdable, cfg config.RateLimitConfig) []gin.HandlerFunc {
 	return []gin.HandlerFunc{
+		// 全局限流，在鉴权之前拦截
+		middleware.RateLimit(globalRateLimitRules(cmd, cfg.Global)),
+
 		// 注册鉴权中间件
-		middleware.AuthByJWT(),
+		middleware.AuthByJWT(j, sessSvc),
+
+		// 路由限流，在鉴权之后才能按照用户限流
+		middleware.RateLimit(routeRateLimitRules(cmd, cfg.Routes)),
 
 		// 配置 CORS
 		cors.New(cors.Config{
 			AllowCredentials: true,
-			AllowHeaders:     []string{"Content-Type", "jwt-token"},
-			ExposeHeaders:    []string{"jwt-token", "Content-Length", "Access-Control-Allow-Origin", "Access-Control-Allow-Headers", "Content-Type"},
+			AllowHeaders:     []string{"Content-Type", "jwt-token", "refresh-token"},
+			ExposeHeaders:    []string{"jwt-token", "refresh-token", "Retry-After", "Content-Length", "Access-Control-Allow-Origin", "Access-Control-Allow-Headers", "Content-Type"},
 			// AllowAllOrigins:  true,
 			AllowOriginFunc: func(origin string) bool {
 				// 允许开发环境的 localhost 和 127.0.0.1
@@ -37,6 +48,29 @@ func InitMiddleware() []gin.HandlerFunc {
 	}
 }
 
+func globalRateLimitRules(cmd redis.Cmdable, cfg config.RateLimitRuleConfig) []middleware.RateLimitRule {
+	if cfg.Limit <= 0 {
+		return nil
+	}
+	return []middleware.RateLimitRule{{
+		Key:     middleware.RateLimitByIP,
+		Limiter: limiter.NewRedisSlidingWindowLimiter(cmd, cfg.Window, cfg.Limit),
+	}}
+}
+
+func routeRateLimitRules(cmd redis.Cmdable, cfg []config.RateLimitRuleConfig) []middleware.RateLimitRule {
+	rules := make([]middleware.RateLimitRule, 0, len(cfg))
+	for _, c := range cfg {
+		rules = append(rules, middleware.RateLimitRule{
+			Method:  c.Method,
+			Path:    c.Path,
+			Key:     c.Key,
+			Limiter: limiter.NewRedisSlidingWindowLimiter(cmd, c.Window, c.Limit),
+		})
+	}
+	return rules
+}
+
 // 处理 OPTIONS 请求
 func handleOptions() gin.HandlerFunc {
 	return func(c *gin.Context) {
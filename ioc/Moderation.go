@@ -0,0 +1,15 @@
+package ioc
+
+import (
+	"github.com/Linxhhh/webook/config"
+	"github.com/Linxhhh/webook/internal/service/moderation"
+)
+
+// InitModerator 初始化帖子审核，目前只有本地的关键词过滤
+func InitModerator(cfg config.ModerationConfig) moderation.Moderator {
+	m, err := moderation.NewKeywordModerator(cfg.Keywords, cfg.Patterns)
+	if err != nil {
+		panic(err)
+	}
+	return m
+}
@@ -0,0 +1,39 @@
+package ioc
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/Linxhhh/webook/config"
+	"github.com/Linxhhh/webook/internal/service/oauth2"
+)
+
+// InitOAuth2Services 只创建已经配置的第三方平台
+func InitOAuth2Services(cfg config.OAuth2Config) []oauth2.Service {
+	client := &http.Client{Timeout: 5 * time.Second}
+
+	var services []oauth2.Service
+	if c := cfg.Github; c.Enabled() {
+		services = append(services, oauth2.NewGithubService(c.ClientId, c.ClientSecret, c.RedirectURL,
+			endpoint(c, oauth2.GithubEndpoint), client))
+	}
+	if c := cfg.Wechat; c.Enabled() {
+		services = append(services, oauth2.NewWechatService(c.ClientId, c.ClientSecret, c.RedirectURL,
+			endpoint(c, oauth2.WechatEndpoint), client))
+	}
+	return services
+}
+
+// endpoint 使用配置中的接口地址覆盖平台的默认地址
+func endpoint(c config.OAuth2ProviderConfig, def oauth2.Endpoint) oauth2.Endpoint {
+	if c.AuthURL != "" {
+		def.AuthURL = c.AuthURL
+	}
+	if c.TokenURL != "" {
+		def.TokenURL = c.TokenURL
+	}
+	if c.UserURL != "" {
+		def.UserURL = c.UserURL
+	}
+	return def
+}
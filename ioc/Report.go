@@ -0,0 +1,13 @@
+package ioc
+
+import (
+	"github.com/Linxhhh/webook/config"
+	"github.com/Linxhhh/webook/internal/repository"
+	"github.com/Linxhhh/webook/internal/service"
+)
+
+// InitReportService 初始化举报服务，自动隐藏帖子的举报数量由配置决定
+func InitReportService(repo repository.ReportRepository, artRepo repository.ArticleRepository,
+	commentRepo repository.CommentRepository, userRepo repository.UserRepository, cfg config.ReportConfig) *service.ReportService {
+	return service.NewReportService(repo, artRepo, commentRepo, userRepo, cfg.HideThreshold)
+}
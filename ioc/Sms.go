@@ -1,7 +1,75 @@
 package ioc
 
-import "github.com/Linxhhh/webook/internal/service/sms"
+import (
+	"net/http"
+	"time"
 
-func InitSmsService() sms.Service {
-	return sms.NewLocalService()
-}
\ No newline at end of file
+	"github.com/Linxhhh/webook/config"
+	"github.com/Linxhhh/webook/internal/repository"
+	"github.com/Linxhhh/webook/internal/service/sms"
+	"github.com/Linxhhh/webook/internal/service/sms/async"
+	"github.com/Linxhhh/webook/internal/service/sms/failover"
+	"github.com/Linxhhh/webook/internal/service/sms/ratelimit"
+	"github.com/Linxhhh/webook/pkg/limiter"
+	"github.com/go-redis/redis"
+	"github.com/tencentcloud/tencentcloud-sdk-go/tencentcloud/common"
+	"github.com/tencentcloud/tencentcloud-sdk-go/tencentcloud/common/profile"
+	TCSms "github.com/tencentcloud/tencentcloud-sdk-go/tencentcloud/sms/v20210111"
+)
+
+/*
+InitSmsService 组装短信服务：
+每个服务商先套上限流，再按照配置的策略在服务商之间切换，最外层在全部失败时转为异步重试
+*/
+func InitSmsService(cfg config.SMSConfig, cmd redis.Cmdable, repo repository.AsyncSmsRepository) *async.Service {
+	providers := cfg.Providers
+	if len(providers) == 0 {
+		providers = []config.SMSProviderConfig{{Type: "local"}}
+	}
+
+	l := limiter.NewRedisTokenBucketLimiter(cmd, cfg.Rate, cfg.Burst)
+	svcs := make([]sms.Service, 0, len(providers))
+	for _, p := range providers {
+		svcs = append(svcs, ratelimit.NewService(newSmsProvider(p), l, p.ProviderName()))
+	}
+
+	var svc sms.Service
+	switch cfg.Strategy {
+	case config.SMSStrategyResponseTime:
+		svc = failover.NewResponseTimeService(svcs, cfg.MaxLatency)
+	default:
+		svc = failover.NewService(svcs, cfg.FailThreshold, cfg.Cooldown)
+	}
+	return async.NewService(svc, repo, cfg.RetryInterval, cfg.RetryMax)
+}
+
+func newSmsProvider(cfg config.SMSProviderConfig) sms.Service {
+	switch cfg.Type {
+	case "tencent":
+		client, err := TCSms.NewClient(common.NewCredential(cfg.SecretId, cfg.SecretKey), cfg.Region, profile.NewClientProfile())
+		if err != nil {
+			panic(err)
+		}
+		return sms.NewTentcentService(client, cfg.AppId, cfg.SignName)
+	case "aliyun":
+		endpoint := cfg.Endpoint
+		if endpoint == "" {
+			endpoint = sms.AliyunEndpoint
+		}
+		return sms.NewAliyunService(cfg.SecretId, cfg.SecretKey, cfg.Region, cfg.SignName,
+			smsTemplates(cfg.Templates), endpoint, &http.Client{Timeout: 5 * time.Second})
+	case "http":
+		return sms.NewHTTPService(cfg.URL, cfg.Method, cfg.Headers,
+			smsTemplates(cfg.Templates), &http.Client{Timeout: 5 * time.Second})
+	default:
+		return sms.NewLocalService()
+	}
+}
+
+func smsTemplates(cfg map[string]config.SMSTemplateConfig) sms.Templates {
+	tpls := make(sms.Templates, len(cfg))
+	for id, t := range cfg {
+		tpls[id] = sms.Template{Id: t.Id, Params: t.Params}
+	}
+	return tpls
+}
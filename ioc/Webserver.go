@@ -5,11 +5,20 @@ import (
 	"github.com/gin-gonic/gin"
 )
 
-func InitEngine(halFunc []gin.HandlerFunc, userHdl *app.UserHandler, artHdl *app.ArticleHandler, followHdl *app.FollowHandler) *gin.Engine {
+func InitEngine(halFunc []gin.HandlerFunc, userHdl *app.UserHandler, artHdl *app.ArticleHandler, followHdl *app.FollowHandler, feedHdl *app.FeedHandler,
+	notiHdl *app.NotificationHandler, commentHdl *app.CommentHandler,
+	oauth2Hdl *app.OAuth2Handler, accountHdl *app.AccountHandler, adminHdl *app.AdminHandler, reportHdl *app.ReportHandler) *gin.Engine {
 	router := gin.Default()
 	router.Use(halFunc...)
 	userHdl.RegistryRouter(router)
 	artHdl.RegistryRouter(router)
 	followHdl.RegistryRouter(router)
+	feedHdl.RegistryRouter(router)
+	notiHdl.RegistryRouter(router)
+	commentHdl.RegistryRouter(router)
+	oauth2Hdl.RegistryRouter(router)
+	accountHdl.RegistryRouter(router)
+	adminHdl.RegistryRouter(router)
+	reportHdl.RegistryRouter(router)
 	return router
 }
\ No newline at end of file
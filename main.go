@@ -1,12 +1,24 @@
 package main
 
+import (
+	"flag"
+	"log"
+
+	"github.com/Linxhhh/webook/config"
+)
+
 func main() {
-	server := InitWebServer()
-	for _, consumer := range server.consumers {
-		err := consumer.Start()
-		if err != nil {
-			panic(err)
-		}
+	path := flag.String("config", "config/dev.yaml", "配置文件路径")
+	flag.Parse()
+
+	// 加载配置，配置错误时直接退出
+	cfg, err := config.Load(*path)
+	if err != nil {
+		log.Fatalln(err)
+	}
+
+	app := InitApp(cfg)
+	if err := app.Run(cfg.Server.Addr); err != nil {
+		log.Fatalln(err)
 	}
-	server.engine.Run(":8081")
-}
\ No newline at end of file
+}
@@ -0,0 +1,141 @@
+package diff
+
+import "strings"
+
+// Op 行的变化类型
+type Op string
+
+const (
+	OpEqual  Op = "equal"
+	OpInsert Op = "insert"
+	OpDelete Op = "delete"
+)
+
+// Line 对比结果中的一行
+type Line struct {
+	Op   Op     `json:"op"`
+	Text string `json:"text"`
+}
+
+// maxEdits 编辑距离的上限，超过之后不再寻找最短的编辑序列，剩余部分整体按照删除和插入处理
+const maxEdits = 1000
+
+/*
+Lines 按行对比 a 和 b，返回把 a 变为 b 的编辑序列：
+先去掉相同的前缀和后缀，再使用 Myers 算法计算最短编辑序列
+*/
+func Lines(a, b string) []Line {
+	al, bl := splitLines(a), splitLines(b)
+
+	// 相同的前缀
+	prefix := 0
+	for prefix < len(al) && prefix < len(bl) && al[prefix] == bl[prefix] {
+		prefix++
+	}
+
+	// 相同的后缀
+	suffix := 0
+	for suffix < len(al)-prefix && suffix < len(bl)-prefix && al[len(al)-1-suffix] == bl[len(bl)-1-suffix] {
+		suffix++
+	}
+
+	res := make([]Line, 0, len(al)+len(bl))
+	res = appendLines(res, OpEqual, al[:prefix])
+	res = append(res, myers(al[prefix:len(al)-suffix], bl[prefix:len(bl)-suffix])...)
+	res = appendLines(res, OpEqual, al[len(al)-suffix:])
+	return res
+}
+
+func splitLines(s string) []string {
+	if s == "" {
+		return nil
+	}
+	return strings.Split(strings.ReplaceAll(s, "\r\n", "\n"), "\n")
+}
+
+func appendLines(res []Line, op Op, lines []string) []Line {
+	for _, l := range lines {
+		res = append(res, Line{Op: op, Text: l})
+	}
+	return res
+}
+
+/*
+myers 计算 a 变为 b 的最短编辑序列：
+第 d 轮记录每条对角线 k = x - y 上能到达的最远 x，到达终点之后根据每一轮的记录回溯路径
+*/
+func myers(a, b []string) []Line {
+	n, m := len(a), len(b)
+	if n == 0 || m == 0 {
+		return appendLines(appendLines(nil, OpDelete, a), OpInsert, b)
+	}
+
+	// v[k+offset] 为对角线 k 上最远的 x，trace[d] 为第 d 轮开始前对角线 [-d, d] 的记录
+	offset := n + m
+	v := make([]int, 2*offset+2)
+	var trace [][]int
+	for d := 0; d <= min(n+m, maxEdits); d++ {
+		trace = append(trace, append([]int(nil), v[offset-d:offset+d+1]...))
+		for k := -d; k <= d; k += 2 {
+			var x int
+			if k == -d || (k != d && v[offset+k-1] < v[offset+k+1]) {
+				x = v[offset+k+1] // 从对角线 k+1 向下移动：插入 b[y]
+			} else {
+				x = v[offset+k-1] + 1 // 从对角线 k-1 向右移动：删除 a[x]
+			}
+			y := x - k
+			for x < n && y < m && a[x] == b[y] {
+				x++
+				y++
+			}
+			v[offset+k] = x
+			if x >= n && y >= m {
+				return backtrack(trace, a, b)
+			}
+		}
+	}
+
+	// 差异太大
+	return appendLines(appendLines(nil, OpDelete, a), OpInsert, b)
+}
+
+// backtrack 从终点沿着每一轮的记录回溯到起点，得到编辑序列
+func backtrack(trace [][]int, a, b []string) []Line {
+	x, y := len(a), len(b)
+	var res []Line
+	for d := len(trace) - 1; d >= 0; d-- {
+		prevX, prevY := 0, 0
+		if d > 0 {
+			v := trace[d]
+			get := func(k int) int { return v[k+d] }
+			k := x - y
+			prevK := k - 1
+			if k == -d || (k != d && get(k-1) < get(k+1)) {
+				prevK = k + 1
+			}
+			prevX = get(prevK)
+			prevY = prevX - prevK
+		}
+
+		// 对角线上相同的行
+		for x > prevX && y > prevY {
+			res = append(res, Line{Op: OpEqual, Text: a[x-1]})
+			x--
+			y--
+		}
+		if d > 0 {
+			if x == prevX {
+				res = append(res, Line{Op: OpInsert, Text: b[y-1]})
+			} else {
+				res = append(res, Line{Op: OpDelete, Text: a[x-1]})
+			}
+			x, y = prevX, prevY
+		}
+	}
+
+	// 翻转为从前往后的顺序
+	for i, j := 0, len(res)-1; i < j; i, j = i+1, j-1 {
+		res[i], res[j] = res[j], res[i]
+	}
+	return res
+}
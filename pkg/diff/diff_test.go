@@ -0,0 +1,123 @@
+package diff
+
+import (
+	"math/rand"
+	"reflect"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func eq(s string) Line  { return Line{Op: OpEqual, Text: s} }
+func ins(s string) Line { return Line{Op: OpInsert, Text: s} }
+func del(s string) Line { return Line{Op: OpDelete, Text: s} }
+
+func TestLines(t *testing.T) {
+	testCases := []struct {
+		name string
+		a, b string
+		want []Line
+	}{
+		{name: "都为空", a: "", b: "", want: []Line{}},
+		{name: "从空内容新增", a: "", b: "a\nb", want: []Line{ins("a"), ins("b")}},
+		{name: "删除为空内容", a: "a\nb", b: "", want: []Line{del("a"), del("b")}},
+		{name: "内容相同", a: "a\nb\nc", b: "a\nb\nc", want: []Line{eq("a"), eq("b"), eq("c")}},
+		{name: "在中间插入", a: "a\nc", b: "a\nb\nc", want: []Line{eq("a"), ins("b"), eq("c")}},
+		{name: "在开头和结尾插入", a: "b", b: "a\nb\nc", want: []Line{ins("a"), eq("b"), ins("c")}},
+		{name: "在中间删除", a: "a\nb\nc", b: "a\nc", want: []Line{eq("a"), del("b"), eq("c")}},
+		{name: "删除开头和结尾", a: "a\nb\nc", b: "b", want: []Line{del("a"), eq("b"), del("c")}},
+		{name: "修改一行", a: "a\nb\nc", b: "a\nx\nc", want: []Line{eq("a"), del("b"), ins("x"), eq("c")}},
+		{name: "交错修改", a: "a\nb\nc\nd\ne", b: "a\nx\nc\ne\nf",
+			want: []Line{eq("a"), del("b"), ins("x"), eq("c"), del("d"), eq("e"), ins("f")}},
+		{name: "完全不同", a: "a\nb", b: "c\nd", want: []Line{del("a"), del("b"), ins("c"), ins("d")}},
+		{name: "Windows 换行", a: "a\r\nb", b: "a\nb", want: []Line{eq("a"), eq("b")}},
+		{name: "结尾的空行", a: "a", b: "a\n", want: []Line{eq("a"), ins("")}},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			got := Lines(tc.a, tc.b)
+			if !reflect.DeepEqual(got, tc.want) {
+				t.Fatalf("期望 %v，实际 %v", tc.want, got)
+			}
+		})
+	}
+}
+
+// apply 把编辑序列应用到 a 上，同时校验序列中相同和删除的行与 a 一致
+func apply(t *testing.T, a string, lines []Line) string {
+	al := splitLines(a)
+	var res []string
+	i := 0
+	for _, l := range lines {
+		switch l.Op {
+		case OpEqual, OpDelete:
+			if i >= len(al) || al[i] != l.Text {
+				t.Fatalf("第 %d 行和原内容不一致：%q", i, l.Text)
+			}
+			i++
+			if l.Op == OpEqual {
+				res = append(res, l.Text)
+			}
+		case OpInsert:
+			res = append(res, l.Text)
+		}
+	}
+	if i != len(al) {
+		t.Fatalf("原内容还剩 %d 行没有处理", len(al)-i)
+	}
+	return strings.Join(res, "\n")
+}
+
+func edits(lines []Line) int {
+	cnt := 0
+	for _, l := range lines {
+		if l.Op != OpEqual {
+			cnt++
+		}
+	}
+	return cnt
+}
+
+func TestLinesApply(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+	gen := func(n int) string {
+		lines := make([]string, n)
+		for i := range lines {
+			lines[i] = strconv.Itoa(r.Intn(5))
+		}
+		return strings.Join(lines, "\n")
+	}
+	for i := 0; i < 500; i++ {
+		a, b := gen(r.Intn(30)), gen(r.Intn(30))
+		if r.Intn(10) == 0 {
+			a = ""
+		}
+		lines := Lines(a, b)
+		if got := apply(t, a, lines); got != b {
+			t.Fatalf("Lines(%q, %q) 应用之后为 %q", a, b, got)
+		}
+		// 反向的编辑序列长度相同，都是最短编辑序列
+		if edits(lines) != edits(Lines(b, a)) {
+			t.Fatalf("编辑序列不是最短的：%q %q", a, b)
+		}
+	}
+}
+
+func TestLinesMaxEdits(t *testing.T) {
+	// 编辑距离超过上限时，中间部分整体按照删除和插入处理，结果仍然正确
+	var al, bl []string
+	for i := 0; i < maxEdits; i++ {
+		al = append(al, "a"+strconv.Itoa(i))
+		bl = append(bl, "b"+strconv.Itoa(i))
+	}
+	a := "head\n" + strings.Join(al, "\n") + "\ntail"
+	b := "head\n" + strings.Join(bl, "\n") + "\ntail"
+
+	lines := Lines(a, b)
+	if got := apply(t, a, lines); got != b {
+		t.Fatal("应用编辑序列之后和目标内容不一致")
+	}
+	if lines[0] != eq("head") || lines[len(lines)-1] != eq("tail") || edits(lines) != 2*maxEdits {
+		t.Fatalf("编辑序列错误：%d", edits(lines))
+	}
+}
@@ -1,40 +1,103 @@
 package jwts
 
 import (
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
 	"time"
 
 	"github.com/dgrijalva/jwt-go/v4"
 )
 
+// 令牌类型，防止长短令牌混用
+const (
+	AccessToken  = "access"
+	RefreshToken = "refresh"
+)
+
+var ErrTokenType = errors.New("令牌类型错误")
+
 type JwtPayload struct {
 	UserId    int64  `json:"userId"`
 	UserAgent string `json:"userAgent"`
+	Ssid      string `json:"ssid"` // 会话 ID，退出登录后失效
+	Role      uint8  `json:"role"` // 用户角色，角色变更后需要重新登录
 }
 
 type CustomClaims struct {
 	JwtPayload
-	jwt.StandardClaims // 标准声明结构体
+	TokenType          string `json:"tokenType"`
+	jwt.StandardClaims        // 标准声明结构体
 }
 
-// 生成用户 token
-func GenToken(user JwtPayload) (string, error) {
+/*
+JWT 使用同一个密钥签发和解析用户 token：
+短令牌 access token 用于访问接口，长令牌 refresh token 只用于刷新短令牌
+*/
+type JWT struct {
+	key           []byte
+	expire        time.Duration
+	refreshExpire time.Duration
+}
+
+func NewJWT(key string, expire time.Duration, refreshExpire time.Duration) *JWT {
+	return &JWT{
+		key:           []byte(key),
+		expire:        expire,
+		refreshExpire: refreshExpire,
+	}
+}
+
+// NewSsid 生成随机的会话 ID
+func NewSsid() string {
+	b := make([]byte, 16)
+	_, _ = rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+// RefreshExpire 长令牌的有效期，也是会话的最长有效期
+func (j *JWT) RefreshExpire() time.Duration {
+	return j.refreshExpire
+}
+
+// 生成用户短令牌
+func (j *JWT) GenToken(user JwtPayload) (string, error) {
+	return j.genToken(user, AccessToken, j.expire)
+}
+
+// 生成用户长令牌
+func (j *JWT) GenRefreshToken(user JwtPayload) (string, error) {
+	return j.genToken(user, RefreshToken, j.refreshExpire)
+}
+
+func (j *JWT) genToken(user JwtPayload, typ string, expire time.Duration) (string, error) {
 
 	claims := CustomClaims{
 		JwtPayload: user,
+		TokenType:  typ,
 		StandardClaims: jwt.StandardClaims{
-			ExpiresAt: jwt.At(time.Now().Add(time.Duration(8) * time.Hour)), // 到期时间
+			ExpiresAt: jwt.At(time.Now().Add(expire)), // 到期时间
 		},
 	}
 	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims) // 加密算法
 
-	return token.SignedString([]byte("uis&*jbb55dHRhf5")) // 使用密钥，生成带签名的JWT
+	return token.SignedString(j.key) // 使用密钥，生成带签名的JWT
 }
 
-// 解析用户 token
-func ParseToken(token string) (*CustomClaims, error) {
+// 解析用户短令牌
+func (j *JWT) ParseToken(token string) (*CustomClaims, error) {
+	return j.parseToken(token, AccessToken)
+}
+
+// 解析用户长令牌
+func (j *JWT) ParseRefreshToken(token string) (*CustomClaims, error) {
+	return j.parseToken(token, RefreshToken)
+}
+
+func (j *JWT) parseToken(token string, typ string) (*CustomClaims, error) {
 
 	Token, err := jwt.ParseWithClaims(token, &CustomClaims{}, func(t *jwt.Token) (interface{}, error) {
-		return []byte("uis&*jbb55dHRhf5"), nil
+		return j.key, nil
 	})
 	if err != nil {
 		// 解析 token 异常
@@ -50,6 +113,10 @@ func ParseToken(token string) (*CustomClaims, error) {
 		// 数据不一致
 		return nil, &jwt.InvalidClaimsError{}
 	}
+	if claims.TokenType != typ || claims.Ssid == "" {
+		// 令牌类型不匹配，或者是旧版本签发的令牌
+		return nil, ErrTokenType
+	}
 
 	return claims, nil
 }
@@ -0,0 +1,8 @@
+package limiter
+
+import "context"
+
+// Limiter 限流器，Limit 返回 true 表示触发限流
+type Limiter interface {
+	Limit(ctx context.Context, key string) (bool, error)
+}
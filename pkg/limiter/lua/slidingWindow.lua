@@ -0,0 +1,22 @@
+local key = KEYS[1]
+local window = tonumber(ARGV[1])
+local threshold = tonumber(ARGV[2])
+local now = tonumber(ARGV[3])
+local member = ARGV[4]
+
+-- 移除窗口之外的请求
+redis.call("zremrangebyscore", key, "-inf", now - window)
+
+local cnt = redis.call("zcard", key)
+if cnt >= threshold then
+    -- 触发限流，等待窗口内最早的请求过期
+    local oldest = redis.call("zrange", key, 0, 0, "withscores")
+    return tonumber(oldest[2]) + window - now
+end
+
+redis.call("zadd", key, now, member)
+redis.call("pexpire", key, window)
+return 0
+
+-- 返回 0，表示允许请求
+-- 返回大于 0 的值，表示触发限流，需要等待的毫秒数
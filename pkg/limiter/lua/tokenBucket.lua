@@ -0,0 +1,31 @@
+local key = KEYS[1]
+local rate = tonumber(ARGV[1])
+local capacity = tonumber(ARGV[2])
+local now = tonumber(ARGV[3])
+
+local bucket = redis.call("hmget", key, "tokens", "ts")
+local tokens = tonumber(bucket[1])
+local ts = tonumber(bucket[2])
+if tokens == nil or ts == nil then
+    -- 桶不存在，初始为满桶
+    tokens = capacity
+    ts = now
+end
+
+-- 按照流逝的时间补充令牌
+local elapsed = math.max(0, now - ts)
+tokens = math.min(capacity, tokens + elapsed * rate / 1000)
+
+local limited = 1
+if tokens >= 1 then
+    tokens = tokens - 1
+    limited = 0
+end
+
+redis.call("hmset", key, "tokens", tostring(tokens), "ts", now)
+-- 桶补满之后就没有保存的必要了
+redis.call("pexpire", key, math.ceil(capacity / rate * 1000) + 1000)
+return limited
+
+-- 返回 0，表示获取到令牌
+-- 返回 1，表示触发限流
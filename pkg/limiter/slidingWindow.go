@@ -0,0 +1,52 @@
+package limiter
+
+import (
+	"context"
+	"crypto/rand"
+	_ "embed"
+	"encoding/hex"
+	"time"
+
+	"github.com/go-redis/redis"
+)
+
+//go:embed lua/slidingWindow.lua
+var luaSlidingWindow string
+
+// RedisSlidingWindowLimiter 基于 Redis 的滑动窗口限流器，任意 window 时间内最多允许 threshold 个请求
+type RedisSlidingWindowLimiter struct {
+	cmd       redis.Cmdable
+	window    time.Duration
+	threshold int
+}
+
+func NewRedisSlidingWindowLimiter(cmd redis.Cmdable, window time.Duration, threshold int) *RedisSlidingWindowLimiter {
+	return &RedisSlidingWindowLimiter{
+		cmd:       cmd,
+		window:    window,
+		threshold: threshold,
+	}
+}
+
+func (l *RedisSlidingWindowLimiter) Limit(ctx context.Context, key string) (bool, error) {
+	limited, _, err := l.Acquire(ctx, key)
+	return limited, err
+}
+
+// Acquire 尝试通过限流，触发限流时同时返回需要等待的时间
+func (l *RedisSlidingWindowLimiter) Acquire(ctx context.Context, key string) (bool, time.Duration, error) {
+	now := time.Now().UnixMilli()
+	wait, err := l.cmd.Eval(luaSlidingWindow, []string{key},
+		l.window.Milliseconds(), l.threshold, now, member(now)).Int64()
+	if err != nil {
+		return false, 0, err
+	}
+	return wait > 0, time.Duration(wait) * time.Millisecond, nil
+}
+
+// member 同一毫秒内可能有多个请求，加上随机后缀避免相互覆盖
+func member(now int64) string {
+	b := make([]byte, 8)
+	_, _ = rand.Read(b)
+	return time.UnixMilli(now).Format("150405.000") + ":" + hex.EncodeToString(b)
+}
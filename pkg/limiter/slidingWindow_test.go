@@ -0,0 +1,60 @@
+package limiter
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestSlidingWindow(t *testing.T) {
+	mr, client := newTestRedis(t)
+	l := NewRedisSlidingWindowLimiter(client, 200*time.Millisecond, 2)
+	ctx := context.Background()
+
+	for i := 0; i < 2; i++ {
+		limited, wait, err := l.Acquire(ctx, "sw")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if limited || wait != 0 {
+			t.Fatalf("第 %d 个请求不应该被限流：%s", i+1, wait)
+		}
+	}
+
+	// 窗口内超过阈值，等待时间为最早的请求离开窗口的时间
+	limited, wait, err := l.Acquire(ctx, "sw")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !limited || wait <= 0 || wait > 200*time.Millisecond {
+		t.Fatalf("应该被限流，等待时间错误：%v %s", limited, wait)
+	}
+
+	// 被限流的请求不计入窗口，等待之后可以通过
+	time.Sleep(wait + 5*time.Millisecond)
+	if limited, err = l.Limit(ctx, "sw"); err != nil || limited {
+		t.Fatalf("等待之后不应该被限流：%v %v", limited, err)
+	}
+	if cnt, _ := mr.ZMembers("sw"); len(cnt) != 1 {
+		t.Fatalf("窗口内应该只剩 1 个请求：%v", cnt)
+	}
+	if ttl := mr.TTL("sw"); ttl != 200*time.Millisecond {
+		t.Fatalf("过期时间应该等于窗口：%s", ttl)
+	}
+}
+
+func TestSlidingWindowSameMillisecond(t *testing.T) {
+	_, client := newTestRedis(t)
+	l := NewRedisSlidingWindowLimiter(client, time.Minute, 100)
+	ctx := context.Background()
+
+	// 同一毫秒内的请求不会相互覆盖
+	for i := 0; i < 100; i++ {
+		if limited, err := l.Limit(ctx, "sw"); err != nil || limited {
+			t.Fatalf("第 %d 个请求不应该被限流：%v %v", i+1, limited, err)
+		}
+	}
+	if limited, err := l.Limit(ctx, "sw"); err != nil || !limited {
+		t.Fatalf("第 101 个请求应该被限流：%v %v", limited, err)
+	}
+}
@@ -0,0 +1,38 @@
+package limiter
+
+import (
+	"context"
+	_ "embed"
+	"time"
+
+	"github.com/go-redis/redis"
+)
+
+//go:embed lua/tokenBucket.lua
+var luaTokenBucket string
+
+/*
+RedisTokenBucketLimiter 基于 Redis 的令牌桶限流器：
+每秒生成 rate 个令牌，桶中最多存放 capacity 个令牌，允许一定程度的突发流量
+*/
+type RedisTokenBucketLimiter struct {
+	cmd      redis.Cmdable
+	rate     float64
+	capacity int
+}
+
+func NewRedisTokenBucketLimiter(cmd redis.Cmdable, rate float64, capacity int) *RedisTokenBucketLimiter {
+	return &RedisTokenBucketLimiter{
+		cmd:      cmd,
+		rate:     rate,
+		capacity: capacity,
+	}
+}
+
+func (l *RedisTokenBucketLimiter) Limit(ctx context.Context, key string) (bool, error) {
+	res, err := l.cmd.Eval(luaTokenBucket, []string{key}, l.rate, l.capacity, time.Now().UnixMilli()).Int()
+	if err != nil {
+		return false, err
+	}
+	return res == 1, nil
+}
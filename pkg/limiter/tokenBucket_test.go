@@ -0,0 +1,70 @@
+package limiter
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/go-redis/redis"
+)
+
+// newTestRedis 使用 miniredis 执行限流的 lua 脚本
+func newTestRedis(t *testing.T) (*miniredis.Miniredis, redis.Cmdable) {
+	mr := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	t.Cleanup(func() { _ = client.Close() })
+	return mr, client
+}
+
+func TestTokenBucket(t *testing.T) {
+	mr, client := newTestRedis(t)
+	l := NewRedisTokenBucketLimiter(client, 100, 3)
+	ctx := context.Background()
+
+	// 初始为满桶，允许 capacity 个突发请求
+	for i := 0; i < 3; i++ {
+		limited, err := l.Limit(ctx, "tb")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if limited {
+			t.Fatalf("第 %d 个请求不应该被限流", i+1)
+		}
+	}
+	limited, err := l.Limit(ctx, "tb")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !limited {
+		t.Fatal("令牌用完之后应该被限流")
+	}
+
+	// 每秒生成 100 个令牌，30ms 之后至少补充 2 个
+	time.Sleep(30 * time.Millisecond)
+	for i := 0; i < 2; i++ {
+		if limited, err = l.Limit(ctx, "tb"); err != nil || limited {
+			t.Fatalf("补充令牌之后不应该被限流：%v %v", limited, err)
+		}
+	}
+
+	// 不同的 key 相互独立
+	if limited, err = l.Limit(ctx, "other"); err != nil || limited {
+		t.Fatalf("其它 key 不应该被限流：%v %v", limited, err)
+	}
+
+	// 设置了过期时间：补满桶的时间加 1 秒
+	if ttl := mr.TTL("tb"); ttl <= 0 || ttl > 1030*time.Millisecond {
+		t.Fatalf("过期时间错误：%s", ttl)
+	}
+}
+
+func TestTokenBucketRedisError(t *testing.T) {
+	mr, client := newTestRedis(t)
+	l := NewRedisTokenBucketLimiter(client, 1, 1)
+	mr.Close()
+
+	if _, err := l.Limit(context.Background(), "tb"); err == nil {
+		t.Fatal("Redis 不可用时应该返回错误")
+	}
+}
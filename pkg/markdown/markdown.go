@@ -0,0 +1,194 @@
+package markdown
+
+import (
+	"bytes"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/microcosm-cc/bluemonday"
+	"github.com/yuin/goldmark"
+	"github.com/yuin/goldmark/ast"
+	"github.com/yuin/goldmark/extension"
+	"github.com/yuin/goldmark/parser"
+	"github.com/yuin/goldmark/text"
+)
+
+// Heading 目录中的一个标题，Id 和渲染出的 HTML 中标题的 id 属性一致
+type Heading struct {
+	Level int    `json:"level"`
+	Id    string `json:"id"`
+	Text  string `json:"text"`
+}
+
+// Result 渲染结果
+type Result struct {
+	HTML     string    // 过滤之后的 HTML
+	Abstract string    // 去掉标记的纯文本摘要
+	TOC      []Heading // 目录
+}
+
+// AbstractLength 摘要的最大长度（字符数）
+const AbstractLength = 128
+
+var (
+	// 支持 GFM（表格、删除线、任务列表、自动链接），原始 HTML 不会被渲染
+	md = goldmark.New(
+		goldmark.WithExtensions(extension.GFM),
+		goldmark.WithParserOptions(parser.WithAutoHeadingID()),
+	)
+
+	policy = newPolicy()
+)
+
+/*
+newPolicy HTML 白名单：
+在 bluemonday 的 UGC 策略基础上，允许代码块的语言 class、标题的 id（目录跳转）和任务列表的复选框
+*/
+func newPolicy() *bluemonday.Policy {
+	p := bluemonday.UGCPolicy()
+	p.AllowAttrs("class").Matching(regexp.MustCompile(`^language-[\w+#-]+$`)).OnElements("code")
+	p.AllowAttrs("id").Matching(regexp.MustCompile(`^[\p{L}\p{N}_-]+$`)).OnElements("h1", "h2", "h3", "h4", "h5", "h6")
+	p.AllowAttrs("type").Matching(regexp.MustCompile(`^checkbox$`)).OnElements("input")
+	p.AllowAttrs("checked", "disabled").OnElements("input")
+	return p
+}
+
+/*
+Render 把 markdown 渲染为过滤之后的 HTML：
+同一次解析的语法树同时用于生成目录和纯文本摘要，渲染出的 HTML 再经过白名单过滤，防止 XSS
+*/
+func Render(src string) (Result, error) {
+	source := []byte(src)
+	doc := parse(source)
+
+	var buf bytes.Buffer
+	if err := md.Renderer().Render(&buf, source, doc); err != nil {
+		return Result{}, err
+	}
+	return Result{
+		HTML:     policy.Sanitize(buf.String()),
+		Abstract: truncate(plainText(doc, source), AbstractLength),
+		TOC:      toc(doc, source),
+	}, nil
+}
+
+// Abstract 生成去掉 markdown 标记的纯文本摘要，最多 n 个字符
+func Abstract(src string, n int) string {
+	source := []byte(src)
+	doc := parse(source)
+	return truncate(plainText(doc, source), n)
+}
+
+// parse 解析 markdown，每次解析使用独立的标题 id 生成器
+func parse(source []byte) ast.Node {
+	ctx := parser.NewContext(parser.WithIDs(&headingIds{values: map[string]struct{}{}}))
+	return md.Parser().Parse(text.NewReader(source), parser.WithContext(ctx))
+}
+
+/*
+headingIds 标题 id 生成器：
+goldmark 默认只保留 ASCII 字符，中文标题会生成无意义的 id，这里保留所有字母和数字，
+空白转为 "-"，重复的 id 追加序号
+*/
+type headingIds struct {
+	values map[string]struct{}
+}
+
+func (s *headingIds) Generate(value []byte, kind ast.NodeKind) []byte {
+	var sb strings.Builder
+	for _, r := range strings.ToLower(strings.TrimSpace(string(value))) {
+		switch {
+		case unicode.IsLetter(r) || unicode.IsNumber(r) || r == '_' || r == '-':
+			sb.WriteRune(r)
+		case unicode.IsSpace(r):
+			sb.WriteByte('-')
+		}
+	}
+	id := sb.String()
+	if id == "" {
+		id = "heading"
+	}
+	res := id
+	for i := 1; ; i++ {
+		if _, ok := s.values[res]; !ok {
+			break
+		}
+		res = id + "-" + strconv.Itoa(i)
+	}
+	s.values[res] = struct{}{}
+	return []byte(res)
+}
+
+func (s *headingIds) Put(value []byte) {
+	s.values[string(value)] = struct{}{}
+}
+
+// toc 按照出现的顺序提取所有标题
+func toc(doc ast.Node, source []byte) []Heading {
+	var res []Heading
+	_ = ast.Walk(doc, func(n ast.Node, entering bool) (ast.WalkStatus, error) {
+		h, ok := n.(*ast.Heading)
+		if !ok || !entering {
+			return ast.WalkContinue, nil
+		}
+		var id string
+		if v, ok := h.AttributeString("id"); ok {
+			if b, ok := v.([]byte); ok {
+				id = string(b)
+			}
+		}
+		res = append(res, Heading{
+			Level: h.Level,
+			Id:    id,
+			Text:  strings.TrimSpace(plainText(h, source)),
+		})
+		return ast.WalkSkipChildren, nil
+	})
+	return res
+}
+
+/*
+plainText 提取节点中的纯文本：
+跳过代码块、图片和原始 HTML，块级元素之间、换行处使用空格分隔，连续的空白合并为一个空格
+*/
+func plainText(node ast.Node, source []byte) string {
+	var sb strings.Builder
+	_ = ast.Walk(node, func(n ast.Node, entering bool) (ast.WalkStatus, error) {
+		switch t := n.(type) {
+		case *ast.CodeBlock, *ast.FencedCodeBlock, *ast.HTMLBlock, *ast.RawHTML, *ast.Image:
+			return ast.WalkSkipChildren, nil
+		case *ast.Text:
+			if entering {
+				sb.Write(t.Segment.Value(source))
+				if t.SoftLineBreak() || t.HardLineBreak() {
+					sb.WriteByte(' ')
+				}
+			}
+		case *ast.String:
+			if entering {
+				sb.Write(t.Value)
+			}
+		case *ast.AutoLink:
+			if entering {
+				sb.Write(t.Label(source))
+			}
+		default:
+			if n.Type() == ast.TypeBlock {
+				sb.WriteByte(' ')
+			}
+		}
+		return ast.WalkContinue, nil
+	})
+	return strings.Join(strings.Fields(sb.String()), " ")
+}
+
+// truncate 截取前 n 个字符
+func truncate(s string, n int) string {
+	str := []rune(s)
+	if len(str) > n {
+		str = str[:n]
+	}
+	return string(str)
+}
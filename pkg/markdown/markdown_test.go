@@ -0,0 +1,178 @@
+package markdown
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"unicode/utf8"
+)
+
+func TestPolicy(t *testing.T) {
+	testCases := []struct {
+		name    string
+		html    string
+		want    []string // 过滤之后必须包含
+		wantNot []string // 过滤之后不能包含
+	}{
+		{name: "script 标签", html: `<script>alert(1)</script><p>正文</p>`,
+			want: []string{"<p>正文</p>"}, wantNot: []string{"<script", "alert(1)"}},
+		{name: "javascript 链接", html: `<a href="javascript:alert(1)">链接</a>`,
+			want: []string{"链接"}, wantNot: []string{"javascript:"}},
+		{name: "大小写混合的 javascript 链接", html: `<a href="JaVaScRiPt:alert(1)">链接</a>`,
+			wantNot: []string{"alert(1)"}},
+		{name: "on 事件属性", html: `<p onclick="alert(1)">正文</p><img src="a.png" onerror="alert(1)">`,
+			want: []string{"<p>正文</p>", `src="a.png"`}, wantNot: []string{"onclick", "onerror"}},
+		{name: "style 和 iframe", html: `<p style="background:url(javascript:alert(1))">正文</p><iframe src="https://evil.com"></iframe>`,
+			want: []string{"<p>正文</p>"}, wantNot: []string{"style", "iframe"}},
+		{name: "代码块的语言 class", html: `<pre><code class="language-c++">x</code></pre>`,
+			want: []string{`<code class="language-c++">`}},
+		{name: "其它 class", html: `<code class="evil">x</code><p class="language-go">y</p>`,
+			want: []string{"<code>x</code>", "<p>y</p>"}, wantNot: []string{"class"}},
+		{name: "语言 class 中的空格", html: `<code class="language-go x">x</code>`,
+			wantNot: []string{"class"}},
+		{name: "任务列表的复选框", html: `<input checked="" disabled="" type="checkbox">`,
+			want: []string{`type="checkbox"`, `checked=""`, `disabled=""`}},
+		{name: "其它类型的 input", html: `<input type="text" value="x" onfocus="alert(1)">`,
+			wantNot: []string{`type="text"`, "onfocus"}},
+		{name: "标题的 id", html: `<h2 id="中文-标题_1">标题</h2>`,
+			want: []string{`<h2 id="中文-标题_1">`}},
+		{name: "标题 id 中的引号", html: `<h2 id="a&#34; onmouseover=&#34;alert(1)">标题</h2>`,
+			want: []string{"标题</h2>"}, wantNot: []string{`" onmouseover`}},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			got := policy.Sanitize(tc.html)
+			for _, s := range tc.want {
+				if !strings.Contains(got, s) {
+					t.Errorf("缺少 %q：%s", s, got)
+				}
+			}
+			for _, s := range tc.wantNot {
+				if strings.Contains(strings.ToLower(got), strings.ToLower(s)) {
+					t.Errorf("没有过滤 %q：%s", s, got)
+				}
+			}
+		})
+	}
+}
+
+func TestRender(t *testing.T) {
+	testCases := []struct {
+		name    string
+		src     string
+		want    []string
+		wantNot []string
+	}{
+		{name: "原始 HTML", src: "<script>alert(1)</script>\n\n正文 <img src=x onerror=alert(1)>",
+			want: []string{"正文"}, wantNot: []string{"<script", "onerror"}},
+		{name: "javascript 链接", src: "[点击](javascript:alert(1)) ![图](javascript:alert(2))",
+			want: []string{"点击"}, wantNot: []string{"javascript:"}},
+		{name: "代码块", src: "```go\nfmt.Println(\"<b>\")\n```",
+			want: []string{`<code class="language-go">`, "&lt;b&gt;"}, wantNot: []string{"<b>"}},
+		{name: "任务列表", src: "- [x] 完成\n- [ ] 未完成",
+			want: []string{`checked=""`, `type="checkbox"`, "完成"}},
+		{name: "GFM 表格和删除线", src: "| a |\n| - |\n| b |\n\n~~删除~~",
+			want: []string{"<table>", "<td>b</td>", "<del>删除</del>"}},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			res, err := Render(tc.src)
+			if err != nil {
+				t.Fatal(err)
+			}
+			for _, s := range tc.want {
+				if !strings.Contains(res.HTML, s) {
+					t.Errorf("缺少 %q：%s", s, res.HTML)
+				}
+			}
+			for _, s := range tc.wantNot {
+				if strings.Contains(strings.ToLower(res.HTML), s) {
+					t.Errorf("没有过滤 %q：%s", s, res.HTML)
+				}
+			}
+		})
+	}
+}
+
+func TestHeadingIds(t *testing.T) {
+	testCases := []struct {
+		name string
+		src  string
+		want []Heading
+	}{
+		{name: "中文标题", src: "# 快速 开始\n## 安装_步骤",
+			want: []Heading{{Level: 1, Id: "快速-开始", Text: "快速 开始"}, {Level: 2, Id: "安装_步骤", Text: "安装_步骤"}}},
+		{name: "重复的标题", src: "## 示例\n## 示例\n## 示例",
+			want: []Heading{{Level: 2, Id: "示例", Text: "示例"}, {Level: 2, Id: "示例-1", Text: "示例"}, {Level: 2, Id: "示例-2", Text: "示例"}}},
+		{name: "大小写和标点", src: "# Hello, World!\n# hello world",
+			want: []Heading{{Level: 1, Id: "hello-world", Text: "Hello, World!"}, {Level: 1, Id: "hello-world-1", Text: "hello world"}}},
+		{name: "没有字母和数字", src: "# !!!\n# ???",
+			want: []Heading{{Level: 1, Id: "heading", Text: "!!!"}, {Level: 1, Id: "heading-1", Text: "???"}}},
+		{name: "标题中的标记", src: "### **粗体** 和 `代码`",
+			want: []Heading{{Level: 3, Id: "粗体-和-代码", Text: "粗体 和 代码"}}},
+		{name: "没有标题", src: "正文"},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			res, err := Render(tc.src)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(res.TOC, tc.want) {
+				t.Fatalf("目录错误：%+v", res.TOC)
+			}
+			// 目录中的 id 和 HTML 中的 id 一致
+			for _, h := range tc.want {
+				if !strings.Contains(res.HTML, `id="`+h.Id+`"`) {
+					t.Errorf("HTML 中缺少 id %q：%s", h.Id, res.HTML)
+				}
+			}
+		})
+	}
+
+	// 每次渲染使用独立的生成器，不会受到上一次渲染的影响
+	res, err := Render("## 示例")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.TOC[0].Id != "示例" {
+		t.Fatalf("id 错误：%q", res.TOC[0].Id)
+	}
+}
+
+func TestAbstract(t *testing.T) {
+	testCases := []struct {
+		name string
+		src  string
+		n    int
+		want string
+	}{
+		{name: "去掉标记", src: "# 标题\n\n**粗体**和[链接](https://a.com)\n换行", n: 100, want: "标题 粗体和链接 换行"},
+		{name: "跳过代码块、图片和原始 HTML", src: "正文\n\n```go\ncode\n```\n\n![图片](a.png)<b>加粗</b>\n\n<div>块</div>\n\n结尾", n: 100, want: "正文 加粗 结尾"},
+		{name: "按字符截取中文", src: "你好世界，欢迎", n: 4, want: "你好世界"},
+		{name: "按字符截取 emoji", src: "😀😃😄😁", n: 3, want: "😀😃😄"},
+		{name: "不足 n 个字符", src: "abc", n: 5, want: "abc"},
+		{name: "n 为 0", src: "abc", n: 0, want: ""},
+		{name: "空内容", src: "", n: 5, want: ""},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			got := Abstract(tc.src, tc.n)
+			if got != tc.want {
+				t.Fatalf("期望 %q，实际 %q", tc.want, got)
+			}
+			if !utf8.ValidString(got) {
+				t.Fatalf("截断了字符：%q", got)
+			}
+		})
+	}
+
+	// 渲染结果中的摘要最多 AbstractLength 个字符
+	res, err := Render(strings.Repeat("中", AbstractLength+10))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if utf8.RuneCountInString(res.Abstract) != AbstractLength || !utf8.ValidString(res.Abstract) {
+		t.Fatalf("摘要长度错误：%d", utf8.RuneCountInString(res.Abstract))
+	}
+}
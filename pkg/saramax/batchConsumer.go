@@ -0,0 +1,127 @@
+package samarax
+
+import (
+	"encoding/json"
+	"log"
+	"time"
+
+	"github.com/IBM/sarama"
+)
+
+// BatchConsumer 批量消费，攒够 batchSize 条消息或者等待 interval 之后，调用一次 fn
+type BatchConsumer[T any] struct {
+	fn        func(msgs []*sarama.ConsumerMessage, events []T) error
+	batchSize int
+	interval  time.Duration
+
+	// 死信队列，producer 为 nil 时，失败的消息只记录日志
+	producer sarama.SyncProducer
+	retry    RetryConfig
+}
+
+func NewBatchConsumer[T any](fn func(msgs []*sarama.ConsumerMessage, events []T) error, batchSize int, interval time.Duration) *BatchConsumer[T] {
+	return &BatchConsumer[T]{
+		fn:        fn,
+		batchSize: batchSize,
+		interval:  interval,
+	}
+}
+
+// WithDLQ 批量处理失败时按照 cfg 重试，重试耗尽后把整批消息投递到 <topic>.dlq
+func (h *BatchConsumer[T]) WithDLQ(producer sarama.SyncProducer, cfg RetryConfig) *BatchConsumer[T] {
+	h.producer = producer
+	h.retry = cfg
+	return h
+}
+
+func (h *BatchConsumer[T]) Setup(session sarama.ConsumerGroupSession) error {
+	return nil
+}
+
+func (h *BatchConsumer[T]) Cleanup(session sarama.ConsumerGroupSession) error {
+	return nil
+}
+
+func (h *BatchConsumer[T]) ConsumeClaim(session sarama.ConsumerGroupSession, claim sarama.ConsumerGroupClaim) error {
+
+	msgs := claim.Messages()
+	for {
+		batch := make([]*sarama.ConsumerMessage, 0, h.batchSize)
+		decoded := make([]*sarama.ConsumerMessage, 0, h.batchSize)
+		events := make([]T, 0, h.batchSize)
+		timer := time.NewTimer(h.interval)
+		stop, flush := false, false
+
+		// 攒批：消息数量达到 batchSize，或者超时，或者消费结束
+		for !flush && len(batch) < h.batchSize {
+			select {
+			case msg, ok := <-msgs:
+				if !ok {
+					stop, flush = true, true
+					break
+				}
+				batch = append(batch, msg)
+				var t T
+				if err := json.Unmarshal(msg.Value, &t); err != nil {
+					log.Println("反序列消息体失败:")
+					log.Println("topic", msg.Topic)
+					log.Println("partition", msg.Partition)
+					log.Println("offset", msg.Offset)
+					log.Println(err)
+					if err = h.deadLetter([]*sarama.ConsumerMessage{msg}, err, 0); err != nil {
+						timer.Stop()
+						return err
+					}
+					continue
+				}
+				decoded = append(decoded, msg)
+				events = append(events, t)
+			case <-timer.C:
+				flush = true
+			case <-session.Context().Done():
+				// 消费组关闭或 rebalance，先处理已经拉取的消息
+				stop, flush = true, true
+			}
+		}
+		timer.Stop()
+
+		// 处理这一批消息
+		if len(events) > 0 {
+			err := retry(session.Context(), h.retry, func() error {
+				return h.fn(decoded, events)
+			})
+			if err != nil && session.Context().Err() != nil {
+				// 消费被中断，不投递死信队列
+				return err
+			}
+			if err != nil {
+				log.Println("批量处理消息失败")
+				log.Println("topic", claim.Topic())
+				log.Println("partition", claim.Partition())
+				log.Println("offset", batch[0].Offset, "-", batch[len(batch)-1].Offset)
+				log.Println(err)
+				if err = h.deadLetter(decoded, err, h.retry.MaxRetries); err != nil {
+					return err
+				}
+			}
+		}
+		for _, msg := range batch {
+			session.MarkMessage(msg, "")
+		}
+
+		if stop {
+			return nil
+		}
+	}
+}
+
+func (h *BatchConsumer[T]) deadLetter(msgs []*sarama.ConsumerMessage, cause error, retries int) error {
+	if h.producer == nil {
+		return nil
+	}
+	err := sendToDLQ(h.producer, msgs, cause, retries)
+	if err != nil {
+		log.Println("投递死信队列失败", msgs[0].Topic, msgs[0].Partition, msgs[0].Offset, err)
+	}
+	return err
+}
@@ -9,12 +9,23 @@ import (
 
 type Consumer[T any] struct {
 	fn func(msg *sarama.ConsumerMessage, event T) error
+
+	// 死信队列，producer 为 nil 时，失败的消息只记录日志
+	producer sarama.SyncProducer
+	retry    RetryConfig
 }
 
 func NewConsumer[T any](fn func(msg *sarama.ConsumerMessage, event T) error) *Consumer[T] {
 	return &Consumer[T]{fn: fn}
 }
 
+// WithDLQ 处理失败时按照 cfg 重试，重试耗尽后投递到 <topic>.dlq
+func (h *Consumer[T]) WithDLQ(producer sarama.SyncProducer, cfg RetryConfig) *Consumer[T] {
+	h.producer = producer
+	h.retry = cfg
+	return h
+}
+
 func (h *Consumer[T]) Setup(session sarama.ConsumerGroupSession) error {
 	return nil
 }
@@ -24,27 +35,68 @@ func (h *Consumer[T]) Cleanup(session sarama.ConsumerGroupSession) error {
 }
 
 func (h *Consumer[T]) ConsumeClaim(session sarama.ConsumerGroupSession, claim sarama.ConsumerGroupClaim) error {
-	
+
 	msgs := claim.Messages()
-	for msg := range msgs {
-		var t T
-		err := json.Unmarshal(msg.Value, &t)
-		if err != nil {
-			log.Println("反序列消息体失败:")
-			log.Println("topic", msg.Topic)
-			log.Println("partition", msg.Partition)
-			log.Println("offset", msg.Offset)
-			log.Println(err)
+	for {
+		select {
+		case msg, ok := <-msgs:
+			if !ok {
+				return nil
+			}
+			if err := h.handle(session, msg); err != nil {
+				// 没有标记的消息，会在重新加入消费组后再次消费
+				return err
+			}
+			session.MarkMessage(msg, "")
+		case <-session.Context().Done():
+			// 消费组关闭或 rebalance，未处理的消息会在下次分配时重新消费
+			return nil
 		}
-		err = h.fn(msg, t)
-		if err != nil {
-			log.Println("处理消息失败")
-			log.Println("topic", msg.Topic)
-			log.Println("partition", msg.Partition)
-			log.Println("offset", msg.Offset)
-			log.Println(err)
-		}
-		session.MarkMessage(msg, "")
+	}
+}
+
+/*
+handle 处理一条消息：
+反序列化失败的消息不会重试，处理失败的消息按照配置重试，两者最终都投递到死信队列；
+只有在消费被中断或者死信队列投递失败时，才返回 error
+*/
+func (h *Consumer[T]) handle(session sarama.ConsumerGroupSession, msg *sarama.ConsumerMessage) error {
+	var t T
+	err := json.Unmarshal(msg.Value, &t)
+	if err != nil {
+		log.Println("反序列消息体失败:")
+		log.Println("topic", msg.Topic)
+		log.Println("partition", msg.Partition)
+		log.Println("offset", msg.Offset)
+		log.Println(err)
+		return h.deadLetter(msg, err, 0)
+	}
+
+	err = retry(session.Context(), h.retry, func() error {
+		return h.fn(msg, t)
+	})
+	if err != nil && session.Context().Err() != nil {
+		// 消费被中断，不投递死信队列
+		return err
+	}
+	if err != nil {
+		log.Println("处理消息失败")
+		log.Println("topic", msg.Topic)
+		log.Println("partition", msg.Partition)
+		log.Println("offset", msg.Offset)
+		log.Println(err)
+		return h.deadLetter(msg, err, h.retry.MaxRetries)
 	}
 	return nil
-}
\ No newline at end of file
+}
+
+func (h *Consumer[T]) deadLetter(msg *sarama.ConsumerMessage, cause error, retries int) error {
+	if h.producer == nil {
+		return nil
+	}
+	err := sendToDLQ(h.producer, []*sarama.ConsumerMessage{msg}, cause, retries)
+	if err != nil {
+		log.Println("投递死信队列失败", msg.Topic, msg.Partition, msg.Offset, err)
+	}
+	return err
+}
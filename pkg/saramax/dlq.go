@@ -0,0 +1,179 @@
+package samarax
+
+import (
+	"context"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/IBM/sarama"
+)
+
+// 死信队列的 topic 后缀，以及死信消息携带的元数据
+const (
+	DLQSuffix = ".dlq"
+
+	HeaderOriginalTopic     = "x-original-topic"
+	HeaderOriginalPartition = "x-original-partition"
+	HeaderOriginalOffset    = "x-original-offset"
+	HeaderError             = "x-error"
+	HeaderRetries           = "x-retries"
+	HeaderFailedAt          = "x-failed-at"
+)
+
+// RetryConfig 重试配置，每次重试的退避时间翻倍，最多为 MaxBackoff
+type RetryConfig struct {
+	MaxRetries int
+	Backoff    time.Duration
+	MaxBackoff time.Duration
+}
+
+var DefaultRetryConfig = RetryConfig{
+	MaxRetries: 3,
+	Backoff:    100 * time.Millisecond,
+	MaxBackoff: 2 * time.Second,
+}
+
+// DLQTopic 获取死信队列的 topic
+func DLQTopic(topic string) string {
+	return topic + DLQSuffix
+}
+
+// OriginalTopic 获取死信消息原本的 topic，优先使用消息头
+func OriginalTopic(msg *sarama.ConsumerMessage) string {
+	for _, h := range msg.Headers {
+		if string(h.Key) == HeaderOriginalTopic {
+			return string(h.Value)
+		}
+	}
+	return strings.TrimSuffix(msg.Topic, DLQSuffix)
+}
+
+/*
+retry 按照配置重试 fn：
+返回最后一次的错误；如果在退避期间 ctx 被取消，则返回 ctx.Err()
+*/
+func retry(ctx context.Context, cfg RetryConfig, fn func() error) error {
+	err := fn()
+	backoff := cfg.Backoff
+	for i := 0; err != nil && i < cfg.MaxRetries; i++ {
+		select {
+		case <-time.After(backoff):
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+		backoff = min(backoff*2, cfg.MaxBackoff)
+		err = fn()
+	}
+	return err
+}
+
+// sendToDLQ 把原始消息和错误信息投递到死信队列
+func sendToDLQ(producer sarama.SyncProducer, msgs []*sarama.ConsumerMessage, cause error, retries int) error {
+	now := strconv.FormatInt(time.Now().UnixMilli(), 10)
+	dlqMsgs := make([]*sarama.ProducerMessage, 0, len(msgs))
+	for _, msg := range msgs {
+		dlqMsg := &sarama.ProducerMessage{
+			Topic: DLQTopic(msg.Topic),
+			Value: sarama.ByteEncoder(msg.Value),
+			Headers: []sarama.RecordHeader{
+				{Key: []byte(HeaderOriginalTopic), Value: []byte(msg.Topic)},
+				{Key: []byte(HeaderOriginalPartition), Value: []byte(strconv.FormatInt(int64(msg.Partition), 10))},
+				{Key: []byte(HeaderOriginalOffset), Value: []byte(strconv.FormatInt(msg.Offset, 10))},
+				{Key: []byte(HeaderError), Value: []byte(cause.Error())},
+				{Key: []byte(HeaderRetries), Value: []byte(strconv.Itoa(retries))},
+				{Key: []byte(HeaderFailedAt), Value: []byte(now)},
+			},
+		}
+		if msg.Key != nil {
+			dlqMsg.Key = sarama.ByteEncoder(msg.Key)
+		}
+		dlqMsgs = append(dlqMsgs, dlqMsg)
+	}
+	return producer.SendMessages(dlqMsgs)
+}
+
+/*
+ReplayDLQ 把死信队列中的消息重新投递到原本的 topic：
+使用 group 记录每个分区的重放进度，只重放启动时已经存在的消息，返回重放的消息数量
+*/
+func ReplayDLQ(client sarama.Client, producer sarama.SyncProducer, dlqTopic, group string) (int, error) {
+
+	consumer, err := sarama.NewConsumerFromClient(client)
+	if err != nil {
+		return 0, err
+	}
+	defer consumer.Close()
+
+	om, err := sarama.NewOffsetManagerFromClient(group, client)
+	if err != nil {
+		return 0, err
+	}
+	defer om.Close()
+
+	partitions, err := client.Partitions(dlqTopic)
+	if err != nil {
+		return 0, err
+	}
+
+	total := 0
+	for _, partition := range partitions {
+		n, err := replayPartition(client, consumer, om, producer, dlqTopic, partition)
+		total += n
+		if err != nil {
+			return total, err
+		}
+	}
+	return total, nil
+}
+
+func replayPartition(client sarama.Client, consumer sarama.Consumer, om sarama.OffsetManager, producer sarama.SyncProducer,
+	dlqTopic string, partition int32) (int, error) {
+
+	pom, err := om.ManagePartition(dlqTopic, partition)
+	if err != nil {
+		return 0, err
+	}
+	defer pom.Close()
+
+	// 重放范围：[上次重放的位置, 启动时的最新位置)
+	end, err := client.GetOffset(dlqTopic, partition, sarama.OffsetNewest)
+	if err != nil {
+		return 0, err
+	}
+	start, _ := pom.NextOffset()
+	if start < 0 {
+		if start, err = client.GetOffset(dlqTopic, partition, sarama.OffsetOldest); err != nil {
+			return 0, err
+		}
+	}
+	if start >= end {
+		return 0, nil
+	}
+
+	pc, err := consumer.ConsumePartition(dlqTopic, partition, start)
+	if err != nil {
+		return 0, err
+	}
+	defer pc.Close()
+
+	n := 0
+	for msg := range pc.Messages() {
+		replay := &sarama.ProducerMessage{
+			Topic: OriginalTopic(msg),
+			Value: sarama.ByteEncoder(msg.Value),
+		}
+		if msg.Key != nil {
+			replay.Key = sarama.ByteEncoder(msg.Key)
+		}
+		if _, _, err = producer.SendMessage(replay); err != nil {
+			return n, err
+		}
+		pom.MarkOffset(msg.Offset+1, "")
+		n++
+		if msg.Offset+1 >= end {
+			break
+		}
+	}
+	return n, nil
+}
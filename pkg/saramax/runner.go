@@ -0,0 +1,53 @@
+package samarax
+
+import (
+	"context"
+	"errors"
+	"log"
+
+	"github.com/IBM/sarama"
+)
+
+// GroupRunner 在 goroutine 中循环消费，直到被关闭
+type GroupRunner struct {
+	cg     sarama.ConsumerGroup
+	cancel context.CancelFunc
+	done   chan struct{}
+}
+
+/*
+StartGroup 启动消费循环：
+每次 rebalance 之后 Consume 都会返回，所以需要在循环中重新加入消费组
+*/
+func StartGroup(cg sarama.ConsumerGroup, topics []string, handler sarama.ConsumerGroupHandler) *GroupRunner {
+	ctx, cancel := context.WithCancel(context.Background())
+	r := &GroupRunner{
+		cg:     cg,
+		cancel: cancel,
+		done:   make(chan struct{}),
+	}
+
+	go func() {
+		defer close(r.done)
+		for {
+			err := cg.Consume(ctx, topics, handler)
+			if errors.Is(err, sarama.ErrClosedConsumerGroup) || ctx.Err() != nil {
+				return
+			}
+			if err != nil {
+				log.Println("消费循环异常", topics, err)
+			}
+		}
+	}()
+	return r
+}
+
+/*
+Close 关闭消费组：
+先取消上下文，等待正在处理的消息完成并提交位移，再关闭消费组
+*/
+func (r *GroupRunner) Close() error {
+	r.cancel()
+	<-r.done
+	return r.cg.Close()
+}
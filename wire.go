@@ -3,21 +3,29 @@
 package main
 
 import (
+	"github.com/Linxhhh/webook/config"
 	"github.com/Linxhhh/webook/internal/app"
+	"github.com/Linxhhh/webook/internal/job"
 	"github.com/Linxhhh/webook/internal/repository"
 	"github.com/Linxhhh/webook/internal/repository/cache"
 	"github.com/Linxhhh/webook/internal/repository/dao"
 	"github.com/Linxhhh/webook/internal/service"
+	"github.com/Linxhhh/webook/internal/service/sms"
+	"github.com/Linxhhh/webook/internal/service/sms/async"
 	"github.com/Linxhhh/webook/internal/events"
 	"github.com/Linxhhh/webook/ioc"
-	"github.com/gin-gonic/gin"
 	"github.com/google/wire"
 )
 
-func InitWebServer() *gin.Engine {
+func InitApp(cfg *config.Config) *App {
 	wire.Build(
+		// 配置
+		wire.FieldsOf(new(*config.Config), "DB", "Redis", "Kafka", "JWT", "OAuth2", "Email", "SMS", "RateLimit", "Moderation", "Admin", "Report"),
+
 		// 第三方依赖
-		ioc.InitCache, ioc.InitDB, ioc.InitSmsService, ioc.InitSaramaClient, ioc.InitSyncProducer,
+		ioc.InitCache, ioc.InitDB, ioc.InitSmsService, ioc.InitEmailService, ioc.InitSaramaClient, ioc.InitSyncProducer, ioc.InitJWT,
+		ioc.InitOAuth2Services, ioc.InitModerator,
+		wire.Bind(new(sms.Service), new(*async.Service)),
 
 		// DAO
 		dao.NewUserDAO,
@@ -26,6 +34,15 @@ func InitWebServer() *gin.Engine {
 		dao.NewFollowDAO,
 		dao.NewFeedPushEventDAO,
 		dao.NewFeedPullEventDAO,
+		dao.NewNotificationDAO,
+		dao.NewCommentDAO,
+		dao.NewSessionDAO,
+		dao.NewAccountDAO,
+		dao.NewAsyncSmsDAO,
+		dao.NewLoginLockoutDAO,
+		dao.NewReportDAO,
+		dao.NewArticleRevisionDAO,
+		dao.NewLockDAO,
 
 		// Cache
 		cache.NewUserCache,
@@ -34,6 +51,10 @@ func InitWebServer() *gin.Engine {
 		cache.NewInteractionCache,
 		cache.NewFollowCache,
 		cache.NewFeedEventCache,
+		cache.NewNotificationCache,
+		cache.NewSessionCache,
+		cache.NewOAuth2StateCache,
+		cache.NewLoginGuardCache,
 
 		// Repository
 		repository.NewUserRepository,
@@ -42,6 +63,16 @@ func InitWebServer() *gin.Engine {
 		repository.NewInteractionRepository,
 		repository.NewFollowRepository,
 		repository.NewFeedEventRepo,
+		repository.NewNotificationRepository,
+		repository.NewCommentRepository,
+		repository.NewSessionRepository,
+		repository.NewOAuth2StateRepository,
+		repository.NewAccountRepository,
+		repository.NewAsyncSmsRepository,
+		repository.NewLoginGuardRepository,
+		repository.NewReportRepository,
+		repository.NewArticleRevisionRepository,
+		repository.NewLockRepository,
 
 		// Service
 		service.NewUserService,
@@ -50,21 +81,52 @@ func InitWebServer() *gin.Engine {
 		service.NewInteractionService,
 		service.NewFollowService,
 		service.NewFeedEventService,
+		service.NewNotificationService,
+		service.NewCommentService,
+		service.NewSessionService,
+		service.NewOAuth2Service,
+		service.NewAccountService,
+		service.NewLoginGuardService,
+		service.NewAdminService,
+		ioc.InitReportService,
 
 		// Event
 		events.NewArticleEventProducer,
 		events.NewArticleEventConsumer,
+		events.NewArticleReviewConsumer,
+		events.NewSaramaSyncProducer,
+		ioc.InitReadEventConsumer,
+		events.NewInteractionEventProducer,
+		events.NewInteractionEventConsumer,
+		events.NewFollowEventProducer,
+		events.NewFollowEventConsumer,
+		events.NewNotificationEventConsumer,
+		events.NewCommentEventProducer,
 		ioc.InitConsumers,
 
+		// Job
+		job.NewArticleScheduler,
+		ioc.InitJobs,
+
 		// Handler
 		app.NewUserHandler,
 		app.NewArticleHandler,
 		app.NewFollowHandler,
+		app.NewFeedHandler,
+		app.NewNotificationHandler,
+		app.NewCommentHandler,
+		app.NewOAuth2Handler,
+		app.NewAccountHandler,
+		app.NewAdminHandler,
+		app.NewReportHandler,
 
 		// Webserver
 		ioc.InitMiddleware,
-		ioc.InitWebServer,
+		ioc.InitEngine,
+
+		// App
+		wire.Struct(new(App), "*"),
 	)
 
-	return gin.Default()
+	return new(App)
 }
\ No newline at end of file
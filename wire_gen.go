@@ -1,29 +1,28 @@
 package main
 
 import (
+	"github.com/Linxhhh/webook/config"
 	"github.com/Linxhhh/webook/internal/app"
 	"github.com/Linxhhh/webook/internal/events"
+	"github.com/Linxhhh/webook/internal/job"
 	"github.com/Linxhhh/webook/internal/repository"
 	"github.com/Linxhhh/webook/internal/repository/cache"
 	"github.com/Linxhhh/webook/internal/repository/dao"
 	"github.com/Linxhhh/webook/internal/service"
 	"github.com/Linxhhh/webook/ioc"
-	"github.com/gin-gonic/gin"
 )
 
-type WebServer struct {
-	engine    *gin.Engine
-	consumers []events.Consumer
-}
-
-func InitWebServer() WebServer {
+func InitApp(cfg *config.Config) *App {
 
 	// 第三方依赖
-	m, s := ioc.InitDB()
-	cmdable := ioc.InitCache()
-	smsService := ioc.InitSmsService()
-	sclient := ioc.InitSaramaClient()
+	m, s := ioc.InitDB(cfg.DB)
+	cmdable := ioc.InitCache(cfg.Redis)
+	emailService := ioc.InitEmailService(cfg.Email)
+	sclient := ioc.InitSaramaClient(cfg.Kafka)
 	sproducer := ioc.InitSyncProducer(sclient)
+	jwt := ioc.InitJWT(cfg.JWT)
+	oauth2Services := ioc.InitOAuth2Services(cfg.OAuth2)
+	moderator := ioc.InitModerator(cfg.Moderation)
 
 	// DAO
 	userDAO := dao.NewUserDAO(m, s)
@@ -32,6 +31,15 @@ func InitWebServer() WebServer {
 	followDAO := dao.NewFollowDAO(m, s)
 	feedPullEventDAO := dao.NewFeedPullEventDAO(m, s)
 	feedPushEventDAO := dao.NewFeedPushEventDAO(m, s)
+	notificationDAO := dao.NewNotificationDAO(m, s)
+	commentDAO := dao.NewCommentDAO(m, s)
+	sessionDAO := dao.NewSessionDAO(m, s)
+	accountDAO := dao.NewAccountDAO(m)
+	asyncSmsDAO := dao.NewAsyncSmsDAO(m)
+	loginLockoutDAO := dao.NewLoginLockoutDAO(m)
+	reportDAO := dao.NewReportDAO(m)
+	articleRevisionDAO := dao.NewArticleRevisionDAO(m)
+	lockDAO := dao.NewLockDAO(m)
 
 	// Cache
 	userCache := cache.NewUserCache(cmdable)
@@ -40,6 +48,10 @@ func InitWebServer() WebServer {
 	interactionCache := cache.NewInteractionCache(cmdable)
 	followCache := cache.NewFollowCache(cmdable)
 	feedEventCache := cache.NewFeedEventCache(cmdable)
+	notificationCache := cache.NewNotificationCache(cmdable)
+	sessionCache := cache.NewSessionCache(cmdable)
+	oauth2StateCache := cache.NewOAuth2StateCache(cmdable)
+	loginGuardCache := cache.NewLoginGuardCache(cmdable)
 
 	// Repository
 	userRepository := repository.NewUserRepository(userDAO, userCache)
@@ -48,31 +60,73 @@ func InitWebServer() WebServer {
 	interactionRepository := repository.NewInteractionRepository(interactionDAO, interactionCache)
 	followRepository := repository.NewFollowRepository(followDAO, followCache)
 	feedEventRepository := repository.NewFeedEventRepo(feedPullEventDAO, feedPushEventDAO, feedEventCache)
+	notificationRepository := repository.NewNotificationRepository(notificationDAO, notificationCache)
+	commentRepository := repository.NewCommentRepository(commentDAO, interactionCache)
+	sessionRepository := repository.NewSessionRepository(sessionDAO, sessionCache)
+	oauth2StateRepository := repository.NewOAuth2StateRepository(oauth2StateCache)
+	accountRepository := repository.NewAccountRepository(accountDAO, userCache, articleCache, followCache, interactionCache)
+	asyncSmsRepository := repository.NewAsyncSmsRepository(asyncSmsDAO)
+	loginGuardRepository := repository.NewLoginGuardRepository(loginLockoutDAO, loginGuardCache)
+	reportRepository := repository.NewReportRepository(reportDAO)
+	articleRevisionRepository := repository.NewArticleRevisionRepository(articleRevisionDAO)
+	lockRepository := repository.NewLockRepository(lockDAO)
+
+	// 短信服务
+	smsService := ioc.InitSmsService(cfg.SMS, cmdable, asyncSmsRepository)
 
 	// Service
 	userService := service.NewUserService(userRepository)
-	codeService := service.NewCodeService(codeRepository, smsService)
-	articleService := service.NewArticleService(articleRepository, userRepository)
+	codeService := service.NewCodeService(codeRepository, smsService, emailService)
+	articleService := service.NewArticleService(articleRepository, articleRevisionRepository, userRepository, moderator)
 	interactionService := service.NewInteractionService(interactionRepository, articleRepository)
 	followService := service.NewFollowService(followRepository)
-	feedEventService := service.NewFeedEventService(feedEventRepository, followRepository)
+	feedEventService := service.NewFeedEventService(feedEventRepository, followRepository, articleRepository, userRepository, interactionRepository)
+	notificationService := service.NewNotificationService(notificationRepository, userRepository, articleRepository)
+	commentService := service.NewCommentService(commentRepository, userRepository, articleRepository)
+	sessionService := service.NewSessionService(sessionRepository)
+	oauth2Service := service.NewOAuth2Service(oauth2Services, oauth2StateRepository, userService)
+	accountService := service.NewAccountService(accountRepository, userRepository, sessionService)
+	loginGuardService := service.NewLoginGuardService(loginGuardRepository)
+	adminService := service.NewAdminService(userRepository, articleRepository, commentRepository, reportRepository, sessionService)
+	reportService := ioc.InitReportService(reportRepository, articleRepository, commentRepository, userRepository, cfg.Report)
 
 	// Event
 	articleEventProducer := events.NewArticleEventProducer(sproducer)
-	articleEventConsumer := events.NewArticleEventConsumer(sclient, feedEventService)
+	articleEventConsumer := events.NewArticleEventConsumer(sclient, sproducer, feedEventService)
+	articleReviewConsumer := events.NewArticleReviewConsumer(sclient, sproducer, articleService, articleEventProducer)
+	readProducer := events.NewSaramaSyncProducer(sproducer)
+	readEventConsumer := ioc.InitReadEventConsumer(interactionRepository, sclient, sproducer, cfg.Kafka)
+	interactionEventProducer := events.NewInteractionEventProducer(sproducer)
+	interactionEventConsumer := events.NewInteractionEventConsumer(sclient, sproducer, feedEventService)
+	followEventProducer := events.NewFollowEventProducer(sproducer)
+	followEventConsumer := events.NewFollowEventConsumer(sclient, sproducer, feedEventService)
+	notificationEventConsumer := events.NewNotificationEventConsumer(sclient, sproducer, notificationService)
+	commentEventProducer := events.NewCommentEventProducer(sproducer)
 
 	// Handler
-	userHandler := app.NewUserHandler(userService, codeService)
-	articleHandler := app.NewArticleHandler(articleService, interactionService, articleEventProducer)
-	followHandler := app.NewFollowHandler(followService)
+	userHandler := app.NewUserHandler(userService, codeService, sessionService, loginGuardService, jwt)
+	articleHandler := app.NewArticleHandler(articleService, interactionService, articleEventProducer, readProducer, interactionEventProducer)
+	followHandler := app.NewFollowHandler(followService, followEventProducer)
+	feedHandler := app.NewFeedHandler(feedEventService)
+	notificationHandler := app.NewNotificationHandler(notificationService)
+	commentHandler := app.NewCommentHandler(commentService, commentEventProducer)
+	oauth2Handler := app.NewOAuth2Handler(oauth2Service, userService, sessionService, jwt)
+	accountHandler := app.NewAccountHandler(userService, codeService, accountService)
+	adminHandler := app.NewAdminHandler(adminService)
+	reportHandler := app.NewReportHandler(reportService)
 
 	// Webserver
-	v := ioc.InitMiddleware()
-	engine := ioc.InitEngine(v, userHandler, articleHandler, followHandler)
-	consumers := ioc.InitConsumers(articleEventConsumer)
-	
-	return WebServer{
-		engine: engine,
-		consumers: consumers,
+	v := ioc.InitMiddleware(jwt, sessionService, cmdable, cfg.RateLimit)
+	engine := ioc.InitEngine(v, userHandler, articleHandler, followHandler, feedHandler, notificationHandler, commentHandler, oauth2Handler, accountHandler, adminHandler, reportHandler)
+	consumers := ioc.InitConsumers(articleEventConsumer, articleReviewConsumer, readEventConsumer, interactionEventConsumer, followEventConsumer, notificationEventConsumer)
+	articleScheduler := job.NewArticleScheduler(articleService, lockRepository, articleEventProducer)
+	jobs := ioc.InitJobs(smsService, articleScheduler)
+
+	return &App{
+		Server:    engine,
+		Consumers: consumers,
+		Jobs:      jobs,
+		AdminSvc:  adminService,
+		AdminCfg:  cfg.Admin,
 	}
 }